@@ -0,0 +1,790 @@
+// Package attack разбирает STIX-бандлы MITRE ATT&CK и отвечает на запросы
+// «митигация → техники» без зависимости от CLI mitremit.
+//
+//	idx, err := attack.ParseBundle(raw)
+//	if err != nil { ... }
+//	techs, ok := idx.TechniquesForMitigation("M1037")
+package attack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+-------------------------------------------------------------
+Minimal STIX structures we need
+-------------------------------------------------------------
+*/
+type Bundle struct {
+	Type        string            `json:"type"`
+	SpecVersion string            `json:"spec_version"`
+	Objects     []json.RawMessage `json:"objects"`
+}
+
+// envelope – only type and id are required for the first pass
+type baseObject struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Kill chain phase (ATT&CK tactic)
+type KillChainPhase struct {
+	KillChainName string `json:"kill_chain_name"`
+	PhaseName     string `json:"phase_name"`
+}
+
+// Technique / sub‑technique
+type AttackPattern struct {
+	Type            string              `json:"type"`
+	ID              string              `json:"id"`
+	Name            string              `json:"name"`
+	Description     string              `json:"description,omitempty"`
+	ExternalRefs    []ExternalReference `json:"external_references,omitempty"`
+	KillChainPhases []KillChainPhase    `json:"kill_chain_phases,omitempty"`
+	Platforms       []string            `json:"x_mitre_platforms,omitempty"`
+	DataSources     []string            `json:"x_mitre_data_sources,omitempty"` // "Источник: Компонент"
+	Created         string              `json:"created,omitempty"`
+	Modified        string              `json:"modified,omitempty"`
+	Revoked         bool                `json:"revoked,omitempty"`
+	Deprecated      bool                `json:"x_mitre_deprecated,omitempty"`
+}
+
+// Obsolete сообщает, что техника отозвана (revoked) или помечена устаревшей (x_mitre_deprecated).
+func (ap AttackPattern) Obsolete() bool {
+	return ap.Revoked || ap.Deprecated
+}
+
+// Mitigation
+type CourseOfAction struct {
+	Type         string              `json:"type"`
+	ID           string              `json:"id"`
+	Name         string              `json:"name"`
+	ExternalRefs []ExternalReference `json:"external_references,omitempty"`
+	Modified     string              `json:"modified,omitempty"`
+	Revoked      bool                `json:"revoked,omitempty"`
+	Deprecated   bool                `json:"x_mitre_deprecated,omitempty"`
+}
+
+// Obsolete сообщает, что митигация отозвана (revoked) или помечена устаревшей (x_mitre_deprecated).
+func (co CourseOfAction) Obsolete() bool {
+	return co.Revoked || co.Deprecated
+}
+
+// Group (intrusion set)
+type IntrusionSet struct {
+	Type         string              `json:"type"`
+	ID           string              `json:"id"`
+	Name         string              `json:"name"`
+	Modified     string              `json:"modified,omitempty"`
+	Revoked      bool                `json:"revoked,omitempty"`
+	Deprecated   bool                `json:"x_mitre_deprecated,omitempty"`
+	ExternalRefs []ExternalReference `json:"external_references,omitempty"`
+}
+
+// Obsolete сообщает, что группа отозвана (revoked) или помечена устаревшей (x_mitre_deprecated).
+func (is IntrusionSet) Obsolete() bool {
+	return is.Revoked || is.Deprecated
+}
+
+// Software: вредоносное ПО (malware) или инструмент (tool)
+type Software struct {
+	Type         string              `json:"type"`
+	ID           string              `json:"id"`
+	Name         string              `json:"name"`
+	ExternalRefs []ExternalReference `json:"external_references,omitempty"`
+}
+
+// Campaign — кампания (набор операций за период), связанная с техниками отношением "uses"
+type Campaign struct {
+	Type         string              `json:"type"`
+	ID           string              `json:"id"`
+	Name         string              `json:"name"`
+	ExternalRefs []ExternalReference `json:"external_references,omitempty"`
+}
+
+// Data source (x-mitre-data-source), например "Network Traffic"
+type DataSource struct {
+	Type         string              `json:"type"`
+	ID           string              `json:"id"`
+	Name         string              `json:"name"`
+	ExternalRefs []ExternalReference `json:"external_references,omitempty"`
+}
+
+// Data component (x-mitre-data-component) — часть источника данных, связанная с техниками
+// отношением "detects"
+type DataComponent struct {
+	Type          string `json:"type"`
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	DataSourceRef string `json:"x_mitre_data_source_ref,omitempty"`
+}
+
+// Tactic (x-mitre-tactic): отображаемое имя ("Defense Evasion") и shortname ("defense-evasion"),
+// который используется в kill_chain_phases техник
+type Tactic struct {
+	Type         string              `json:"type"`
+	ID           string              `json:"id"`
+	Name         string              `json:"name"`
+	ShortName    string              `json:"x_mitre_shortname"`
+	ExternalRefs []ExternalReference `json:"external_references,omitempty"`
+}
+
+// Relationship – "mitigates" (mitigation → technique), "uses" (group → technique), "revoked-by",
+// "detects" (data component → technique); источником "uses" может быть и software или campaign
+type Relationship struct {
+	Type             string `json:"type"`
+	ID               string `json:"id"`
+	RelationshipType string `json:"relationship_type"`
+	SourceRef        string `json:"source_ref"`            // mitigation / group
+	TargetRef        string `json:"target_ref"`            // technique
+	Description      string `json:"description,omitempty"` // для mitigates — как митигация применяется к технике
+}
+
+// External reference (the place where ATT&CK stores the human‑readable ID)
+type ExternalReference struct {
+	SourceName string `json:"source_name"` // "mitre-attack"
+	ExternalID string `json:"external_id"` // "T1059.001" or "M1037"
+	URL        string `json:"url,omitempty"`
+}
+
+/*
+-------------------------------------------------------------
+Helper – pull the ATT&CK external ID from a slice of refs
+-------------------------------------------------------------
+*/
+func ExternalID(refs []ExternalReference) (string, bool) {
+	if r, ok := ExternalRef(refs); ok {
+		return r.ExternalID, true
+	}
+	return "", false
+}
+
+// ExternalRef возвращает ссылку ATT&CK ("mitre-attack" с непустым ID) целиком — с URL.
+func ExternalRef(refs []ExternalReference) (ExternalReference, bool) {
+	for _, r := range refs {
+		if strings.EqualFold(r.SourceName, "mitre-attack") && r.ExternalID != "" {
+			return r, true
+		}
+	}
+	return ExternalReference{}, false
+}
+
+// TacticsFromKillChain возвращает phase_name из фаз с kill_chain_name == "mitre-attack" —
+// по алфавиту и без повторов: порядок kill_chain_phases в бандле не гарантирован, а от него не
+// должны зависеть диффы вывода.
+func TacticsFromKillChain(phases []KillChainPhase) []string {
+	var out []string
+	for _, p := range phases {
+		if strings.EqualFold(p.KillChainName, "mitre-attack") && p.PhaseName != "" {
+			out = append(out, p.PhaseName)
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+/*
+-------------------------------------------------------------
+Index: справочники объектов бандла
+-------------------------------------------------------------
+*/
+// Index — справочники объектов бандла, построенные за один проход по objects.
+type Index struct {
+	SpecVersion   string
+	AttackVersion string                    // x_mitre_version объекта x-mitre-collection ("15.1"), если он есть
+	Mitigations   map[string]CourseOfAction // key = STIX ID
+	Techniques    map[string]AttackPattern  // key = STIX ID
+	Groups        map[string]IntrusionSet   // key = STIX ID
+	Software      map[string]Software       // key = STIX ID; malware и tool
+	Campaigns     map[string]Campaign       // key = STIX ID
+	// Источники данных и их компоненты (связи "detects")
+	DataSources    map[string]DataSource    // key = STIX ID
+	DataComponents map[string]DataComponent // key = STIX ID
+	Tactics        map[string]Tactic        // key = STIX ID
+	Rels           []Relationship
+
+	// Обратный индекс связей "uses" (target_ref → source_ref), строится при первом UsersOf
+	usersOnce sync.Once
+	users     map[string][]string
+}
+
+// NewIndex возвращает пустой индекс.
+func NewIndex() *Index {
+	return &Index{
+		Mitigations:    make(map[string]CourseOfAction),
+		Techniques:     make(map[string]AttackPattern),
+		Groups:         make(map[string]IntrusionSet),
+		Software:       make(map[string]Software),
+		Campaigns:      make(map[string]Campaign),
+		DataSources:    make(map[string]DataSource),
+		DataComponents: make(map[string]DataComponent),
+		Tactics:        make(map[string]Tactic),
+	}
+}
+
+// ParseBundle разбирает бандл целиком в памяти.
+func ParseBundle(raw []byte) (*Index, error) {
+	var bundle Bundle
+	if err := json.Unmarshal(raw, &bundle); err != nil {
+		return nil, err
+	}
+	if bundle.Type != "bundle" {
+		return nil, notBundleError(bundle.Type)
+	}
+	return BuildIndex(bundle), nil
+}
+
+// notBundleError — ошибка для JSON, который не является STIX bundle.
+func notBundleError(typ string) error {
+	return fmt.Errorf("input is not a STIX bundle (type %q)", typ)
+}
+
+// BuildIndex раскладывает объекты бандла по справочникам параллельно, по горутине на CPU;
+// некорректные объекты пропускаются.
+func BuildIndex(bundle Bundle) *Index {
+	return BuildIndexParallel(bundle, runtime.NumCPU())
+}
+
+// BuildIndexParallel делит objects на workers непрерывных частей, разбирает каждую в своей
+// горутине в частичный индекс и сливает их по порядку частей. Поэтому при повторе STIX ID
+// побеждает последний объект в бандле, а связи сохраняют исходный порядок — как при
+// последовательном разборе (workers <= 1).
+func BuildIndexParallel(bundle Bundle, workers int) *Index {
+	workers = min(workers, len(bundle.Objects))
+	if workers <= 1 {
+		idx := NewIndex()
+		idx.SpecVersion = bundle.SpecVersion
+		for _, rawObj := range bundle.Objects {
+			idx.add(rawObj)
+		}
+		return idx
+	}
+
+	partials := make([]*Index, workers)
+	chunk := (len(bundle.Objects) + workers - 1) / workers
+	var wg sync.WaitGroup
+	for i := range partials {
+		part := NewIndex()
+		partials[i] = part
+		lo := min(i*chunk, len(bundle.Objects))
+		hi := min(lo+chunk, len(bundle.Objects))
+		wg.Add(1)
+		go func(objects []json.RawMessage) {
+			defer wg.Done()
+			for _, rawObj := range objects {
+				part.add(rawObj)
+			}
+		}(bundle.Objects[lo:hi])
+	}
+	wg.Wait()
+
+	idx := partials[0]
+	for _, part := range partials[1:] {
+		idx.merge(part)
+	}
+	idx.SpecVersion = bundle.SpecVersion
+	return idx
+}
+
+// add классифицирует один объект бандла по типу и кладёт его в соответствующий справочник.
+func (idx *Index) add(rawObj json.RawMessage) {
+	var bo baseObject
+	if err := json.Unmarshal(rawObj, &bo); err != nil {
+		return // ignore malformed entries
+	}
+	switch bo.Type {
+	case "course-of-action":
+		var co CourseOfAction
+		if err := json.Unmarshal(rawObj, &co); err == nil {
+			idx.Mitigations[co.ID] = co
+		}
+	case "attack-pattern":
+		var ap AttackPattern
+		if err := json.Unmarshal(rawObj, &ap); err == nil {
+			idx.Techniques[ap.ID] = ap
+		}
+	case "intrusion-set":
+		var is IntrusionSet
+		if err := json.Unmarshal(rawObj, &is); err == nil {
+			idx.Groups[is.ID] = is
+		}
+	case "malware", "tool":
+		var sw Software
+		if err := json.Unmarshal(rawObj, &sw); err == nil {
+			idx.Software[sw.ID] = sw
+		}
+	case "campaign":
+		var ca Campaign
+		if err := json.Unmarshal(rawObj, &ca); err == nil {
+			idx.Campaigns[ca.ID] = ca
+		}
+	case "x-mitre-data-source":
+		var ds DataSource
+		if err := json.Unmarshal(rawObj, &ds); err == nil {
+			idx.DataSources[ds.ID] = ds
+		}
+	case "x-mitre-data-component":
+		var dc DataComponent
+		if err := json.Unmarshal(rawObj, &dc); err == nil {
+			idx.DataComponents[dc.ID] = dc
+		}
+	case "x-mitre-tactic":
+		var ta Tactic
+		if err := json.Unmarshal(rawObj, &ta); err == nil {
+			idx.Tactics[ta.ID] = ta
+		}
+	case "x-mitre-collection":
+		var c struct {
+			Version string `json:"x_mitre_version"`
+		}
+		if err := json.Unmarshal(rawObj, &c); err == nil && c.Version != "" {
+			idx.AttackVersion = c.Version
+		}
+	case "relationship":
+		var r Relationship
+		if err := json.Unmarshal(rawObj, &r); err == nil {
+			idx.Rels = append(idx.Rels, r)
+		}
+	}
+}
+
+// merge переносит объекты other в idx (при совпадении STIX ID побеждает объект из other).
+func (idx *Index) merge(other *Index) {
+	for id, co := range other.Mitigations {
+		idx.Mitigations[id] = co
+	}
+	for id, ap := range other.Techniques {
+		idx.Techniques[id] = ap
+	}
+	for id, is := range other.Groups {
+		idx.Groups[id] = is
+	}
+	for id, sw := range other.Software {
+		idx.Software[id] = sw
+	}
+	for id, ca := range other.Campaigns {
+		idx.Campaigns[id] = ca
+	}
+	for id, ds := range other.DataSources {
+		idx.DataSources[id] = ds
+	}
+	for id, dc := range other.DataComponents {
+		idx.DataComponents[id] = dc
+	}
+	for id, ta := range other.Tactics {
+		idx.Tactics[id] = ta
+	}
+	if other.AttackVersion != "" {
+		idx.AttackVersion = other.AttackVersion
+	}
+	idx.Rels = append(idx.Rels, other.Rels...)
+}
+
+// UsersOf возвращает STIX ID объектов (групп, ПО, кампаний), связанных с targetSTIXID
+// отношением "uses", без повторов и в порядке связей бандла. Индекс target_ref → source_ref
+// строится один раз на весь Index, поэтому запрос для каждой техники результата не
+// пересматривает все связи заново.
+func (idx *Index) UsersOf(targetSTIXID string) []string {
+	idx.usersOnce.Do(func() {
+		idx.users = make(map[string][]string)
+		seen := make(map[[2]string]bool)
+		for _, r := range idx.Rels {
+			key := [2]string{r.TargetRef, r.SourceRef}
+			if r.RelationshipType != "uses" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			idx.users[r.TargetRef] = append(idx.users[r.TargetRef], r.SourceRef)
+		}
+	})
+	return idx.users[targetSTIXID]
+}
+
+// ReplacementOf возвращает технику, заменившую отозванную techSTIXID, — конец цепочки связей
+// revoked-by (цепочка с циклом или ведущая к неизвестному объекту даёт ok == false).
+func (idx *Index) ReplacementOf(techSTIXID string) (AttackPattern, bool) {
+	visited := map[string]bool{techSTIXID: true}
+	cur := techSTIXID
+	for {
+		next := ""
+		for _, r := range idx.Rels {
+			if r.RelationshipType == "revoked-by" && r.SourceRef == cur {
+				next = r.TargetRef
+				break
+			}
+		}
+		if next == "" {
+			break
+		}
+		if visited[next] {
+			return AttackPattern{}, false
+		}
+		visited[next] = true
+		cur = next
+	}
+	if cur == techSTIXID {
+		return AttackPattern{}, false
+	}
+	ap, ok := idx.Techniques[cur]
+	return ap, ok
+}
+
+// TacticDisplayNames возвращает отображаемые имена тактик по shortname
+// ("defense-evasion" -> "Defense Evasion").
+func (idx *Index) TacticDisplayNames() map[string]string {
+	names := make(map[string]string, len(idx.Tactics))
+	for _, ta := range idx.Tactics {
+		if ta.ShortName != "" && ta.Name != "" {
+			names[ta.ShortName] = ta.Name
+		}
+	}
+	return names
+}
+
+// NormalizeMitigationID дополняет голый номер префиксом "M" ("1037" -> "M1037", например ID из
+// таблицы без префикса); остальные значения возвращаются без изменений.
+func NormalizeMitigationID(s string) string {
+	if s == "" {
+		return s
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return s
+		}
+	}
+	return "M" + s
+}
+
+var (
+	reMitigationExtID = regexp.MustCompile(`(?i)^M\d{4}$`)
+	reTechniqueExtID  = regexp.MustCompile(`(?i)^T\d{4}(\.\d{3})?$`)
+)
+
+// ValidMitigationID сообщает, похоже ли s на внешний ID митигации: M и четыре цифры (без учёта
+// регистра; голый номер дополняется префиксом, как в MitigationByExtID).
+func ValidMitigationID(s string) bool {
+	return reMitigationExtID.MatchString(NormalizeMitigationID(s))
+}
+
+// ValidTechniqueID сообщает, похоже ли s на внешний ID техники или под-техники: T1059, T1059.001.
+func ValidTechniqueID(s string) bool {
+	return reTechniqueExtID.MatchString(s)
+}
+
+// MitigationByExtID возвращает STIX ID митигации с внешним ID want (без учёта регистра, голый
+// номер дополняется префиксом "M") или "". Если ID носят несколько объектов, выбирается
+// первый из MitigationCandidates.
+func (idx *Index) MitigationByExtID(want string) string {
+	if ids := idx.MitigationCandidates(want); len(ids) > 0 {
+		return ids[0]
+	}
+	return ""
+}
+
+// MitigationCandidates возвращает STIX ID всех митигаций с внешним ID want (сравнение как в
+// MitigationByExtID), лучший кандидат первым: действующие раньше отозванных и устаревших,
+// затем более поздний modified, при равенстве — меньший STIX ID. Порядок не зависит от
+// порядка обхода map.
+func (idx *Index) MitigationCandidates(want string) []string {
+	want = NormalizeMitigationID(want)
+	var ids []string
+	for id, co := range idx.Mitigations {
+		if ext, ok := ExternalID(co.ExternalRefs); ok && strings.EqualFold(ext, want) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := idx.Mitigations[ids[i]], idx.Mitigations[ids[j]]
+		if a.Obsolete() != b.Obsolete() {
+			return !a.Obsolete()
+		}
+		if ma, mb := modifiedTime(a.Modified), modifiedTime(b.Modified); !ma.Equal(mb) {
+			return ma.After(mb)
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// modifiedTime разбирает метку modified (RFC 3339); некорректная или пустая метка считается
+// самой старой.
+func modifiedTime(ts string) time.Time {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+/*
+-------------------------------------------------------------
+Запрос «митигация → техники»
+-------------------------------------------------------------
+*/
+// Technique — техника в результате TechniquesForMitigation.
+type Technique struct {
+	ID          string   `json:"external_id"` // внешний ID ATT&CK; без него — хвост STIX ID
+	STIXID      string   `json:"stix_id"`
+	Name        string   `json:"name"`
+	Tactics     []string `json:"tactics,omitempty"`
+	Platforms   []string `json:"platforms,omitempty"`
+	Description string   `json:"description,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	Revoked     bool     `json:"revoked,omitempty"`
+	Deprecated  bool     `json:"deprecated,omitempty"`
+	Replaces    string   `json:"replaces,omitempty"` // ID отозванной техники, заменённой этой (revoked-by)
+}
+
+// NewTechnique формирует Technique из объекта attack-pattern.
+func NewTechnique(ap AttackPattern) Technique {
+	ref, _ := ExternalRef(ap.ExternalRefs)
+	return Technique{
+		ID:          techniqueID(ap),
+		STIXID:      ap.ID,
+		Name:        ap.Name,
+		Tactics:     TacticsFromKillChain(ap.KillChainPhases),
+		Platforms:   ap.Platforms,
+		Description: ap.Description,
+		URL:         ref.URL,
+		Revoked:     ap.Revoked,
+		Deprecated:  ap.Deprecated,
+	}
+}
+
+// techniqueID возвращает внешний ID техники; без ссылки ATT&CK — хвост STIX ID.
+func techniqueID(ap AttackPattern) string {
+	if id, ok := ExternalID(ap.ExternalRefs); ok {
+		return id
+	}
+	return strings.TrimPrefix(ap.ID, "attack-pattern--")
+}
+
+// MitigatedTechnique — техника, на которую указывает связь "mitigates" (см. MitigatedTechniques).
+type MitigatedTechnique struct {
+	Technique AttackPattern
+	Rel       Relationship // связь mitigates, давшая технику
+	Replaces  string       // ID отозванной техники, вместо которой взята Technique; "" для прямой связи
+}
+
+// MitigatedTechniques возвращает техники, на которые указывают связи "mitigates" митигации
+// mitSTIXID. С replaceRevoked отозванная техника заменяется концом цепочки revoked-by
+// (см. ReplacementOf); keep, если не nil, отбирает техники уже после замены. Дубликаты
+// отбрасываются по STIX ID техники (внешний ID может отсутствовать или совпасть у разных
+// объектов) — прямая связь важнее полученной через замену, поэтому результат не зависит от
+// порядка связей; порядок — по ID техники, затем по STIX ID.
+func (idx *Index) MitigatedTechniques(mitSTIXID string, replaceRevoked bool, keep func(AttackPattern) bool) []MitigatedTechnique {
+	var out []MitigatedTechnique
+	seenTechniques := make(map[string]int) // STIX ID техники → индекс в out
+	seenTargets := make(map[string]bool)   // target_ref уже обработанных связей
+	for _, r := range idx.Rels {
+		if r.RelationshipType != "mitigates" || r.SourceRef != mitSTIXID {
+			continue
+		}
+		// Повтор связи митигация → техника в исходных данных (тот же target_ref) пропускаем сразу
+		if seenTargets[r.TargetRef] {
+			continue
+		}
+		seenTargets[r.TargetRef] = true
+		ap, ok := idx.Techniques[r.TargetRef]
+		if !ok {
+			continue
+		}
+		var replaces string
+		if ap.Revoked && replaceRevoked {
+			if succ, ok := idx.ReplacementOf(ap.ID); ok {
+				replaces = techniqueID(ap)
+				ap = succ
+			}
+		}
+		if keep != nil && !keep(ap) {
+			continue
+		}
+		if i, seen := seenTechniques[ap.ID]; seen {
+			if replaces == "" {
+				out[i].Replaces = ""
+			}
+			continue
+		}
+		seenTechniques[ap.ID] = len(out)
+		out = append(out, MitigatedTechnique{Technique: ap, Rel: r, Replaces: replaces})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].Technique, out[j].Technique
+		if idA, idB := techniqueID(a), techniqueID(b); idA != idB {
+			return idA < idB
+		}
+		return a.ID < b.ID
+	})
+	return out
+}
+
+// TechniquesForMitigation возвращает техники, на которые указывают связи "mitigates" митигации
+// с внешним ID mitigationID (например, "M1037"), — без дубликатов, отсортированные по ID.
+// Отозванная техника заменяется преемником по связи revoked-by (поле Replaces); отозванные без
+// преемника и устаревшие техники не отбрасываются (см. поля Revoked/Deprecated).
+// ok == false, если митигации нет в бандле.
+func (idx *Index) TechniquesForMitigation(mitigationID string) (techs []Technique, ok bool) {
+	mitSTIXID := idx.MitigationByExtID(mitigationID)
+	if mitSTIXID == "" {
+		return nil, false
+	}
+	for _, mt := range idx.MitigatedTechniques(mitSTIXID, true, nil) {
+		t := NewTechnique(mt.Technique)
+		t.Replaces = mt.Replaces
+		techs = append(techs, t)
+	}
+	return techs, true
+}
+
+/*
+-------------------------------------------------------------
+Потоковый разбор бандла через ограниченный канал
+-------------------------------------------------------------
+*/
+// seqObject — сырой объект бандла и его порядковый номер в массиве objects.
+type seqObject struct {
+	seq int
+	raw json.RawMessage
+}
+
+// seqIndex — справочник из одного разобранного объекта с порядковым номером этого объекта.
+type seqIndex struct {
+	seq int
+	idx *Index
+}
+
+// ParseStream разбирает бандл конвейером: горутина-декодер читает объекты из r по одному
+// и отправляет их в канал ёмкостью buffer, воркеры (по числу CPU) классифицируют их, а сборщик
+// сливает результаты строго в порядке объектов бандла — итог, включая победу последнего объекта
+// при повторе STIX ID и порядок связей, совпадает с разбором в памяти. Число объектов «в полёте»
+// (в каналах, у воркеров и в ожидании своей очереди) ограничено buffer+воркеры: декодер берёт
+// жетон на каждый объект, сборщик возвращает его после слияния.
+func ParseStream(r io.Reader, buffer int) (*Index, error) {
+	workers := runtime.NumCPU()
+	tokens := make(chan struct{}, buffer+workers)
+	objects := make(chan seqObject, buffer)
+	parsed := make(chan seqIndex, buffer)
+
+	var specVersion string
+	var decodeErr error
+	go func() {
+		defer close(objects)
+		specVersion, decodeErr = decodeBundleObjects(r, func(seq int, rawObj json.RawMessage) {
+			tokens <- struct{}{}
+			objects <- seqObject{seq: seq, raw: rawObj}
+		})
+	}()
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for obj := range objects {
+				part := NewIndex()
+				part.add(obj.raw)
+				parsed <- seqIndex{seq: obj.seq, idx: part}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(parsed)
+	}()
+
+	idx := NewIndex()
+	pending := make(map[int]*Index)
+	next := 0
+	for p := range parsed {
+		pending[p.seq] = p.idx
+		for part, ok := pending[next]; ok; part, ok = pending[next] {
+			idx.merge(part)
+			delete(pending, next)
+			next++
+			<-tokens
+		}
+	}
+	if decodeErr != nil {
+		return nil, decodeErr
+	}
+	idx.SpecVersion = specVersion
+	return idx, nil
+}
+
+// decodeBundleObjects читает объект bundle токенами и передаёт каждый элемент массива
+// "objects" в emit вместе с его порядковым номером, не материализуя массив целиком.
+// Возвращает spec_version бандла.
+func decodeBundleObjects(r io.Reader, emit func(seq int, rawObj json.RawMessage)) (string, error) {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return "", err
+	}
+	var specVersion, typ string
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return "", err
+		}
+		key, _ := tok.(string)
+		switch key {
+		case "type":
+			if err := dec.Decode(&typ); err != nil {
+				return "", err
+			}
+			if typ != "bundle" {
+				return "", notBundleError(typ)
+			}
+		case "objects":
+			if err := expectDelim(dec, '['); err != nil {
+				return "", err
+			}
+			for seq := 0; dec.More(); seq++ {
+				var rawObj json.RawMessage
+				if err := dec.Decode(&rawObj); err != nil {
+					return "", err
+				}
+				emit(seq, rawObj)
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return "", err
+			}
+		case "spec_version":
+			if err := dec.Decode(&specVersion); err != nil {
+				return "", err
+			}
+		default:
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return "", err
+			}
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return "", err
+	}
+	if typ != "bundle" {
+		return "", notBundleError(typ)
+	}
+	return specVersion, nil
+}
+
+// expectDelim читает следующий токен и проверяет, что это ожидаемый разделитель JSON.
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != want {
+		return fmt.Errorf("unexpected token %v, want %q", tok, want)
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+//go:build embedbundle
+
+// Сборка со встроенным снимком бандла Enterprise: go build -tags embedbundle (см. make build-embedded).
+package main
+
+import _ "embed"
+
+// embeddedBundle — снимок enterprise-attack.json на момент сборки (для -embedded).
+//
+//go:embed embedded/enterprise-attack.json
+var embeddedBundle []byte
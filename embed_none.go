@@ -0,0 +1,6 @@
+//go:build !embedbundle
+
+package main
+
+// embeddedBundle пуст в обычной сборке: -embedded требует сборки с -tags embedbundle.
+var embeddedBundle []byte
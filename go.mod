@@ -1,3 +1,8 @@
 module mitremit
 
 go 1.25.6
+
+require (
+	golang.org/x/sys v0.41.0
+	golang.org/x/term v0.40.0
+)
@@ -1,20 +1,47 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/csv"
+	"encoding/gob"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"hash"
+	"html/template"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"text/tabwriter"
+	texttemplate "text/template"
 	"time"
 	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+
+	"mitremit/attack"
 )
 
 /*
@@ -24,7 +51,9 @@ Global flags
 */
 var (
 	// Основные флаги
-	flagDbg = flag.Bool("debug", false, "extra diagnostic output")
+	flagDbg     = flag.Bool("debug", false, "extra diagnostic output")
+	flagQuiet   = flag.Bool("quiet", false, "suppress warnings and informational stderr messages (errors and -debug output are kept)")
+	flagMetrics = flag.Bool("metrics", false, "print the wall-clock duration of each phase (cache read, download, parse, extraction) to stderr")
 
 	// Флаги управления кэшем
 	flagCacheDir = flag.String("cache-dir", "",
@@ -33,99 +62,242 @@ var (
 		"disable caching")
 	flagForceRefresh = flag.Bool("force-refresh", false,
 		"force download fresh bundle ignoring cache")
+	flagCacheInfo = flag.Bool("cache-info", false,
+		"print cache directory, bundle size, modification time, age, TTL remaining and spec_version (no network)")
+	flagClearCache = flag.Bool("clear-cache", false,
+		"delete the cached bundles, checksums, validators and index snapshots from the cache directory, then exit")
+	flagDryRun = flag.Bool("dry-run", false,
+		"print the cache directory, cache age/validity and whether a download would happen, then exit")
+	flagCheckFreshness = flag.Bool("check-freshness", false,
+		"compare the cached bundle with upstream via a conditional HEAD (ETag/Last-Modified); exit 0 if up to date, 3 if an update is available")
+	flagAllowStale = flag.Bool("allow-stale", false,
+		"if the download fails, fall back to an expired cached bundle (with a warning on stderr)")
+	flagDomain = flag.String("domain", domainEnterprise,
+		"ATT&CK domain (matrix): enterprise, mobile or ics.")
+	flagBundleFile = flag.String("bundle-file", "",
+		"Read the STIX bundle from this local file, or from stdin with \"-\" (no network, no cache).")
+	flagEmbedded = flag.Bool("embedded", false,
+		"Use the enterprise bundle snapshot embedded at build time (-tags embedbundle); no network, no cache.")
+
+	// Флаги сети
+	flagRetries = flag.Int("retries", defaultRetries,
+		"retry the download this many times on network errors and HTTP 5xx (not 4xx)")
+	flagRetryDelay = flag.Duration("retry-delay", defaultRetryDelay,
+		"base delay before the first retry; doubled on every next attempt")
+	flagTimeout = flag.Duration("timeout", defaultDownloadTimeout,
+		"HTTP client timeout for the bundle download (Go duration, e.g. 30s, 2m)")
+	flagProxy = flag.String("proxy", "",
+		"HTTP(S) proxy URL for the download (overrides HTTP_PROXY/HTTPS_PROXY/NO_PROXY)")
+	flagBundleURL = flag.String("bundle-url", "",
+		"download the bundle from this http(s) URL (internal mirror) instead of mitre/cti")
+	flagCAFile = flag.String("ca-file", "",
+		"PEM file with the CA certificates trusted for the download (instead of the system pool)")
+	flagPinSHA256 = flag.String("pin-sha256", "",
+		"comma-separated base64 SHA-256 hashes of the server public key (SPKI); the download fails on mismatch")
+
+	// Флаги разбора бандла
+	flagStreamBuffer = flag.Int("stream-buffer", defaultStreamBuffer,
+		"parse bundle via a bounded object pipeline with this channel buffer (0 = in-memory parse)")
+	flagIndexCache = flag.Bool("index-cache", false,
+		"persist the parsed index next to the cached bundle and reuse it while the bundle hash matches")
+
+	// Режим HTTP-сервера
+	flagServe = flag.String("serve", "",
+		"Run an HTTP server on this address (e.g. :8080) serving GET /mitigation/{id}.")
 
 	// Флаги запросов
 	flagMitigation = flag.String("mitigation", "",
-		"Mitigation external ID (e.g. M1037).")
+		"Mitigation external ID (e.g. M1037, m1037 or 1037); comma-separated list for batch.")
+	flagStrictID = flag.Bool("strict-id", false,
+		"Match -mitigation IDs case-sensitively (m1037 no longer matches M1037).")
 	flagMitigationName = flag.String("mitigation-name", "",
 		"Full mitigation name (case‑insensitive).")
+	flagSuggestDistance = flag.Int("suggest-distance", 0,
+		"Maximum edit distance for \"Did you mean\" suggestions (0 = scale with the name length).")
+	flagMitigationSearch = flag.String("mitigation-search", "",
+		"Case-insensitive substring of the mitigation name; several matches are listed on stderr.")
+	flagMitigationRegex = flag.String("mitigation-regex", "",
+		"Go regexp on mitigation names; techniques of all matching mitigations are reported as in batch mode.")
+	flagTechnique = flag.String("technique", "",
+		"Reverse lookup: technique external ID (e.g. T1059.001) to list its mitigations.")
+	flagDetections = flag.String("detections", "",
+		"Detection lookup: technique external ID to list the data components/sources that detect it.")
+	flagCompare = flag.String("compare", "",
+		"Compare two mitigations (e.g. M1037,M1040): techniques only the first covers, only the second, and both.")
+	flagDiffAgainst = flag.String("diff-against", "",
+		"Compare the result with a previous -json output FILE and print added (+) and removed (-) techniques.")
+	flagCaseStudy = flag.Bool("case-study", false,
+		"Threat-informed defense: techniques used by -group with their covering mitigations.")
+	flagGroup = flag.String("group", "",
+		"Group (intrusion set) external ID for -case-study (e.g. G0016).")
+	flagListMitigations = flag.Bool("list-mitigations", false,
+		"List every mitigation (external ID and name) sorted by ID.")
+	flagListPlatforms = flag.Bool("list-platforms", false,
+		"List the unique platform values (x_mitre_platforms) of all techniques, sorted.")
 
 	// Флаги вывода
-	flagJSON = flag.Bool("json", false, "Emit JSON array.")
-	flagCSV  = flag.Bool("csv", false, "Emit CSV.")
-	flagNGQL = flag.Bool("ngql", false, "Emit Nebula Graph INSERT statements.")
-	flagHelp = flag.Bool("h", false, "Show help.")
-)
-
-/*
--------------------------------------------------------------
-Minimal STIX structures we need
--------------------------------------------------------------
-*/
-type Bundle struct {
-	Type        string            `json:"type"`
-	SpecVersion string            `json:"spec_version"`
-	Objects     []json.RawMessage `json:"objects"`
-}
+	flagJSON     = flag.Bool("json", false, "Emit JSON array.")
+	flagJSONL    = flag.Bool("jsonl", false, "Emit JSON Lines: one technique object per line.")
+	flagCSV      = flag.Bool("csv", false, "Emit CSV.")
+	flagTSV      = flag.Bool("tsv", false, "Emit TSV (same columns as -csv, tab-separated).")
+	flagMarkdown = flag.Bool("markdown", false, "Emit a Markdown (pipe) table per mitigation.")
+	flagHTML     = flag.Bool("html", false, "Emit a self-contained HTML page with a table per mitigation.")
+	flagNGQL     = flag.Bool("ngql", false, "Emit Nebula Graph INSERT statements.")
+	flagDOT      = flag.Bool("dot", false, "Emit Graphviz DOT digraph.")
+	flagCypher   = flag.Bool("cypher", false, "Emit Neo4j Cypher MERGE statements.")
+	flagMermaid  = flag.Bool("mermaid", false, "Emit a Mermaid graph LR definition (renders in GitHub Markdown).")
+	flagSTIX     = flag.Bool("stix", false, "Emit a STIX bundle subset: mitigation, techniques, mitigates relationships.")
+	flagExec     = flag.String("exec", "",
+		"Run command once per technique; placeholders {id} {name} {tactics} {platforms} {mitigation}.")
+	flagTemplate = flag.String("template", "",
+		"Render each technique through this Go text/template (fields .ExternalID, .Name, .Tactics, .Mitigation, ...).")
+	flagTemplateFile = flag.String("template-file", "",
+		"Like -template, but read the template from PATH.")
+	flagJSONGraph = flag.Bool("json-graph", false,
+		"Emit a JSON graph object per mitigation: mitigation, techniques and edges [{from, to}].")
+	flagHelp  = flag.Bool("h", false, "Show help.")
+	flagCount = flag.Bool("count", false,
+		"Print only the number of techniques per mitigation (table, JSON or CSV summary).")
+	flagTacticHistogram = flag.Bool("tactic-histogram", false,
+		"Print the number of techniques per tactic, sorted descending, instead of the technique list.")
+	flagTacticDisplayNames = flag.Bool("tactic-display-names", false,
+		"Show tactics by their ATT&CK display names (Defense Evasion) instead of shortnames (defense-evasion).")
+	flagFailEmpty = flag.Bool("fail-empty", false,
+		"Exit with code 2 when no results remain after filtering (output is still printed).")
+	flagVersion = flag.Bool("version", false,
+		"Print the tool version and the spec_version of the cached bundle (no download).")
+	flagJSONSchema = flag.Bool("json-schema", false,
+		"Print a JSON Schema describing the -json output (generated from the result struct).")
+	flagWithMeta = flag.Bool("with-meta", false,
+		"Wrap -json output in {mitigation, techniques, generated_at, attack_version}; add the same metadata as CSV/TSV columns.")
 
-// envelope – only type and id are required for the first pass
-type baseObject struct {
-	Type string `json:"type"`
-	ID   string `json:"id"`
-}
+	flagNoSubtechniques = flag.Bool("no-subtechniques", false,
+		"Drop sub-techniques (external IDs with a dot, e.g. T1059.001); keep top-level techniques only.")
+	flagTree = flag.Bool("tree", false,
+		"Nest sub-techniques under their parent (indented table, subtechniques array in JSON).")
+	flagColor = flag.String("color", colorAuto,
+		"Color the table: auto (terminal only; honors CLICOLOR_FORCE, NO_COLOR, CLICOLOR), always or never.")
+	flagNoColor = flag.Bool("no-color", false, "Alias for -color=never.")
+	flagGroupBy = flag.String("group-output-by", "none",
+		"Group output by: none, mitigation, tactic, platform.")
+	flagSortBy = flag.String("sort-by", sortByID,
+		"Order techniques by: id, name, tactic (alphabetically first tactic, then ID).")
+	flagFlattenPlatforms = flag.Bool("flatten-platforms", false,
+		"CSV: emit one row per (technique, platform) instead of joining platforms.")
+	flagCSVDelimiter = flag.String("csv-delimiter", ",",
+		"CSV field delimiter: a single character other than a quote or newline (e.g. ';').")
+	flagNoHeader  = flag.Bool("no-header", false, "Omit the header row from CSV and TSV output.")
+	flagOutputDir = flag.String("output-dir", "",
+		"Write the report in every format plus manifest.json into this directory.")
+	flagOutput = flag.String("output", "",
+		"Write the result to FILE (mode 0600, atomic) instead of stdout.")
+	flagShowDescription = flag.Bool("show-description", false,
+		"Include technique descriptions (JSON field, CSV column, wrapped text in the table).")
+	flagShowURL = flag.Bool("show-url", false,
+		"Include the ATT&CK website URL of each technique (JSON field, CSV and table column).")
+	flagShowGroups = flag.Bool("show-groups", false,
+		"List the groups (intrusion sets) that use each technique (JSON field, CSV and table column).")
+	flagShowSoftware = flag.Bool("show-software", false,
+		"List the software (malware and tools) that uses each technique (JSON field, CSV and table column).")
+	flagShowCampaigns = flag.Bool("show-campaigns", false,
+		"List the campaigns that used each technique (JSON field, CSV and table column).")
+	flagShowRelDesc = flag.Bool("show-relationship-desc", false,
+		"Include how the mitigation applies to each technique (mitigates relationship description; JSON field, table text).")
+	flagShowDataSources = flag.Bool("show-datasources", false,
+		"List the data sources (x_mitre_data_sources) of each technique in JSON (data_sources field).")
+	flagDescWidth = flag.Int("desc-width", defaultDescWidth,
+		"Truncate descriptions to N characters with an ellipsis in table/CSV output (0 = no limit; JSON stays full).")
 
-// Kill chain phase (ATT&CK tactic)
-type killChainPhase struct {
-	KillChainName string `json:"kill_chain_name"`
-	PhaseName     string `json:"phase_name"`
-}
+	// Флаги фильтрации
+	flagCreatedAfter = flag.String("created-after", "",
+		"Only techniques created on or after this date (YYYY-MM-DD or RFC3339).")
+	flagCreatedBefore = flag.String("created-before", "",
+		"Only techniques created on or before this date (YYYY-MM-DD or RFC3339).")
+	flagModifiedSince = flag.String("modified-since", "",
+		"Only techniques modified on or after this date (YYYY-MM-DD or RFC3339), e.g. since the last ATT&CK release.")
+	flagDeprecatedOnly = flag.Bool("deprecated-only", false,
+		"Audit mode: show only revoked/deprecated techniques linked to the mitigation.")
+	flagIncludeDeprecated = flag.Bool("include-deprecated", false,
+		"Include techniques marked x_mitre_deprecated (excluded by default).")
+	flagTactic = flag.String("tactic", "",
+		"Only techniques in this tactic (ATT&CK shortname, e.g. defense-evasion; case-insensitive).")
+	flagPlatform = flag.String("platform", "",
+		"Only techniques for these platforms (comma-separated, any of; e.g. Windows,Linux).")
+)
 
-// Technique / sub‑technique
-type attackPattern struct {
-	Type            string              `json:"type"`
-	ID              string              `json:"id"`
-	Name            string              `json:"name"`
-	ExternalRefs    []externalReference `json:"external_references,omitempty"`
-	KillChainPhases []killChainPhase    `json:"kill_chain_phases,omitempty"`
-}
+// version — версия сборки, задаётся при сборке: -ldflags "-X main.version=v0.3.0".
+var version = "dev"
 
-// Mitigation
-type courseOfAction struct {
-	Type         string              `json:"type"`
-	ID           string              `json:"id"`
-	Name         string              `json:"name"`
-	ExternalRefs []externalReference `json:"external_references,omitempty"`
+// printVersion выводит версию сборки и spec_version бандла из кэша (или из -bundle-file). Бандл
+// не скачивается; TTL кэша не учитывается.
+func printVersion(w io.Writer) {
+	fmt.Fprintf(w, "mitremit %s\n", version)
+	src := *flagBundleFile
+	if src == bundleFromStdin {
+		// stdin не читаем ради версии: он предназначен для основного запуска
+		fmt.Fprintf(w, "ATT&CK %s bundle: stdin\n", *flagDomain)
+		return
+	}
+	if src == "" {
+		st := statCache()
+		if !st.exists {
+			fmt.Fprintf(w, "ATT&CK %s bundle: not cached\n", *flagDomain)
+			return
+		}
+		src = st.path
+	}
+	spec, err := bundleSpecVersion(src)
+	if err != nil {
+		fmt.Fprintf(w, "ATT&CK %s bundle: %s (unreadable: %v)\n", *flagDomain, src, err)
+		return
+	}
+	fmt.Fprintf(w, "ATT&CK %s bundle: %s (spec_version %s)\n", *flagDomain, src, spec)
 }
 
-// Relationship – we only care about relationship_type == "mitigates"
-type relationship struct {
-	Type             string `json:"type"`
-	ID               string `json:"id"`
-	RelationshipType string `json:"relationship_type"`
-	SourceRef        string `json:"source_ref"` // mitigation
-	TargetRef        string `json:"target_ref"` // technique
+// bundleSpecVersion читает spec_version бандла из файла: кэша .gz (с проверкой SHA-256) или
+// несжатого JSON. Для бандлов STIX 2.1, где spec_version есть только у объектов, — "not set".
+func bundleSpecVersion(path string) (string, error) {
+	var r io.ReadCloser
+	var err error
+	if strings.HasSuffix(path, ".gz") {
+		r, _, err = openCachedBundle(path)
+	} else {
+		r, err = os.Open(path)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+	// Объекты не сохраняются: нужен только spec_version обёртки
+	var bundle struct {
+		SpecVersion string `json:"spec_version"`
+	}
+	if err := json.NewDecoder(r).Decode(&bundle); err != nil {
+		return "", err
+	}
+	if bundle.SpecVersion == "" {
+		return "not set", nil
+	}
+	return bundle.SpecVersion, nil
 }
 
-// External reference (the place where ATT&CK stores the human‑readable ID)
-type externalReference struct {
-	SourceName string `json:"source_name"` // "mitre-attack"
-	ExternalID string `json:"external_id"` // "T1059.001" or "M1037"
-	URL        string `json:"url,omitempty"`
-}
+// knownSpecVersions — значения spec_version бандла, на которые рассчитан разбор. У бандлов
+// STIX 2.1 поле на уровне бандла необязательно, поэтому пустое значение тоже допустимо.
+var knownSpecVersions = []string{"", "2.0", "2.1"}
 
-/*
--------------------------------------------------------------
-Helper – pull the ATT&CK external ID from a slice of refs
--------------------------------------------------------------
-*/
-func externalID(refs []externalReference) (string, bool) {
-	for _, r := range refs {
-		if strings.EqualFold(r.SourceName, "mitre-attack") && r.ExternalID != "" {
-			return r.ExternalID, true
+// checkSpecVersion предупреждает (не фатально), если spec_version бандла незнакома: при смене
+// формата у MITRE разбор может молча терять объекты. С -debug версия выводится всегда.
+func checkSpecVersion(spec string) {
+	if *flagDbg {
+		shown := spec
+		if shown == "" {
+			shown = "not set"
 		}
+		fmt.Fprintf(os.Stderr, ">>> bundle spec_version: %s\n", shown)
 	}
-	return "", false
-}
-
-// tacticsFromKillChain возвращает phase_name из фаз с kill_chain_name == "mitre-attack".
-func tacticsFromKillChain(phases []killChainPhase) []string {
-	var out []string
-	for _, p := range phases {
-		if strings.EqualFold(p.KillChainName, "mitre-attack") && p.PhaseName != "" {
-			out = append(out, p.PhaseName)
-		}
+	if !slices.Contains(knownSpecVersions, spec) {
+		warnf("unrecognized bundle spec_version %q (expected STIX 2.0 or 2.1); some objects may be skipped", spec)
 	}
-	return out
 }
 
 // levenshtein возвращает расстояние Левенштейна между a и b (количество вставок/замен/удалений).
@@ -161,29 +333,166 @@ func levenshtein(a, b string) int {
 
 const didYouMeanMaxDist = 2
 
-// suggestMitigationName возвращает единственное имя митигации из mitMap с расстоянием Левенштейна до target ≤ maxDist.
-// Если таких 0 или больше одного — возвращает "".
-func suggestMitigationName(target string, mitMap map[string]courseOfAction, maxDist int) string {
+// nameSuggestChars — сколько символов имени приходится на одну допустимую правку при подсказке
+// по названию: для "Filter Network Traffic" порог 4, для коротких имён — 1.
+const nameSuggestChars = 5
+
+// nameSuggestDistance возвращает порог расстояния для подсказки имени: -suggest-distance, если
+// задан, иначе длина target / nameSuggestChars (не меньше 1).
+func nameSuggestDistance(target string) int {
+	if *flagSuggestDistance > 0 {
+		return *flagSuggestDistance
+	}
+	return max(1, utf8.RuneCountInString(target)/nameSuggestChars)
+}
+
+// idSuggestDistance — порог для подсказки ID: -suggest-distance или didYouMeanMaxDist
+// (длина ID митигаций одинакова, нормализация не нужна).
+func idSuggestDistance() int {
+	if *flagSuggestDistance > 0 {
+		return *flagSuggestDistance
+	}
+	return didYouMeanMaxDist
+}
+
+// nameDistance — имя митигации и его расстояние Левенштейна до запроса.
+type nameDistance struct {
+	name string
+	dist int
+}
+
+// mitigationNameDistances возвращает расстояния от target до всех имён митигаций (без учёта
+// регистра), по возрастанию расстояния, при равенстве — по имени.
+func mitigationNameDistances(target string, mitMap map[string]attack.CourseOfAction) []nameDistance {
 	targetLower := strings.ToLower(target)
-	var suggestion string
-	count := 0
+	seen := make(map[string]bool, len(mitMap))
+	var out []nameDistance
 	for _, co := range mitMap {
-		if co.Name == "" {
+		if co.Name == "" || seen[co.Name] {
 			continue
 		}
-		d := levenshtein(targetLower, strings.ToLower(co.Name))
-		if d <= maxDist && d > 0 {
-			if count == 1 && suggestion != co.Name {
-				return "" // больше одного варианта — не подсказываем
-			}
-			suggestion = co.Name
-			count++
+		seen[co.Name] = true
+		out = append(out, nameDistance{co.Name, levenshtein(targetLower, strings.ToLower(co.Name))})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].dist != out[j].dist {
+			return out[i].dist < out[j].dist
 		}
+		return out[i].name < out[j].name
+	})
+	return out
+}
+
+// debugNameDistances — сколько ближайших имён показывает -debug при ненайденном названии.
+const debugNameDistances = 5
+
+// printNameDistances выводит в w ближайшие имена с расстояниями и порогом (для -debug): видно,
+// почему подсказка была или не была предложена.
+func printNameDistances(w io.Writer, target string, mitMap map[string]attack.CourseOfAction, maxDist int) {
+	fmt.Fprintf(w, ">>> suggestion threshold for %q: %d\n", target, maxDist)
+	ds := mitigationNameDistances(target, mitMap)
+	for _, nd := range ds[:min(debugNameDistances, len(ds))] {
+		fmt.Fprintf(w, ">>>   distance %d: %s\n", nd.dist, nd.name)
 	}
-	if count != 1 {
-		return ""
+}
+
+// maxNameSuggestions — сколько ближайших названий предлагается в «Did you mean».
+const maxNameSuggestions = 3
+
+// suggestMitigationNames возвращает до maxNameSuggestions имён митигаций из mitMap с расстоянием
+// Левенштейна до target ≤ maxDist: ближайшие первыми, при равном расстоянии — по алфавиту.
+func suggestMitigationNames(target string, mitMap map[string]attack.CourseOfAction, maxDist int) []string {
+	var out []string
+	for _, nd := range mitigationNameDistances(target, mitMap) {
+		if nd.dist > maxDist || len(out) == maxNameSuggestions {
+			break
+		}
+		if nd.dist > 0 {
+			out = append(out, nd.name)
+		}
+	}
+	return out
+}
+
+// quotedOrList перечисляет имена в кавычках через запятую с "or" перед последним:
+// "X", "Y", or "Z".
+func quotedOrList(names []string) string {
+	quoted := make([]string, len(names))
+	for i, n := range names {
+		quoted[i] = strconv.Quote(n)
+	}
+	switch len(quoted) {
+	case 1:
+		return quoted[0]
+	case 2:
+		return quoted[0] + " or " + quoted[1]
+	}
+	return strings.Join(quoted[:len(quoted)-1], ", ") + ", or " + quoted[len(quoted)-1]
+}
+
+// suggestMitigationIDs возвращает внешние ID митигаций, ближайшие к target по расстоянию
+// Левенштейна (не дальше maxDist, без учёта регистра), отсортированные; несколько ID —
+// если на минимальном расстоянии их несколько.
+func suggestMitigationIDs(target string, mitMap map[string]attack.CourseOfAction, maxDist int) []string {
+	targetUpper := strings.ToUpper(target)
+	best := maxDist + 1
+	var out []string
+	for _, co := range mitMap {
+		ext, ok := attack.ExternalID(co.ExternalRefs)
+		if !ok {
+			continue
+		}
+		d := levenshtein(targetUpper, strings.ToUpper(ext))
+		if d == 0 || d > best {
+			continue
+		}
+		if d < best {
+			best, out = d, nil
+		}
+		if !slices.Contains(out, ext) {
+			out = append(out, ext)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// dateLayout — формат даты в флагах фильтрации (дата без времени).
+const dateLayout = "2006-01-02"
+
+// parseDateBound разбирает значение флага-даты (YYYY-MM-DD или RFC3339). Для верхней границы,
+// заданной датой без времени, возвращается конец этого дня, чтобы граница была включительной.
+func parseDateBound(value string, upper bool) (time.Time, error) {
+	if t, err := time.Parse(dateLayout, value); err == nil {
+		if upper {
+			return t.Add(24*time.Hour - time.Nanosecond), nil
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or RFC3339 date, got %q", value)
+	}
+	return t, nil
+}
+
+// inDateRange сообщает, попадает ли STIX-метка времени ts в [after, before] (нулевая граница не ограничивает).
+// Объекты без метки или с некорректной меткой в ограниченный диапазон не попадают.
+func inDateRange(ts string, after, before time.Time) bool {
+	if after.IsZero() && before.IsZero() {
+		return true
+	}
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return false
+	}
+	if !after.IsZero() && t.Before(after) {
+		return false
 	}
-	return suggestion
+	if !before.IsZero() && t.After(before) {
+		return false
+	}
+	return true
 }
 
 /*
@@ -192,10 +501,59 @@ func suggestMitigationName(target string, mitMap map[string]courseOfAction, maxD
 -------------------------------------------------------------
 */
 const (
-	bundleURL = "https://raw.githubusercontent.com/mitre/cti/master/enterprise-attack/enterprise-attack.json"
-	cacheTTL  = 24 * time.Hour
+	bundleBaseURL = "https://raw.githubusercontent.com/mitre/cti/master/"
+	cacheTTL      = 24 * time.Hour
+)
+
+// Домены (матрицы) ATT&CK; бандл каждого лежит в mitre/cti как <domain>-attack/<domain>-attack.json.
+const (
+	domainEnterprise = "enterprise"
+	domainMobile     = "mobile"
+	domainICS        = "ics"
 )
 
+func validDomain(d string) bool {
+	switch d {
+	case domainEnterprise, domainMobile, domainICS:
+		return true
+	}
+	return false
+}
+
+// bundleFileName возвращает имя файла бандла выбранного домена в кэше, поэтому бандлы разных
+// доменов не перезаписывают друг друга в одной директории. С -bundle-url в имя добавляется
+// префикс SHA-256 адреса: при смене зеркала не отдаётся кэш другого источника.
+func bundleFileName() string {
+	name := *flagDomain + "-attack"
+	if *flagBundleURL != "" {
+		name += "-" + sha256Hex([]byte(*flagBundleURL))[:12]
+	}
+	return name + ".json"
+}
+
+// bundleURL возвращает адрес бандла выбранного домена (или -bundle-url).
+func bundleURL() string {
+	if *flagBundleURL != "" {
+		return *flagBundleURL
+	}
+	return bundleBaseURL + *flagDomain + "-attack/" + *flagDomain + "-attack.json"
+}
+
+// validateBundleURL проверяет -bundle-url: допустимы только http(s) с указанным хостом.
+func validateBundleURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid -bundle-url %q: %w", raw, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("invalid -bundle-url %q: scheme must be http or https", raw)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("invalid -bundle-url %q: missing host", raw)
+	}
+	return nil
+}
+
 // getCacheDir определяет директорию для кэша с приоритетом:
 // 1. Флаг --cache-dir
 // 2. Переменная окружения MITRE_CACHE_DIR
@@ -217,7 +575,7 @@ func getCacheDir() string {
 	if dir := os.Getenv("MITRE_CACHE_DIR"); dir != "" {
 		cleaned := filepath.Clean(dir)
 		if !filepath.IsAbs(cleaned) {
-			fmt.Fprintf(os.Stderr, "WARNING: MITRE_CACHE_DIR must be absolute path, ignoring: %s\n", dir)
+			warnf("MITRE_CACHE_DIR must be absolute path, ignoring: %s", dir)
 		} else {
 			return cleaned
 		}
@@ -258,6 +616,11 @@ func isRunningInContainer() bool {
 	return false
 }
 
+// cachedBundlePath возвращает путь сжатого бандла в директории кэша cacheDir.
+func cachedBundlePath(cacheDir string) string {
+	return filepath.Join(cacheDir, bundleFileName()+".gz")
+}
+
 // isCacheValid возвращает true, если файл кэша существует и его возраст меньше cacheTTL.
 func isCacheValid(path string) bool {
 	info, err := os.Stat(path)
@@ -267,12 +630,50 @@ func isCacheValid(path string) bool {
 	return time.Since(info.ModTime()) < cacheTTL
 }
 
+/*
+-------------------------------------------------------------
+Предупреждения
+-------------------------------------------------------------
+*/
+// warnf печатает предупреждение в stderr; с -quiet предупреждения подавляются.
+func warnf(format string, args ...any) {
+	if *flagQuiet {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "WARNING: "+format+"\n", args...)
+}
+
 /*
 -------------------------------------------------------------
 Загрузка & кэширование ATT&CK bundle
 -------------------------------------------------------------
 */
-func fetchBundle() ([]byte, error) {
+// fetchBundle открывает поток бандла: локальный файл (-bundle-file), свежий кэш или ответ
+// сервера. Скачиваемый бандл пишется в кэш по мере чтения и фиксируется при Close, только если
+// тело ответа получено целиком. Вызывающий обязан закрыть поток.
+func fetchBundle() (io.ReadCloser, error) {
+	// Локальный файл бандла: сеть и кэш не используются (-no-cache / -force-refresh не действуют)
+	if *flagBundleFile != "" {
+		if *flagBundleFile == bundleFromStdin {
+			if *flagDbg {
+				fmt.Fprintln(os.Stderr, ">>> reading bundle from stdin")
+			}
+			return io.NopCloser(os.Stdin), nil
+		}
+		if *flagDbg {
+			fmt.Fprintf(os.Stderr, ">>> reading bundle from file: %s\n", *flagBundleFile)
+		}
+		f, err := os.Open(*flagBundleFile)
+		if err != nil {
+			return nil, fmt.Errorf("read bundle file: %w", err)
+		}
+		return f, nil
+	}
+	// Встроенный снимок (-embedded): тоже без сети и кэша
+	if *flagEmbedded {
+		return embeddedBundleData()
+	}
+
 	// Получаем директорию кэша из окружения
 	cacheDir := getCacheDir()
 
@@ -280,10 +681,10 @@ func fetchBundle() ([]byte, error) {
 	// DEBUG: выводим информацию о директории кэша
 	// -----------------------------------------------------------------
 	if *flagDbg {
-		fmt.Fprintf(os.Stdout, ">>> fetchBundle() - entry point\n")
-		fmt.Fprintf(os.Stdout, ">>> cache directory: %s\n", cacheDir)
+		fmt.Fprintf(os.Stderr, ">>> fetchBundle() - entry point\n")
+		fmt.Fprintf(os.Stderr, ">>> cache directory: %s\n", cacheDir)
 		if *flagForceRefresh {
-			fmt.Fprintln(os.Stdout, ">>> force refresh enabled")
+			fmt.Fprintln(os.Stderr, ">>> force refresh enabled")
 		}
 	}
 
@@ -297,7 +698,11 @@ func fetchBundle() ([]byte, error) {
 		}
 	}
 
-	bundlePath := filepath.Join(cacheDir, "enterprise-attack.json")
+	// Бандл хранится в кэше сжатым (.json.gz); несжатый кэш прежних версий переносим
+	bundlePath := cachedBundlePath(cacheDir)
+	if cacheDir != "/dev/null" {
+		migrateUncompressedCache(filepath.Join(cacheDir, bundleFileName()), bundlePath)
+	}
 
 	// -----------------------------------------------------------------
 	// 2️⃣ Используем кэшированный бандл если он существует и не устарел (cache TTL)
@@ -305,29 +710,38 @@ func fetchBundle() ([]byte, error) {
 	// Если cacheDir == "/dev/null", пропускаем проверку кэша
 	if cacheDir != "/dev/null" && !*flagForceRefresh {
 		if isCacheValid(bundlePath) {
-			if cached, err := os.ReadFile(bundlePath); err == nil {
+			start := time.Now()
+			cached, size, err := openCachedBundle(bundlePath)
+			recordPhase("cache read", start)
+			if err == nil {
 				if *flagDbg {
-					fmt.Fprintln(os.Stdout, ">>> cached bundle found – returning cached data")
-					fmt.Fprintf(os.Stdout, ">>> cache file: %s (%d bytes)\n",
-						bundlePath, len(cached))
+					fmt.Fprintln(os.Stderr, ">>> cached bundle found – returning cached data")
+					fmt.Fprintf(os.Stderr, ">>> cache file: %s (%d bytes)\n",
+						bundlePath, size)
 				}
 				return cached, nil // fast path – return cache
+			} else if errors.Is(err, errChecksumMismatch) {
+				// Повреждённый кэш отбрасываем целиком и скачиваем бандл заново
+				if *flagDbg {
+					fmt.Fprintf(os.Stderr, ">>> %v – discarding cache and downloading\n", err)
+				}
+				discardCache(bundlePath)
 			} else if !os.IsNotExist(err) {
 				// Если ошибка не "файл не существует", логируем но продолжаем
 				if *flagDbg {
-					fmt.Fprintf(os.Stdout, ">>> cache read error (will download): %v\n", err)
+					fmt.Fprintf(os.Stderr, ">>> cache read error (will download): %v\n", err)
 				}
 			}
 		} else if *flagDbg {
-			fmt.Fprintln(os.Stdout, ">>> cache expired or missing – will download")
+			fmt.Fprintln(os.Stderr, ">>> cache expired or missing – will download")
 		}
 	}
 	if cacheDir == "/dev/null" || *flagForceRefresh {
 		if *flagDbg {
 			if *flagForceRefresh {
-				fmt.Fprintln(os.Stdout, ">>> force refresh - ignoring cache")
+				fmt.Fprintln(os.Stderr, ">>> force refresh - ignoring cache")
 			} else {
-				fmt.Fprintln(os.Stdout, ">>> cache disabled")
+				fmt.Fprintln(os.Stderr, ">>> cache disabled")
 			}
 		}
 	}
@@ -336,346 +750,5253 @@ func fetchBundle() ([]byte, error) {
 	// 3️⃣ Загружаем бандл из сети
 	// -----------------------------------------------------------------
 	if *flagDbg {
-		fmt.Fprintln(os.Stdout, ">>> downloading ATT&CK bundle")
+		fmt.Fprintln(os.Stderr, ">>> downloading ATT&CK bundle")
+	}
+	// Условный GET: валидаторы прошлого ответа отправляем, только если есть что переиспользовать
+	var validators cacheValidators
+	if cacheDir != "/dev/null" && !*flagForceRefresh {
+		if _, err := os.Stat(bundlePath); err == nil {
+			validators = loadValidators(validatorsPath(bundlePath))
+		}
+	}
+	start := time.Now()
+	body, newValidators, err := downloadBundle(validators)
+	recordPhase("download", start)
+	if errors.Is(err, errNotModified) {
+		// 304: бандл в кэше актуален — продлеваем TTL (mtime) и отдаём его
+		cached, _, rerr := openCachedBundle(bundlePath)
+		if rerr == nil {
+			now := time.Now()
+			_ = os.Chtimes(bundlePath, now, now)
+			if *flagDbg {
+				fmt.Fprintln(os.Stderr, ">>> bundle not modified (HTTP 304) – cache TTL renewed")
+			}
+			return cached, nil
+		}
+		// Кэш исчез или повреждён — скачиваем без валидаторов
+		if *flagDbg {
+			fmt.Fprintf(os.Stderr, ">>> cache unusable after 304 (%v) – downloading full bundle\n", rerr)
+		}
+		discardCache(bundlePath)
+		start = time.Now()
+		body, newValidators, err = downloadBundle(cacheValidators{})
+		recordPhase("download", start)
 	}
-	data, err := downloadBundle()
 	if err != nil {
+		if *flagAllowStale && cacheDir != "/dev/null" {
+			if stale, ok := openStaleBundle(bundlePath, err); ok {
+				return stale, nil
+			}
+		}
 		return nil, err
 	}
 
-	if *flagDbg {
-		fmt.Fprintf(os.Stdout, ">>> downloaded bundle (%d bytes)\n", len(data))
-	}
-
 	// -----------------------------------------------------------------
-	// 4️⃣ Кэшируем скачанный бандл (если кэш не отключен)
+	// 4️⃣ Кэшируем скачанный бандл по мере чтения (если кэш не отключен)
 	// -----------------------------------------------------------------
-	if cacheDir != "/dev/null" {
-		if *flagDbg {
-			fmt.Fprintf(os.Stdout, ">>> caching to: %s\n", bundlePath)
-		}
-		// Создаем временный файл для атомарной записи
-		tmpPath := bundlePath + ".tmp"
-		if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
-			if *flagDbg {
-				fmt.Fprintf(os.Stdout, ">>> WARNING: failed to write cache: %v\n", err)
-			}
-			// Если не удалось записать кэш, все равно возвращаем данные
-		} else {
-			// Атомарно переименовываем временный файл в целевой
-			if err := os.Rename(tmpPath, bundlePath); err != nil {
-				if *flagDbg {
-					fmt.Fprintf(os.Stdout, ">>> WARNING: failed to rename cache file: %v\n", err)
-				}
-				// Пытаемся удалить временный файл
-				os.Remove(tmpPath)
-			} else if *flagDbg {
-				fmt.Fprintln(os.Stdout, ">>> cache saved successfully")
-			}
-		}
+	if cacheDir == "/dev/null" {
+		return body, nil
+	}
+	if *flagDbg {
+		fmt.Fprintf(os.Stderr, ">>> caching to: %s\n", bundlePath)
 	}
+	return newCachingReader(body, bundlePath, newValidators), nil
+}
 
-	return data, nil
+/*
+-------------------------------------------------------------
+Замеры фаз (-metrics)
+-------------------------------------------------------------
+*/
+// phaseTiming — длительность одной фазы запуска.
+type phaseTiming struct {
+	name string
+	d    time.Duration
 }
 
-/* ---------- helper used by fetchBundle ---------- */
-func downloadBundle() ([]byte, error) {
-	if *flagDbg {
-		fmt.Fprintf(os.Stdout, ">>> downloading from: %s\n", bundleURL)
+var (
+	phaseMu      sync.Mutex
+	phaseTimings []phaseTiming
+)
+
+// recordPhase запоминает длительность фазы name, начатой в start (только с -metrics). Удобно
+// вызывать как defer recordPhase("parse", time.Now()).
+func recordPhase(name string, start time.Time) {
+	if !*flagMetrics {
+		return
 	}
+	d := time.Since(start)
+	phaseMu.Lock()
+	phaseTimings = append(phaseTimings, phaseTiming{name: name, d: d})
+	phaseMu.Unlock()
+}
 
-	// Создаем HTTP клиент с таймаутом
-	client := &http.Client{
-		Timeout: 5 * time.Minute, // Долгая загрузка больших файлов
+// printMetrics выводит длительности фаз в порядке выполнения и общее время с момента start.
+func printMetrics(w io.Writer, start time.Time) {
+	phaseMu.Lock()
+	defer phaseMu.Unlock()
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "metrics:")
+	for _, p := range phaseTimings {
+		fmt.Fprintf(tw, "  %s\t%s\n", p.name, p.d.Round(time.Microsecond))
 	}
+	fmt.Fprintf(tw, "  total\t%s\n", time.Since(start).Round(time.Microsecond))
+	_ = tw.Flush()
+}
 
-	resp, err := client.Get(bundleURL)
+// openStaleBundle (-allow-stale) открывает кэш независимо от TTL после неудачной загрузки и
+// предупреждает в stderr, указывая возраст кэша. ok == false, если кэша нет или он повреждён.
+// Запасной путь действует только для ошибок до начала тела ответа: обрыв уже читаемого тела
+// остаётся ошибкой.
+func openStaleBundle(bundlePath string, downloadErr error) (io.ReadCloser, bool) {
+	cached, _, err := openCachedBundle(bundlePath)
 	if err != nil {
-		return nil, fmt.Errorf("download bundle: %w", err)
+		if *flagDbg && !os.IsNotExist(err) {
+			fmt.Fprintf(os.Stderr, ">>> stale cache unusable: %v\n", err)
+		}
+		return nil, false
+	}
+	var age time.Duration
+	if info, err := os.Stat(bundlePath); err == nil {
+		age = time.Since(info.ModTime()).Round(time.Minute)
 	}
-	defer resp.Body.Close()
+	warnf("bundle download failed (%v); using STALE cache %s (age %s, TTL %s)",
+		downloadErr, bundlePath, age, cacheTTL)
+	return cached, true
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("bundle HTTP %d", resp.StatusCode)
+// errChecksumMismatch — содержимое кэша не совпадает с SHA-256 из файла-спутника.
+var errChecksumMismatch = errors.New("cache checksum mismatch")
+
+// checksumPath возвращает путь файла-спутника с SHA-256 распакованного бандла.
+func checksumPath(bundlePath string) string {
+	return bundlePath + ".sha256"
+}
+
+// discardCache удаляет кэш бандла вместе с контрольной суммой и валидаторами, чтобы следующий
+// запрос скачал бандл целиком.
+func discardCache(bundlePath string) {
+	for _, p := range []string{bundlePath, checksumPath(bundlePath), validatorsPath(bundlePath)} {
+		_ = os.Remove(p)
 	}
+}
 
-	// Читаем с ограничением по размеру (например, 200MB)
-	maxSize := 200 * 1024 * 1024 // 200MB
-	limitedReader := &io.LimitedReader{R: resp.Body, N: int64(maxSize)}
+// openCachedBundle открывает gzip-кэш бандла для потокового чтения распакованных данных и
+// возвращает размер файла на диске; каталог и другие нерегулярные файлы считаются ошибкой чтения.
+// Если рядом лежит файл-спутник с SHA-256, распакованный поток сначала хэшируется отдельным
+// проходом и сверяется с ним (кэш прежних версий без спутника принимается без проверки).
+func openCachedBundle(path string) (*gzipFileReader, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err == nil && !info.Mode().IsRegular() {
+		err = fmt.Errorf("%s is not a regular file", path)
+	}
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, 0, fmt.Errorf("open gzip cache %s: %w", path, err)
+	}
+	g := &gzipFileReader{f: f, zr: zr}
+	if err := verifyCachedBundle(g, path); err != nil {
+		g.Close()
+		return nil, 0, err
+	}
+	return g, info.Size(), nil
+}
 
-	data, err := io.ReadAll(limitedReader)
+// verifyCachedBundle сверяет SHA-256 распакованного кэша с файлом-спутником и возвращает поток
+// в начало.
+func verifyCachedBundle(g *gzipFileReader, path string) error {
+	want, err := os.ReadFile(checksumPath(path))
 	if err != nil {
-		return nil, fmt.Errorf("read response: %w", err)
+		return nil // спутника нет — проверять не с чем
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, g); err != nil {
+		return err
+	}
+	if _, err := g.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != strings.TrimSpace(string(want)) {
+		return fmt.Errorf("%w: %s has sha256 %s, expected %s", errChecksumMismatch, path, got, strings.TrimSpace(string(want)))
 	}
+	return nil
+}
+
+// gzipFileReader — распакованный поток gzip-файла кэша. Seek поддерживает только возврат в
+// начало (loadIndex считает хэш бандла отдельным проходом до разбора).
+type gzipFileReader struct {
+	f  *os.File
+	zr *gzip.Reader
+}
 
-	if limitedReader.N <= 0 {
-		return nil, fmt.Errorf("bundle too large (max %d MB)", maxSize/1024/1024)
+func (g *gzipFileReader) Read(p []byte) (int, error) {
+	n, err := g.zr.Read(p)
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("decompress cache %s: %w", g.f.Name(), err)
 	}
+	return n, err
+}
 
-	return data, nil
+func (g *gzipFileReader) Seek(offset int64, whence int) (int64, error) {
+	if offset != 0 || whence != io.SeekStart {
+		return 0, errors.New("gzip cache supports only rewinding to the start")
+	}
+	if _, err := g.f.Seek(0, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return 0, g.zr.Reset(g.f)
 }
 
-/*
--------------------------------------------------------------
-Core extraction logic
--------------------------------------------------------------
-*/
-type techniqueInfo struct {
-	ExternalID string   `json:"external_id"`
-	Name       string   `json:"name"`
-	Tactics    []string `json:"tactics,omitempty"`
+func (g *gzipFileReader) Close() error {
+	g.zr.Close()
+	return g.f.Close()
 }
 
-func main() {
-	/* ---------------------------------------------------------
-	   Парсинг флагов
-	   --------------------------------------------------------- */
-	flag.Parse()
+// cachingReader отдаёт тело ответа читателю и одновременно сжимает его gzip во временный файл
+// рядом с кэшем. Close дочитывает остаток тела (разбор может остановиться до хвостовых пробелов) и,
+// если тело получено без ошибок, записывает SHA-256 тела в файл-спутник, атомарно переименовывает
+// временный файл в path и сохраняет валидаторы ответа. Если записать кэш не удалось, данные всё равно отдаются читателю.
+type cachingReader struct {
+	body       io.ReadCloser
+	tmp        *os.File // nil после ошибки записи: кэш в этом запуске не обновляется
+	zw         *gzip.Writer
+	path       string
+	validators cacheValidators
+	size       int64
+	hash       hash.Hash // SHA-256 распакованного тела для файла-спутника
+	readErr    error     // первая ошибка чтения тела
+}
 
-	// Если запрошен help, показываем его и выходим с кодом 0
-	if *flagHelp {
-		printUsage()
-		os.Exit(0)
+func newCachingReader(body io.ReadCloser, path string, v cacheValidators) *cachingReader {
+	c := &cachingReader{body: body, path: path, validators: v, hash: sha256.New()}
+	tmp, err := createTmpFile(path + ".tmp")
+	if err != nil {
+		c.cacheFailed(err)
+	} else {
+		c.tmp = tmp
+		c.zw = gzip.NewWriter(tmp)
 	}
+	return c
+}
 
-	// Если не указаны обязательные флаги, показываем help и выходим с ошибкой
-	if *flagMitigation == "" && *flagMitigationName == "" {
-		printUsage()
-		fmt.Fprintln(os.Stderr, "\nERROR: must specify -mitigation or -mitigation-name")
-		os.Exit(1)
+func (c *cachingReader) Read(p []byte) (int, error) {
+	n, err := c.body.Read(p)
+	c.size += int64(n)
+	c.hash.Write(p[:n])
+	if n > 0 && c.tmp != nil {
+		if _, werr := c.zw.Write(p[:n]); werr != nil {
+			c.discardTmp()
+			c.cacheFailed(werr)
+		}
+	}
+	if err != nil && err != io.EOF && c.readErr == nil {
+		c.readErr = err
 	}
+	return n, err
+}
 
-	/* ---------------------------------------------------------
-	   Load the ATT&CK bundle
-	   --------------------------------------------------------- */
-	raw, err := fetchBundle()
+func (c *cachingReader) Close() error {
+	if c.readErr == nil {
+		_, _ = io.Copy(io.Discard, c)
+	}
+	c.body.Close()
+	if c.readErr != nil {
+		// Недокачанный бандл в кэш не попадает; прежний кэш и его валидаторы остаются
+		c.discardTmp()
+		return c.readErr
+	}
+	if *flagDbg {
+		fmt.Fprintf(os.Stderr, ">>> downloaded bundle (%d bytes)\n", c.size)
+	}
+	if c.tmp == nil {
+		return nil
+	}
+	tmpPath := c.tmp.Name()
+	err := c.zw.Close()
+	if cerr := c.tmp.Close(); err == nil {
+		err = cerr
+	}
+	// Спутник пишется первым: прерванная запись оставит старый бандл с новой суммой, и кэш
+	// будет отброшен при чтении, а не принят молча
+	if err == nil {
+		err = writeFileAtomic(checksumPath(c.path), []byte(hex.EncodeToString(c.hash.Sum(nil))+"\n"), 0o600)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, c.path)
+	}
+	untrackTmpFile(tmpPath)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error fetching ATT&CK bundle: %v\n", err)
-		os.Exit(1)
+		os.Remove(tmpPath)
+		c.cacheFailed(err)
+		return nil
 	}
-	var bundle Bundle
-	if err = json.Unmarshal(raw, &bundle); err != nil {
-		fmt.Fprintf(os.Stderr, "error parsing bundle JSON: %v\n", err)
-		os.Exit(1)
+	saveValidators(validatorsPath(c.path), c.validators)
+	if *flagDbg {
+		fmt.Fprintln(os.Stderr, ">>> cache saved successfully")
 	}
+	return nil
+}
 
-	/* ---------------------------------------------------------
-	   Build lookup maps (mitigations, techniques, relationships)
-	   --------------------------------------------------------- */
-	mitMap := make(map[string]courseOfAction) // key = STIX ID
-	techMap := make(map[string]attackPattern) // key = STIX ID
-	var rels []relationship
-
-	for _, rawObj := range bundle.Objects {
-		var bo baseObject
-		if err = json.Unmarshal(rawObj, &bo); err != nil {
-			continue // ignore malformed entries
-		}
-		switch bo.Type {
-		case "course-of-action":
-			var co courseOfAction
-			if err = json.Unmarshal(rawObj, &co); err == nil {
-				mitMap[co.ID] = co
+// discardTmp закрывает и удаляет временный файл кэша.
+func (c *cachingReader) discardTmp() {
+	if c.tmp == nil {
+		return
+	}
+	c.tmp.Close()
+	os.Remove(c.tmp.Name())
+	untrackTmpFile(c.tmp.Name())
+	c.tmp = nil
+}
+
+// cacheFailed обрабатывает ошибку записи кэша: валидаторы без записанного бандла дали бы 304
+// на старый (или отсутствующий) кэш, поэтому они удаляются.
+func (c *cachingReader) cacheFailed(err error) {
+	_ = os.Remove(validatorsPath(c.path))
+	if *flagDbg {
+		fmt.Fprintf(os.Stderr, ">>> WARNING: failed to write cache: %v\n", err)
+	}
+}
+
+// migrateUncompressedCache переносит несжатый кэш (oldPath) в gzip-кэш gzPath с сохранением
+// mtime (TTL продолжает отсчитываться от загрузки) и удаляет старый файл. Ошибки не фатальны:
+// в худшем случае бандл будет скачан заново.
+func migrateUncompressedCache(oldPath, gzPath string) {
+	info, err := os.Stat(oldPath)
+	if err != nil {
+		return
+	}
+	if _, err := os.Stat(gzPath); err == nil {
+		_ = os.Remove(oldPath) // gzip-кэш уже есть — старый файл не нужен
+		return
+	}
+	if err := compressFile(oldPath, gzPath); err != nil {
+		if *flagDbg {
+			fmt.Fprintf(os.Stderr, ">>> WARNING: failed to migrate cache to gzip: %v\n", err)
+		}
+		return
+	}
+	_ = os.Chtimes(gzPath, info.ModTime(), info.ModTime())
+	_ = os.Rename(validatorsPath(oldPath), validatorsPath(gzPath))
+	_ = os.Remove(oldPath)
+	if *flagDbg {
+		fmt.Fprintf(os.Stderr, ">>> migrated uncompressed cache %s -> %s\n", oldPath, gzPath)
+	}
+}
+
+// compressFile потоково сжимает файл src gzip в dst (атомарно, права 0600) и записывает SHA-256
+// несжатых данных в файл-спутник dst.
+func compressFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmpPath := dst + ".tmp"
+	out, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return fmt.Errorf("write %s: %w", tmpPath, err)
+	}
+	zw := gzip.NewWriter(out)
+	h := sha256.New()
+	_, err = io.Copy(zw, io.TeeReader(in, h))
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = writeFileAtomic(checksumPath(dst), []byte(hex.EncodeToString(h.Sum(nil))+"\n"), 0o600)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, dst)
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("compress %s: %w", src, err)
+	}
+	return nil
+}
+
+// cacheValidators — валидаторы HTTP-ответа (ETag, Last-Modified), сохраняемые рядом с кэшем
+// бандла для условного GET при следующем обновлении.
+type cacheValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// errNotModified — сервер ответил 304: бандл не изменился с сохранённой версии.
+var errNotModified = errors.New("bundle not modified")
+
+// validatorsPath возвращает путь файла валидаторов для кэшированного бандла.
+func validatorsPath(bundlePath string) string {
+	return bundlePath + ".meta"
+}
+
+// loadValidators читает сохранённые валидаторы; при любой ошибке возвращает пустые.
+func loadValidators(path string) cacheValidators {
+	var v cacheValidators
+	if data, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(data, &v)
+	}
+	return v
+}
+
+// saveValidators сохраняет валидаторы ответа; если сервер их не прислал, удаляет устаревший файл.
+func saveValidators(path string, v cacheValidators) {
+	if v == (cacheValidators{}) {
+		_ = os.Remove(path)
+		return
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+	if err := writeFileAtomic(path, data, 0o600); err != nil && *flagDbg {
+		fmt.Fprintf(os.Stderr, ">>> WARNING: failed to write cache validators: %v\n", err)
+	}
+}
+
+/*
+-------------------------------------------------------------
+Состояние кэша (-dry-run)
+-------------------------------------------------------------
+*/
+// cacheStatus — состояние файла бандла в кэше без чтения его содержимого.
+type cacheStatus struct {
+	dir     string // "/dev/null" — кэш отключён
+	path    string // сжатый кэш или несжатый кэш прежних версий, если есть только он
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+// statCache находит файл бандла выбранного домена в директории кэша.
+func statCache() cacheStatus {
+	st := cacheStatus{dir: getCacheDir()}
+	if st.dir == "/dev/null" {
+		return st
+	}
+	st.path = cachedBundlePath(st.dir)
+	for _, p := range []string{st.path, filepath.Join(st.dir, bundleFileName())} {
+		if info, err := os.Stat(p); err == nil {
+			st.path, st.exists, st.size, st.modTime = p, true, info.Size(), info.ModTime()
+			break
+		}
+	}
+	return st
+}
+
+// age возвращает возраст кэша (время с последней загрузки).
+func (st cacheStatus) age() time.Duration {
+	return time.Since(st.modTime)
+}
+
+// valid повторяет проверку isCacheValid: кэш есть и моложе cacheTTL.
+func (st cacheStatus) valid() bool {
+	return st.exists && st.age() < cacheTTL
+}
+
+// runCacheInfo печатает метаданные кэша: директорию, файл, размер, время изменения, возраст,
+// остаток TTL и spec_version бандла. Сеть не используется.
+func runCacheInfo(w io.Writer) int {
+	st := statCache()
+	if st.dir == "/dev/null" {
+		fmt.Fprintln(os.Stderr, "ERROR: cache is disabled (-no-cache)")
+		return 1
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+	fmt.Fprintf(tw, "cache dir:\t%s\n", st.dir)
+	if !st.exists {
+		fmt.Fprintf(tw, "bundle file:\t%s (missing)\n", st.path)
+		return exitOK
+	}
+	fmt.Fprintf(tw, "bundle file:\t%s\n", st.path)
+	fmt.Fprintf(tw, "size:\t%d bytes\n", st.size)
+	fmt.Fprintf(tw, "modified:\t%s\n", st.modTime.Format(time.RFC3339))
+	fmt.Fprintf(tw, "age:\t%s\n", st.age().Round(time.Second))
+	if remaining := cacheTTL - st.age(); remaining > 0 {
+		fmt.Fprintf(tw, "ttl remaining:\t%s\n", remaining.Round(time.Second))
+	} else {
+		fmt.Fprintf(tw, "ttl remaining:\texpired %s ago\n", (-remaining).Round(time.Second))
+	}
+	if spec, err := bundleSpecVersion(st.path); err != nil {
+		fmt.Fprintf(tw, "spec_version:\tunreadable (%v)\n", err)
+	} else {
+		fmt.Fprintf(tw, "spec_version:\t%s\n", spec)
+	}
+	return exitOK
+}
+
+// runDryRun печатает, что сделал бы fetchBundle: директорию и файл кэша, его возраст и
+// актуальность, и будет ли загрузка (с причиной). Ничего не скачивает и не меняет.
+func runDryRun(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+	if *flagBundleFile != "" {
+		fmt.Fprintf(tw, "source:\tlocal file %s\n", *flagBundleFile)
+		fmt.Fprintln(tw, "download:\tno (-bundle-file)")
+		return
+	}
+	if *flagEmbedded {
+		fmt.Fprintf(tw, "source:\tembedded snapshot (%d bytes)\n", len(embeddedBundle))
+		fmt.Fprintln(tw, "download:\tno (-embedded)")
+		return
+	}
+	st := statCache()
+	if st.dir == "/dev/null" {
+		fmt.Fprintln(tw, "cache dir:\tdisabled (-no-cache)")
+	} else {
+		fmt.Fprintf(tw, "cache dir:\t%s\n", st.dir)
+		if st.exists {
+			fmt.Fprintf(tw, "cache file:\t%s\n", st.path)
+			fmt.Fprintf(tw, "cache age:\t%s\n", st.age().Round(time.Second))
+		} else {
+			fmt.Fprintf(tw, "cache file:\t%s (missing)\n", st.path)
+		}
+		valid := "no"
+		if st.valid() {
+			valid = "yes"
+		}
+		fmt.Fprintf(tw, "cache valid:\t%s (TTL %s)\n", valid, cacheTTL)
+	}
+
+	var reason string
+	switch {
+	case st.dir == "/dev/null":
+		reason = "cache disabled"
+	case *flagForceRefresh:
+		reason = "-force-refresh"
+	case !st.exists:
+		reason = "cache missing"
+	case !st.valid():
+		reason = "cache expired"
+		if v := loadValidators(validatorsPath(st.path)); v != (cacheValidators{}) {
+			reason += "; conditional GET, 304 renews the cache"
+		}
+		if *flagAllowStale {
+			reason += "; -allow-stale falls back to it on failure"
+		}
+	default:
+		fmt.Fprintln(tw, "download:\tno (cache is fresh)")
+		return
+	}
+	fmt.Fprintf(tw, "download:\tyes (%s)\n", reason)
+	fmt.Fprintf(tw, "url:\t%s\n", bundleURL())
+}
+
+// runCheckFreshness сравнивает кэш с источником условным HEAD-запросом: сохранённые ETag и
+// Last-Modified (без них — время загрузки кэша) уходят как If-None-Match / If-Modified-Since.
+// 304 или совпавшие валидаторы ответа 200 — кэш актуален (exitOK), иначе — exitUpdateAvailable.
+// Бандл не скачивается, кэш и его TTL не меняются.
+func runCheckFreshness(w io.Writer) int {
+	if *flagBundleFile != "" || *flagEmbedded {
+		fmt.Fprintln(os.Stderr, "ERROR: -check-freshness needs the download source, not -bundle-file or -embedded")
+		return 1
+	}
+	st := statCache()
+	if st.dir == "/dev/null" {
+		fmt.Fprintln(os.Stderr, "ERROR: cache is disabled (-no-cache)")
+		return 1
+	}
+	if !st.exists {
+		fmt.Fprintf(w, "no cached bundle in %s: update available\n", st.dir)
+		return exitUpdateAvailable
+	}
+	v := loadValidators(validatorsPath(st.path))
+	client, err := newHTTPClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	req, err := http.NewRequestWithContext(interruptCtx, http.MethodHead, bundleURL(), nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: check freshness: %v\n", err)
+		return 1
+	}
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	lastModified := v.LastModified
+	if lastModified == "" {
+		lastModified = st.modTime.UTC().Format(http.TimeFormat)
+	}
+	req.Header.Set("If-Modified-Since", lastModified)
+	if *flagDbg {
+		fmt.Fprintf(os.Stderr, ">>> HEAD %s (If-None-Match: %q, If-Modified-Since: %q)\n", bundleURL(), v.ETag, lastModified)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: check freshness: %v\n", err)
+		return 1
+	}
+	resp.Body.Close()
+
+	fresh := false
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		fresh = true
+	case resp.StatusCode != http.StatusOK:
+		fmt.Fprintf(os.Stderr, "ERROR: check freshness: bundle HTTP %d\n", resp.StatusCode)
+		return 1
+	case v.ETag != "" && resp.Header.Get("ETag") != "":
+		// сервер не поддерживает условный HEAD — сравниваем валидаторы сами
+		fresh = resp.Header.Get("ETag") == v.ETag
+	default:
+		upstream, err := http.ParseTime(resp.Header.Get("Last-Modified"))
+		cached, cerr := http.ParseTime(lastModified)
+		fresh = err == nil && cerr == nil && !upstream.After(cached)
+	}
+	if fresh {
+		fmt.Fprintf(w, "cache is up to date (%s, age %s)\n", st.path, st.age().Round(time.Second))
+		return exitOK
+	}
+	fmt.Fprintf(w, "update available for %s (cached %s ago)\n", bundleURL(), st.age().Round(time.Second))
+	return exitUpdateAvailable
+}
+
+// reCacheEntry — имена файлов, которые mitremit создаёт в директории кэша: бандлы доменов
+// (сжатые и несжатые прежних версий, с хэшем -bundle-url в имени), SHA-256 и валидаторы,
+// снимки индекса, временные файлы.
+var reCacheEntry = regexp.MustCompile(`^(enterprise|mobile|ics)-attack(-[0-9a-f]{12})?\.(json|json\.gz|json\.gz\.sha256|json\.gz\.meta|json\.meta|index\.gob)(\.tmp)?$`)
+
+// clearCache удаляет файлы кэша всех доменов из dir и возвращает их пути. Если в dir есть хоть
+// что-то, кроме файлов кэша (в том числе поддиректории), ничего не удаляется: это защита от
+// -cache-dir, указывающего на произвольную директорию.
+func clearCache(dir string) ([]string, error) {
+	if dir == "/dev/null" {
+		return nil, errors.New("cache is disabled (-no-cache)")
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !reCacheEntry.MatchString(e.Name()) {
+			return nil, fmt.Errorf("refusing to clear %s: not a mitremit cache directory (unexpected entry %q)", dir, e.Name())
+		}
+	}
+	var removed []string
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if err := os.Remove(path); err != nil {
+			return removed, err
+		}
+		removed = append(removed, path)
+	}
+	return removed, nil
+}
+
+// runClearCache выполняет -clear-cache: печатает удалённые файлы и возвращает код выхода.
+func runClearCache(w io.Writer) int {
+	dir := getCacheDir()
+	removed, err := clearCache(dir)
+	for _, p := range removed {
+		fmt.Fprintf(w, "removed %s\n", p)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: clear cache: %v\n", err)
+		return 1
+	}
+	if len(removed) == 0 {
+		fmt.Fprintf(w, "cache %s is already empty\n", dir)
+	}
+	return exitOK
+}
+
+// embeddedBundleData возвращает встроенный при сборке снимок бандла Enterprise. Это данные на
+// момент сборки: они не обновляются, пока бинарник не пересобран.
+func embeddedBundleData() (io.ReadCloser, error) {
+	if len(embeddedBundle) == 0 {
+		return nil, errors.New("this binary has no embedded bundle (build with -tags embedbundle, see make build-embedded)")
+	}
+	if *flagDomain != domainEnterprise {
+		return nil, fmt.Errorf("the embedded bundle is enterprise only, not -domain %s", *flagDomain)
+	}
+	if *flagDbg {
+		fmt.Fprintf(os.Stderr, ">>> using embedded bundle snapshot (%d bytes)\n", len(embeddedBundle))
+	}
+	return io.NopCloser(bytes.NewReader(embeddedBundle)), nil
+}
+
+// bundleFromStdin — значение -bundle-file для чтения бандла из stdin (curl ... | mitremit -bundle-file -).
+const bundleFromStdin = "-"
+
+// writeFileAtomic записывает data во временный файл рядом с path и атомарно
+// переименовывает его в path, чтобы читатель никогда не увидел недописанный файл.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	// Обработчик SIGINT ждёт этот мьютекс: прерывание не оставит недописанный .tmp
+	atomicWriteMu.Lock()
+	defer atomicWriteMu.Unlock()
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, perm); err != nil {
+		return fmt.Errorf("write %s: %w", tmpPath, err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		// Пытаемся удалить временный файл
+		os.Remove(tmpPath)
+		return fmt.Errorf("rename %s: %w", tmpPath, err)
+	}
+	return nil
+}
+
+/*
+-------------------------------------------------------------
+Прерывание (SIGINT)
+-------------------------------------------------------------
+*/
+// exitInterrupted — код выхода по Ctrl-C (128 + SIGINT), как принято в оболочках.
+const exitInterrupted = 130
+
+// interruptCtx отменяется по SIGINT; HTTP-запросы создаются с ним, поэтому загрузка бандла
+// обрывается сразу, а не по -timeout.
+var interruptCtx, cancelInterrupt = context.WithCancel(context.Background())
+
+// atomicWriteMu сериализует writeFileAtomic и учёт pendingTmpFiles с обработчиком прерывания.
+var atomicWriteMu sync.Mutex
+
+// pendingTmpFiles — временные файлы потоковой записи кэша (cachingReader), ещё не
+// переименованные и не удалённые; обработчик прерывания удаляет их перед выходом.
+var pendingTmpFiles = make(map[string]bool)
+
+// createTmpFile создаёт временный файл path и записывает его в pendingTmpFiles. Создание и
+// учёт идут под atomicWriteMu: после прерывания новый файл уже не появится.
+func createTmpFile(path string) (*os.File, error) {
+	atomicWriteMu.Lock()
+	defer atomicWriteMu.Unlock()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err == nil {
+		pendingTmpFiles[path] = true
+	}
+	return f, err
+}
+
+// untrackTmpFile убирает path из pendingTmpFiles (файл переименован или удалён).
+func untrackTmpFile(path string) {
+	atomicWriteMu.Lock()
+	defer atomicWriteMu.Unlock()
+	delete(pendingTmpFiles, path)
+}
+
+// handleInterrupt ставит обработчик SIGINT: отменяет interruptCtx, дожидается окончания
+// текущей атомарной записи (временный файл к этому моменту переименован или удалён, новая
+// запись уже не начнётся), удаляет недописанные файлы потоковой загрузки и завершает процесс
+// с кодом exitInterrupted.
+func handleInterrupt() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	go func() {
+		<-sigs
+		cancelInterrupt()
+		atomicWriteMu.Lock()
+		for path := range pendingTmpFiles {
+			os.Remove(path)
+		}
+		fmt.Fprintln(os.Stderr, "\ninterrupted")
+		os.Exit(exitInterrupted)
+	}()
+}
+
+/* ---------- helper used by fetchBundle ---------- */
+// Повторы загрузки по умолчанию (-retries, -retry-delay): задержки 1s, 2s, 4s.
+const (
+	defaultRetries    = 3
+	defaultRetryDelay = time.Second
+)
+
+// defaultDownloadTimeout — таймаут HTTP-клиента по умолчанию (-timeout): долгая загрузка больших файлов.
+const defaultDownloadTimeout = 5 * time.Minute
+
+// downloadBundle запрашивает бандл и возвращает тело ответа для потокового чтения. Непустые
+// валидаторы отправляются как If-None-Match / If-Modified-Since; ответ 304 возвращается как
+// errNotModified. Сетевые ошибки и ответы 5xx до начала тела повторяются до -retries раз
+// с экспоненциальной задержкой, ответы 4xx — нет; обрыв уже отданного разбору тела не
+// повторяется (разбор не может начаться заново) и приводит к ошибке.
+func downloadBundle(v cacheValidators) (io.ReadCloser, cacheValidators, error) {
+	if *flagDbg {
+		fmt.Fprintf(os.Stderr, ">>> downloading from: %s\n", bundleURL())
+	}
+
+	client, err := newHTTPClient()
+	if err != nil {
+		return nil, cacheValidators{}, err
+	}
+
+	delay := *flagRetryDelay
+	for attempt := 0; ; attempt++ {
+		body, newValidators, retryable, err := downloadOnce(client, v)
+		if err == nil || !retryable || attempt >= *flagRetries || interruptCtx.Err() != nil {
+			return body, newValidators, err
+		}
+		if *flagDbg {
+			fmt.Fprintf(os.Stderr, ">>> download attempt %d failed (%v) – retrying in %s\n", attempt+1, err, delay)
+		}
+		// Прерывание во время паузы не ждёт её конца и не запускает новую попытку
+		select {
+		case <-interruptCtx.Done():
+			return nil, cacheValidators{}, interruptCtx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// newHTTPClient создаёт HTTP-клиент для загрузки бандла: прокси из -proxy, иначе из окружения
+// (HTTP_PROXY, HTTPS_PROXY, NO_PROXY).
+func newHTTPClient() (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if *flagProxy != "" {
+		proxyURL, err := parseProxyURL(*flagProxy)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	tlsConfig, err := tlsConfigFromFlags()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		transport.TLSClientConfig = tlsConfig
+	}
+	// Создаем HTTP клиент с таймаутом (-timeout)
+	return &http.Client{
+		Transport: transport,
+		Timeout:   *flagTimeout,
+	}, nil
+}
+
+// errPinMismatch — открытый ключ сервера не совпал ни с одним хэшем из -pin-sha256.
+var errPinMismatch = errors.New("server public key does not match -pin-sha256")
+
+// tlsConfigFromFlags строит TLS-конфигурацию загрузки из -ca-file и -pin-sha256; nil — если оба
+// флага не заданы (системный пул CA без пиннинга).
+func tlsConfigFromFlags() (*tls.Config, error) {
+	if *flagCAFile == "" && *flagPinSHA256 == "" {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if *flagCAFile != "" {
+		pemData, err := os.ReadFile(*flagCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read -ca-file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pemData) {
+			return nil, fmt.Errorf("-ca-file %s contains no PEM certificates", *flagCAFile)
+		}
+		cfg.RootCAs = pool
+	}
+	if *flagPinSHA256 != "" {
+		pins, err := parsePins(*flagPinSHA256)
+		if err != nil {
+			return nil, err
+		}
+		// Цепочка уже проверена обычным способом; пин дополнительно сверяется с ключом сервера
+		cfg.VerifyConnection = func(cs tls.ConnectionState) error {
+			if len(cs.PeerCertificates) == 0 {
+				return errPinMismatch
+			}
+			sum := sha256.Sum256(cs.PeerCertificates[0].RawSubjectPublicKeyInfo)
+			if !pins[sum] {
+				return fmt.Errorf("%w (got %s)", errPinMismatch, base64.StdEncoding.EncodeToString(sum[:]))
+			}
+			return nil
+		}
+	}
+	return cfg, nil
+}
+
+// parsePins разбирает -pin-sha256: хэши SHA-256 ключа (SPKI) в base64 через запятую, как у
+// "openssl x509 -pubkey | openssl pkey -pubin -outform der | openssl dgst -sha256 -binary | base64".
+func parsePins(raw string) (map[[sha256.Size]byte]bool, error) {
+	pins := make(map[[sha256.Size]byte]bool)
+	for _, p := range splitList(raw) {
+		b, err := base64.StdEncoding.DecodeString(p)
+		if err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("invalid -pin-sha256 %q: want a base64-encoded SHA-256 hash", p)
+		}
+		pins[[sha256.Size]byte(b)] = true
+	}
+	return pins, nil
+}
+
+// parseProxyURL проверяет URL прокси: нужны схема http, https или socks5 и хост.
+func parseProxyURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -proxy %q: %w", raw, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid -proxy %q: scheme must be http, https or socks5", raw)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid -proxy %q: missing host", raw)
+	}
+	return u, nil
+}
+
+// downloadOnce выполняет одну попытку запроса; retryable сообщает, что ошибку стоит повторить
+// (сетевая ошибка или ответ 5xx).
+func downloadOnce(client *http.Client, v cacheValidators) (body io.ReadCloser, newValidators cacheValidators, retryable bool, err error) {
+	req, err := http.NewRequestWithContext(interruptCtx, http.MethodGet, bundleURL(), nil)
+	if err != nil {
+		return nil, cacheValidators{}, false, fmt.Errorf("download bundle: %w", err)
+	}
+	if v.ETag != "" {
+		req.Header.Set("If-None-Match", v.ETag)
+	}
+	if v.LastModified != "" {
+		req.Header.Set("If-Modified-Since", v.LastModified)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// Несовпадение пина повтором не исправить
+		return nil, cacheValidators{}, !errors.Is(err, errPinMismatch), fmt.Errorf("download bundle: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return nil, v, false, errNotModified
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, cacheValidators{}, resp.StatusCode >= 500, fmt.Errorf("bundle HTTP %d", resp.StatusCode)
+	}
+	newValidators = cacheValidators{
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+
+	// Прогресс в stderr только для терминала: в логах CI и с -quiet его нет. Считаются байты
+	// ответа, поэтому процент сходится с Content-Length и для сжатого бандла.
+	var raw io.ReadCloser = resp.Body
+	if term.IsTerminal(int(os.Stderr.Fd())) && !*flagQuiet {
+		raw = &progressReader{ReadCloser: raw, w: os.Stderr, total: resp.ContentLength}
+	}
+	dec, compressed, err := gunzipIfCompressed(raw)
+	if err != nil {
+		raw.Close()
+		return nil, cacheValidators{}, false, fmt.Errorf("download bundle: %w", err)
+	}
+	if compressed && *flagDbg {
+		fmt.Fprintln(os.Stderr, ">>> bundle is gzip-compressed – decompressing")
+	}
+	// Для сжатого бандла ограничение действует на распакованные данные — защита от gzip-бомб
+	body = &limitedBody{ReadCloser: struct {
+		io.Reader
+		io.Closer
+	}{dec, raw}, left: maxBundleSize}
+	return body, newValidators, false, nil
+}
+
+// maxBundleSize — предельный размер скачиваемого бандла.
+const maxBundleSize = 200 * 1024 * 1024 // 200MB
+
+// limitedBody — тело ответа с ограничением размера: чтение сверх left возвращает ошибку.
+type limitedBody struct {
+	io.ReadCloser
+	left int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	if b.left <= 0 {
+		return 0, fmt.Errorf("bundle too large (max %d MB)", maxBundleSize/1024/1024)
+	}
+	if int64(len(p)) > b.left {
+		p = p[:b.left]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.left -= int64(n)
+	if err != nil && err != io.EOF {
+		err = fmt.Errorf("read response: %w", err)
+	}
+	return n, err
+}
+
+// gzipMagic — первые байты потока gzip (RFC 1952).
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// gunzipIfCompressed распознаёт gzip по сигнатуре и возвращает поток распакованных данных;
+// иначе — исходный поток без изменений. Сигнатура, а не имя файла или Content-Encoding:
+// зеркала отдают .json.gz и с Content-Type application/gzip, и вовсе без заголовков, а ответ
+// с Content-Encoding: gzip net/http распаковывает сам.
+func gunzipIfCompressed(r io.Reader) (io.Reader, bool, error) {
+	br := bufio.NewReader(r)
+	head, err := br.Peek(len(gzipMagic))
+	if err != nil || !bytes.Equal(head, gzipMagic) {
+		// Короткий или пустой ответ разбирается дальше как есть
+		return br, false, nil
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, false, fmt.Errorf("open gzip bundle: %w", err)
+	}
+	return zr, true, nil
+}
+
+// progressRedraw — минимальный интервал перерисовки индикатора загрузки.
+const progressRedraw = 100 * time.Millisecond
+
+// progressReader считает прочитанные байты и рисует в w строку прогресса: процент по
+// Content-Length или, если длина неизвестна (-1), спиннер с числом мегабайт. Тело
+// читается потоково при разборе, поэтому итоговая строка рисуется в Close.
+type progressReader struct {
+	io.ReadCloser
+	w     io.Writer
+	total int64
+	read  int64
+	frame int
+	drawn time.Time
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.ReadCloser.Read(b)
+	p.read += int64(n)
+	if time.Since(p.drawn) >= progressRedraw {
+		p.draw()
+	}
+	return n, err
+}
+
+func (p *progressReader) draw() {
+	p.drawn = time.Now()
+	mb := float64(p.read) / (1 << 20)
+	if p.total > 0 {
+		fmt.Fprintf(p.w, "\rdownloading bundle: %3d%% (%.1f/%.1f MB)",
+			p.read*100/p.total, mb, float64(p.total)/(1<<20))
+		return
+	}
+	p.frame++
+	fmt.Fprintf(p.w, "\rdownloading bundle: %c %.1f MB", `|/-\`[p.frame%4], mb)
+}
+
+// Close рисует итоговое состояние и переводит строку, чтобы следующий вывод не затёр прогресс.
+func (p *progressReader) Close() error {
+	p.draw()
+	fmt.Fprintln(p.w)
+	return p.ReadCloser.Close()
+}
+
+/*
+-------------------------------------------------------------
+Аргументы из файла (@argfile)
+-------------------------------------------------------------
+*/
+// expandArgFiles заменяет каждый аргумент вида @path содержимым файла path,
+// разбитым по пробелам и переводам строк. Остальные аргументы сохраняются как есть.
+func expandArgFiles(args []string) ([]string, error) {
+	var out []string
+	for _, a := range args {
+		if len(a) < 2 || a[0] != '@' {
+			out = append(out, a)
+			continue
+		}
+		data, err := os.ReadFile(a[1:])
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", a[1:], err)
+		}
+		out = append(out, strings.Fields(string(data))...)
+	}
+	return out, nil
+}
+
+/*
+-------------------------------------------------------------
+Файл конфигурации (.mitremitrc)
+-------------------------------------------------------------
+*/
+// configFileName — файл конфигурации в рабочей директории; второй кандидат —
+// $XDG_CONFIG_HOME/mitremit/config (по умолчанию ~/.config/mitremit/config).
+const configFileName = ".mitremitrc"
+
+// configFormatKey — псевдоключ конфигурации для формата вывода ("json", "csv", ...),
+// т.к. формат выбирается булевыми флагами.
+const configFormatKey = "format"
+
+// configFormats — значения ключа "format" и соответствующие им флаги.
+var configFormats = []string{formatTable, formatJSON, formatJSONL, formatCSV, formatTSV, formatNGQL, formatDOT, formatCypher,
+	formatMermaid, formatJSONGraph, formatMarkdown, formatHTML}
+
+// configAllowedKeys — ключи, которые можно задать в файле конфигурации. Файл читается и из
+// рабочей директории (например, из чужого клонированного репозитория), поэтому флаги, которые
+// запускают команды (-exec), пишут файлы (-output, -output-dir) или меняют источник бандла
+// (-bundle-file), через него не задаются.
+var configAllowedKeys = []string{"cache-dir", "domain", configFormatKey}
+
+// configPaths возвращает кандидатов на файл конфигурации в порядке приоритета.
+func configPaths() []string {
+	paths := []string{configFileName}
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		if home, err := os.UserHomeDir(); err == nil {
+			base = filepath.Join(home, ".config")
+		}
+	}
+	if base != "" {
+		paths = append(paths, filepath.Join(base, "mitremit", "config"))
+	}
+	return paths
+}
+
+// applyConfigFile читает первый найденный файл конфигурации — JSON-объект, ключи которого
+// совпадают с именами флагов из configAllowedKeys ({"cache-dir": "/cache", "domain": "ics",
+// "format": "json"}), — и выставляет значения флагов, не заданных в командной строке. Любой
+// другой ключ — ошибка. Возвращает путь прочитанного файла ("" — файла нет).
+func applyConfigFile() (string, error) {
+	var path string
+	var data []byte
+	for _, p := range configPaths() {
+		b, err := os.ReadFile(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return p, err
+		}
+		path, data = p, b
+		break
+	}
+	if path == "" {
+		return "", nil
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return path, fmt.Errorf("parse %s: %w", path, err)
+	}
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	formatSet := slices.ContainsFunc(configFormats, func(f string) bool { return set[f] })
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, name := range keys {
+		value := fmt.Sprint(values[name])
+		if name == configFormatKey {
+			if !slices.Contains(configFormats, value) {
+				return path, fmt.Errorf("%s: unknown format %q", path, value)
+			}
+			if !formatSet && value != formatTable {
+				_ = flag.Set(value, "true")
+			}
+			continue
+		}
+		if flag.Lookup(name) == nil {
+			return path, fmt.Errorf("%s: unknown option %q", path, name)
+		}
+		if !slices.Contains(configAllowedKeys, name) {
+			return path, fmt.Errorf("%s: option %q cannot be set in a config file (allowed: %s)",
+				path, name, strings.Join(configAllowedKeys, ", "))
+		}
+		if set[name] {
+			continue // командная строка важнее конфигурации
+		}
+		if err := flag.Set(name, value); err != nil {
+			return path, fmt.Errorf("%s: option %q: %w", path, name, err)
+		}
+	}
+	return path, nil
+}
+
+/*
+-------------------------------------------------------------
+Переменные окружения (MITREMIT_*)
+-------------------------------------------------------------
+*/
+// envPrefix — префикс переменных окружения, подменяющих флаги: MITREMIT_DOMAIN для -domain,
+// MITREMIT_CACHE_DIR для -cache-dir, MITREMIT_FORMAT для формата вывода (как ключ "format").
+const envPrefix = "MITREMIT_"
+
+// envName возвращает имя переменной окружения для флага: -no-cache → MITREMIT_NO_CACHE.
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// applyEnv выставляет флаги, не заданные в командной строке, из переменных MITREMIT_*. Как и в
+// файле конфигурации, допускаются только ключи configAllowedKeys; переменная для любого другого
+// флага — ошибка. MITREMIT_CACHE_DIR, как и MITRE_CACHE_DIR, принимается только абсолютным путём.
+// Вызывается до applyConfigFile: командная строка важнее окружения, окружение — файла.
+// Возвращает применённые переменные (для -debug).
+func applyEnv() ([]string, error) {
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	var allowed []string
+	for _, k := range configAllowedKeys {
+		allowed = append(allowed, envName(k))
+	}
+	var applied []string
+	if value, ok := os.LookupEnv(envName(configFormatKey)); ok && value != "" {
+		if !slices.Contains(configFormats, value) {
+			return nil, fmt.Errorf("%s: unknown format %q", envName(configFormatKey), value)
+		}
+		if !slices.ContainsFunc(configFormats, func(f string) bool { return set[f] }) && value != formatTable {
+			_ = flag.Set(value, "true")
+			applied = append(applied, envName(configFormatKey))
+		}
+	}
+	var err error
+	flag.VisitAll(func(f *flag.Flag) {
+		value, ok := os.LookupEnv(envName(f.Name))
+		if err != nil || !ok || value == "" {
+			return
+		}
+		if !slices.Contains(configAllowedKeys, f.Name) {
+			err = fmt.Errorf("%s cannot set -%s (allowed: %s)", envName(f.Name), f.Name, strings.Join(allowed, ", "))
+			return
+		}
+		if set[f.Name] {
+			return
+		}
+		if f.Name == "cache-dir" {
+			cleaned := filepath.Clean(value)
+			if !filepath.IsAbs(cleaned) {
+				warnf("%s must be absolute path, ignoring: %s", envName(f.Name), value)
+				return
+			}
+			value = cleaned
+		}
+		if serr := flag.Set(f.Name, value); serr != nil {
+			err = fmt.Errorf("%s: %w", envName(f.Name), serr)
+			return
+		}
+		applied = append(applied, envName(f.Name))
+	})
+	return applied, err
+}
+
+/*
+-------------------------------------------------------------
+Core extraction logic
+-------------------------------------------------------------
+*/
+type techniqueInfo struct {
+	ExternalID       string   `json:"external_id"`
+	Name             string   `json:"name"`
+	Tactics          []string `json:"tactics,omitempty"`
+	Platforms        []string `json:"platforms,omitempty"`
+	Description      string   `json:"description,omitempty"`       // только с -show-description
+	URL              string   `json:"url,omitempty"`               // только с -show-url
+	Replaces         string   `json:"replaces,omitempty"`          // ID отозванной техники, заменённой этой (revoked-by)
+	Groups           []string `json:"groups,omitempty"`            // только с -show-groups: "Название (Gxxxx)"
+	Software         []string `json:"software,omitempty"`          // только с -show-software: "Название (Sxxxx)"
+	Campaigns        []string `json:"campaigns,omitempty"`         // только с -show-campaigns: "Название (Cxxxx)"
+	DataSources      []string `json:"data_sources,omitempty"`      // только с -show-datasources (x_mitre_data_sources)
+	MitigationDetail string   `json:"mitigation_detail,omitempty"` // только с -show-relationship-desc: описание связи mitigates
+	Mitigation       string   `json:"mitigation,omitempty"`        // внешний ID митигации, давшей эту строку
+
+	parent string // внешний ID родительской техники (subtechnique-of); только с -tree
+	stixID string // STIX ID техники (-stix копирует объекты по нему)
+}
+
+/*
+-------------------------------------------------------------
+JSON Schema вывода (-json-schema)
+-------------------------------------------------------------
+*/
+// jsonSchema — подмножество JSON Schema (draft 2020-12), достаточное для описания результата.
+type jsonSchema struct {
+	Schema     string                 `json:"$schema,omitempty"`
+	Title      string                 `json:"title,omitempty"`
+	Type       string                 `json:"type"`
+	Items      *jsonSchema            `json:"items,omitempty"`
+	Properties map[string]*jsonSchema `json:"properties,omitempty"`
+	Required   []string               `json:"required,omitempty"`
+}
+
+// schemaForType строит схему типа t по его JSON-тегам: неэкспортируемые поля и поля с тегом "-"
+// пропускаются, поля без omitempty обязательны. Схема выводится из структуры и не может разойтись
+// с ней при добавлении полей.
+func schemaForType(t reflect.Type) *jsonSchema {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return schemaForType(t.Elem())
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return &jsonSchema{Type: "array", Items: schemaForType(t.Elem())}
+	case reflect.Struct:
+		s := &jsonSchema{Type: "object", Properties: make(map[string]*jsonSchema)}
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			s.Properties[name] = schemaForType(f.Type)
+			if !slices.Contains(strings.Split(opts, ","), "omitempty") {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+	}
+	return &jsonSchema{Type: "object"}
+}
+
+// printJSONSchema выводит схему вывода -json: массив объектов techniqueInfo.
+func printJSONSchema(w io.Writer) {
+	schema := &jsonSchema{
+		Schema: "https://json-schema.org/draft/2020-12/schema",
+		Title:  "mitremit -json output",
+		Type:   "array",
+		Items:  schemaForType(reflect.TypeFor[techniqueInfo]()),
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(schema)
+}
+
+/*
+-------------------------------------------------------------
+Снимок разобранного индекса (-index-cache)
+-------------------------------------------------------------
+*/
+// indexSnapshotVersion меняется при изменении набора полей attack.Index/indexSnapshot, чтобы
+// снимки старого формата не использовались.
+const indexSnapshotVersion = 10
+
+// indexSnapshot — сериализуемая (gob) копия attack.Index с хэшем бандла, из которого она построена.
+type indexSnapshot struct {
+	Version       int
+	BundleSHA256  string
+	SpecVersion   string
+	AttackVersion string
+	Mitigations   map[string]attack.CourseOfAction
+	Techniques    map[string]attack.AttackPattern
+	Groups        map[string]attack.IntrusionSet
+	Software      map[string]attack.Software
+	Campaigns     map[string]attack.Campaign
+	DataSources   map[string]attack.DataSource
+	DataComps     map[string]attack.DataComponent
+	Tactics       map[string]attack.Tactic
+	Rels          []attack.Relationship
+}
+
+// loadIndex строит справочники бандла из потока r и возвращает их вместе с SHA-256 бандла.
+// С -index-cache сначала пробует снимок индекса из директории кэша (совпадение по SHA-256
+// бандла), а после полного разбора сохраняет новый снимок.
+func loadIndex(r io.Reader) (*attack.Index, string, error) {
+	var snapshotPath, hash string
+	if *flagIndexCache {
+		if dir := getCacheDir(); dir != "/dev/null" {
+			snapshotPath = filepath.Join(dir, *flagDomain+"-attack.index.gob")
+			// Хэш до разбора есть только у файла (кэш, -bundle-file): считаем его отдельным
+			// проходом и возвращаемся в начало; ответ сервера хэшируется во время разбора
+			if f, ok := r.(io.ReadSeeker); ok {
+				h := sha256.New()
+				if _, err := io.Copy(h, f); err != nil {
+					return nil, "", err
+				}
+				if _, err := f.Seek(0, io.SeekStart); err != nil {
+					return nil, "", err
+				}
+				hash = hex.EncodeToString(h.Sum(nil))
+				if idx, ok := readIndexSnapshot(snapshotPath, hash); ok {
+					if *flagDbg {
+						fmt.Fprintf(os.Stderr, ">>> index snapshot hit: %s\n", snapshotPath)
+					}
+					return idx, hash, nil
+				}
+			}
+		}
+	}
+	h := sha256.New()
+	idx, err := parseIndex(io.TeeReader(r, h))
+	if err != nil {
+		return nil, "", err
+	}
+	// Декодер может не дочитать хвост потока (перевод строки после бандла)
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, "", err
+	}
+	hash = hex.EncodeToString(h.Sum(nil))
+	if snapshotPath != "" {
+		if err := writeIndexSnapshot(snapshotPath, hash, idx); err != nil && *flagDbg {
+			fmt.Fprintf(os.Stderr, ">>> WARNING: failed to write index snapshot: %v\n", err)
+		}
+	}
+	return idx, hash, nil
+}
+
+// defaultStreamBuffer — ёмкость канала потокового разбора по умолчанию. Потоковый разбор читает
+// объекты прямо из файла или тела ответа и не держит в памяти ни исходный JSON, ни срез objects
+// ([]json.RawMessage — копия всех объектов бандла).
+const defaultStreamBuffer = 64
+
+// parseIndex разбирает бандл из r потоково (по умолчанию) или, с -stream-buffer 0, прочитав
+// его целиком в память (запасной путь).
+func parseIndex(r io.Reader) (*attack.Index, error) {
+	if *flagStreamBuffer > 0 {
+		return attack.ParseStream(r, *flagStreamBuffer)
+	}
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return attack.ParseBundle(raw)
+}
+
+// readIndexSnapshot загружает снимок индекса; ok == false, если снимка нет, он повреждён,
+// другого формата или построен из другого бандла.
+func readIndexSnapshot(path, bundleHash string) (*attack.Index, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	var snap indexSnapshot
+	if err := gob.NewDecoder(f).Decode(&snap); err != nil {
+		return nil, false
+	}
+	if snap.Version != indexSnapshotVersion || snap.BundleSHA256 != bundleHash {
+		return nil, false
+	}
+	idx := attack.NewIndex()
+	idx.SpecVersion = snap.SpecVersion
+	idx.AttackVersion = snap.AttackVersion
+	idx.Rels = snap.Rels
+	// gob не сохраняет пустые map — оставляем созданные attack.NewIndex
+	if snap.Mitigations != nil {
+		idx.Mitigations = snap.Mitigations
+	}
+	if snap.Techniques != nil {
+		idx.Techniques = snap.Techniques
+	}
+	if snap.Groups != nil {
+		idx.Groups = snap.Groups
+	}
+	if snap.Software != nil {
+		idx.Software = snap.Software
+	}
+	if snap.Campaigns != nil {
+		idx.Campaigns = snap.Campaigns
+	}
+	if snap.DataSources != nil {
+		idx.DataSources = snap.DataSources
+	}
+	if snap.DataComps != nil {
+		idx.DataComponents = snap.DataComps
+	}
+	if snap.Tactics != nil {
+		idx.Tactics = snap.Tactics
+	}
+	return idx, true
+}
+
+// writeIndexSnapshot атомарно сохраняет снимок индекса idx, построенного из бандла с хэшем bundleHash.
+func writeIndexSnapshot(path, bundleHash string, idx *attack.Index) error {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(indexSnapshot{
+		Version:       indexSnapshotVersion,
+		BundleSHA256:  bundleHash,
+		SpecVersion:   idx.SpecVersion,
+		AttackVersion: idx.AttackVersion,
+		Mitigations:   idx.Mitigations,
+		Techniques:    idx.Techniques,
+		Groups:        idx.Groups,
+		Software:      idx.Software,
+		Campaigns:     idx.Campaigns,
+		DataSources:   idx.DataSources,
+		DataComps:     idx.DataComponents,
+		Tactics:       idx.Tactics,
+		Rels:          idx.Rels,
+	})
+	if err != nil {
+		return fmt.Errorf("encode index snapshot: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("create cache directory: %w", err)
+	}
+	return writeFileAtomic(path, buf.Bytes(), 0o600)
+}
+
+// techniqueFilter — условия отбора техник, заданные флагами фильтрации.
+type techniqueFilter struct {
+	createdAfter   time.Time
+	createdBefore  time.Time
+	modifiedSince  time.Time
+	deprecatedOnly bool
+	includeDepr    bool     // не отбрасывать техники с x_mitre_deprecated
+	tactic         string   // shortname тактики; пусто — без фильтра
+	platforms      []string // техника проходит, если у неё есть хотя бы одна из платформ
+}
+
+// filterFromFlags собирает techniqueFilter из флагов и проверяет их значения.
+func filterFromFlags() (techniqueFilter, error) {
+	f := techniqueFilter{
+		deprecatedOnly: *flagDeprecatedOnly,
+		includeDepr:    *flagIncludeDeprecated,
+		tactic:         strings.TrimSpace(*flagTactic),
+		platforms:      splitList(*flagPlatform),
+	}
+	var err error
+	if *flagCreatedAfter != "" {
+		if f.createdAfter, err = parseDateBound(*flagCreatedAfter, false); err != nil {
+			return f, fmt.Errorf("invalid -created-after: %w", err)
+		}
+	}
+	if *flagCreatedBefore != "" {
+		if f.createdBefore, err = parseDateBound(*flagCreatedBefore, true); err != nil {
+			return f, fmt.Errorf("invalid -created-before: %w", err)
+		}
+	}
+	if *flagModifiedSince != "" {
+		if f.modifiedSince, err = parseDateBound(*flagModifiedSince, false); err != nil {
+			return f, fmt.Errorf("invalid -modified-since: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// keep сообщает, проходит ли техника все фильтры.
+func (f techniqueFilter) keep(ap attack.AttackPattern) bool {
+	if !inDateRange(ap.Created, f.createdAfter, f.createdBefore) {
+		return false
+	}
+	if !inDateRange(ap.Modified, f.modifiedSince, time.Time{}) {
+		return false
+	}
+	if f.deprecatedOnly && !ap.Obsolete() {
+		return false
+	}
+	if ap.Deprecated && !f.includeDepr && !f.deprecatedOnly {
+		return false
+	}
+	if f.tactic != "" && !slices.ContainsFunc(attack.TacticsFromKillChain(ap.KillChainPhases), func(t string) bool {
+		return strings.EqualFold(t, f.tactic)
+	}) {
+		return false
+	}
+	if len(f.platforms) > 0 && !slices.ContainsFunc(ap.Platforms, func(p string) bool {
+		return slices.ContainsFunc(f.platforms, func(want string) bool { return strings.EqualFold(p, want) })
+	}) {
+		return false
+	}
+	return true
+}
+
+// mitigationByExtID возвращает STIX ID митигации по внешнему ID или "": без -strict-id регистр
+// не учитывается (m1037 == M1037), с -strict-id нужно точное совпадение. Из нескольких объектов
+// с одним ID выбирается лучший по attack.MitigationCandidates; с -debug кандидаты выводятся в stderr.
+func mitigationByExtID(idx *attack.Index, want string) string {
+	// Голый номер дополняется префиксом M и для сравнения с -strict-id
+	want = attack.NormalizeMitigationID(want)
+	candidates := idx.MitigationCandidates(want)
+	if *flagStrictID {
+		candidates = slices.DeleteFunc(candidates, func(id string) bool {
+			return mitigationExtID(idx.Mitigations[id]) != want
+		})
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	if len(candidates) > 1 && *flagDbg {
+		fmt.Fprintf(os.Stderr, ">>> %d objects share mitigation ID %s; using %s\n", len(candidates), want, candidates[0])
+		for _, id := range candidates {
+			co := idx.Mitigations[id]
+			fmt.Fprintf(os.Stderr, ">>>   candidate %s (modified %s, revoked %t, deprecated %t)\n",
+				id, co.Modified, co.Revoked, co.Deprecated)
+		}
+	}
+	return candidates[0]
+}
+
+// mitigationByName возвращает STIX ID митигации с названием name (без учёта регистра) или "".
+// Объекты одной митигации (общий внешний ID) разрешаются как в mitigationByExtID; если название
+// носят разные митигации, STIX ID пуст, а ambiguous — все они, отсортированные по внешнему ID.
+func mitigationByName(idx *attack.Index, name string) (stixID string, ambiguous []attack.CourseOfAction) {
+	byExt := make(map[string][]string) // внешний ID -> STIX ID объектов с этим названием
+	for id, co := range idx.Mitigations {
+		if strings.EqualFold(co.Name, name) {
+			ext := mitigationExtID(co)
+			byExt[ext] = append(byExt[ext], id)
+		}
+	}
+	switch len(byExt) {
+	case 0:
+		return "", nil
+	case 1:
+		for _, ids := range byExt {
+			if len(ids) == 1 {
+				return ids[0], nil
+			}
+			// Несколько версий одной митигации: лучший кандидат среди совпавших по названию
+			for _, id := range idx.MitigationCandidates(mitigationExtID(idx.Mitigations[ids[0]])) {
+				if slices.Contains(ids, id) {
+					return id, nil
+				}
+			}
+			slices.Sort(ids)
+			return ids[0], nil
+		}
+	}
+	for _, ids := range byExt {
+		slices.Sort(ids)
+		ambiguous = append(ambiguous, idx.Mitigations[ids[0]])
+	}
+	sort.Slice(ambiguous, func(i, j int) bool {
+		return mitigationExtID(ambiguous[i]) < mitigationExtID(ambiguous[j])
+	})
+	return "", ambiguous
+}
+
+// mitigationExtID возвращает внешний ID митигации; без ID ATT&CK — хвост STIX ID.
+func mitigationExtID(co attack.CourseOfAction) string {
+	if ext, ok := attack.ExternalID(co.ExternalRefs); ok {
+		return ext
+	}
+	return strings.TrimPrefix(co.ID, "course-of-action--")
+}
+
+// mitigationStatus возвращает "revoked" или "deprecated" для отозванной или устаревшей митигации,
+// иначе пустую строку.
+func mitigationStatus(co attack.CourseOfAction) string {
+	switch {
+	case co.Revoked:
+		return "revoked"
+	case co.Deprecated:
+		return "deprecated"
+	}
+	return ""
+}
+
+// newTechniqueInfo формирует строку результата; без внешнего ID ATT&CK используется хвост STIX ID.
+func newTechniqueInfo(ap attack.AttackPattern) techniqueInfo {
+	lt := attack.NewTechnique(ap)
+	t := techniqueInfo{
+		ExternalID: lt.ID,
+		Name:       lt.Name,
+		Tactics:    lt.Tactics,
+		Platforms:  lt.Platforms,
+	}
+	if *flagShowDescription {
+		t.Description = lt.Description
+	}
+	// HTML-отчёт всегда ссылается на сайт ATT&CK
+	if *flagShowURL || *flagHTML {
+		t.URL = lt.URL
+	}
+	if *flagShowDataSources {
+		t.DataSources = ap.DataSources
+	}
+	return t
+}
+
+var (
+	reCitation = regexp.MustCompile(`\(Citation:[^)]*\)`)
+	reMDLink   = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	reHTMLTag  = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+)
+
+// plainDescription приводит markdown-описание ATT&CK к одной строке простого текста для
+// таблицы и CSV: убирает ссылки (Citation: ...), разметку ссылок, HTML-теги и ** / `,
+// схлопывает переводы строк и пробелы.
+func plainDescription(s string) string {
+	s = reCitation.ReplaceAllString(s, "")
+	s = reMDLink.ReplaceAllString(s, "$1")
+	s = reHTMLTag.ReplaceAllString(s, "")
+	s = strings.NewReplacer("**", "", "`", "").Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// defaultDescWidth — длина описания по умолчанию в таблице и CSV (-desc-width).
+const defaultDescWidth = 80
+
+// shortDescription — plainDescription, обрезанное до -desc-width рун для таблицы и CSV.
+func shortDescription(s string) string {
+	return truncateRunes(plainDescription(s), *flagDescWidth)
+}
+
+// truncateRunes обрезает s до n рун, заменяя хвост многоточием; n <= 0 — без ограничения.
+// Считаются руны, а не байты, чтобы не разрезать многобайтовый символ.
+func truncateRunes(s string, n int) string {
+	r := []rune(s)
+	if n <= 0 || len(r) <= n {
+		return s
+	}
+	if n == 1 {
+		return "…"
+	}
+	return strings.TrimRightFunc(string(r[:n-1]), unicode.IsSpace) + "…"
+}
+
+// wrapText разбивает текст на строки не длиннее width рун (по границам слов; слово длиннее
+// width остаётся целым).
+func wrapText(s string, width int) []string {
+	var lines []string
+	var cur []string
+	curLen := 0
+	for _, word := range strings.Fields(s) {
+		n := len([]rune(word))
+		if curLen > 0 && curLen+1+n > width {
+			lines = append(lines, strings.Join(cur, " "))
+			cur, curLen = nil, 0
+		}
+		if curLen > 0 {
+			curLen++
+		}
+		cur = append(cur, word)
+		curLen += n
+	}
+	if len(cur) > 0 {
+		lines = append(lines, strings.Join(cur, " "))
+	}
+	return lines
+}
+
+// techniquesForMitigation возвращает техники, на которые указывают связи "mitigates" митигации
+// mitSTIXID и которые прошли фильтр (отбор, дедупликация и порядок — attack.MitigatedTechniques),
+// дополненные полями вывода.
+func techniquesForMitigation(idx *attack.Index, mitSTIXID string, filter techniqueFilter) []techniqueInfo {
+	mitExt := mitigationExtID(idx.Mitigations[mitSTIXID])
+	var parents map[string]string
+	if *flagTree {
+		parents = subtechniqueParents(idx)
+	}
+	var tacticNames map[string]string
+	if *flagTacticDisplayNames {
+		tacticNames = idx.TacticDisplayNames()
+	}
+	// Отозванную технику заменяем преемником по связи revoked-by (кроме режима аудита,
+	// где нужны именно устаревшие связи)
+	mitigated := idx.MitigatedTechniques(mitSTIXID, !filter.deprecatedOnly, filter.keep)
+	var results []techniqueInfo
+	for _, mt := range mitigated {
+		t := newTechniqueInfo(mt.Technique)
+		t.Mitigation = mitExt
+		t.Replaces = mt.Replaces
+		if tacticNames != nil {
+			t.Tactics = displayTactics(t.Tactics, tacticNames)
+		}
+		if *flagShowRelDesc {
+			t.MitigationDetail = mt.Rel.Description
+		}
+		if *flagShowGroups {
+			t.Groups = groupsUsingTechnique(idx, mt.Technique.ID)
+		}
+		if *flagShowSoftware {
+			t.Software = softwareUsingTechnique(idx, mt.Technique.ID)
+		}
+		if *flagShowCampaigns {
+			t.Campaigns = campaignsUsingTechnique(idx, mt.Technique.ID)
+		}
+		t.parent = parents[mt.Technique.ID]
+		t.stixID = mt.Technique.ID
+		results = append(results, t)
+	}
+	return results
+}
+
+// displayTactics заменяет shortname тактик отображаемыми именами из names; shortname без
+// объекта x-mitre-tactic в бандле остаётся как есть. Результат снова отсортирован — уже по
+// отображаемым именам.
+func displayTactics(tactics []string, names map[string]string) []string {
+	out := make([]string, len(tactics))
+	for i, tac := range tactics {
+		if name, ok := names[tac]; ok {
+			out[i] = name
+		} else {
+			out[i] = tac
+		}
+	}
+	slices.Sort(out)
+	return slices.Compact(out)
+}
+
+// topLevelTechniques отбрасывает под-техники — техники с точкой во внешнем ID (T1059.001).
+func topLevelTechniques(techs []techniqueInfo) []techniqueInfo {
+	return slices.DeleteFunc(techs, func(t techniqueInfo) bool {
+		return strings.Contains(t.ExternalID, ".")
+	})
+}
+
+// subtechniqueParents возвращает внешние ID родительских техник по STIX ID под-техник
+// (связи "subtechnique-of").
+func subtechniqueParents(idx *attack.Index) map[string]string {
+	parents := make(map[string]string)
+	for _, r := range idx.Rels {
+		if r.RelationshipType != "subtechnique-of" {
+			continue
+		}
+		if ap, ok := idx.Techniques[r.TargetRef]; ok {
+			parents[r.SourceRef] = newTechniqueInfo(ap).ExternalID
+		}
+	}
+	return parents
+}
+
+// techniqueNode — техника с вложенными под-техниками (-tree).
+type techniqueNode struct {
+	techniqueInfo
+	Subtechniques []techniqueInfo `json:"subtechniques,omitempty"`
+}
+
+// techniqueTree раскладывает техники в дерево: под-техника попадает под родителя, если он
+// есть среди techs, иначе остаётся на верхнем уровне. Порядок techs сохраняется.
+func techniqueTree(techs []techniqueInfo) []techniqueNode {
+	pos := make(map[string]int) // ExternalID+митигация родителя → индекс в nodes
+	key := func(id, mit string) string { return mit + "\x00" + id }
+	present := make(map[string]bool)
+	for _, t := range techs {
+		present[key(t.ExternalID, t.Mitigation)] = true
+	}
+	var nodes []techniqueNode
+	var subs []techniqueInfo
+	for _, t := range techs {
+		if t.parent != "" && present[key(t.parent, t.Mitigation)] {
+			subs = append(subs, t)
+			continue
+		}
+		pos[key(t.ExternalID, t.Mitigation)] = len(nodes)
+		nodes = append(nodes, techniqueNode{techniqueInfo: t})
+	}
+	for _, t := range subs {
+		i := pos[key(t.parent, t.Mitigation)]
+		nodes[i].Subtechniques = append(nodes[i].Subtechniques, t)
+	}
+	return nodes
+}
+
+// treeOrder возвращает техники в порядке обхода дерева с глубиной вложенности (0 или 1).
+func treeOrder(techs []techniqueInfo) ([]techniqueInfo, []int) {
+	var order []techniqueInfo
+	var depth []int
+	for _, n := range techniqueTree(techs) {
+		order = append(order, n.techniqueInfo)
+		depth = append(depth, 0)
+		for _, sub := range n.Subtechniques {
+			order = append(order, sub)
+			depth = append(depth, 1)
+		}
+	}
+	return order, depth
+}
+
+// groupsUsingTechnique возвращает группы, связанные с техникой techSTIXID отношением "uses",
+// в виде "Название (Gxxxx)", отсортированные по внешнему ID группы.
+func groupsUsingTechnique(idx *attack.Index, techSTIXID string) []string {
+	return techniqueUsers(idx, techSTIXID, func(id string) (string, []attack.ExternalReference, bool) {
+		is, ok := idx.Groups[id]
+		return is.Name, is.ExternalRefs, ok
+	})
+}
+
+// softwareUsingTechnique возвращает вредоносное ПО и инструменты, связанные с техникой
+// techSTIXID отношением "uses", в виде "Название (Sxxxx)", отсортированные по внешнему ID.
+func softwareUsingTechnique(idx *attack.Index, techSTIXID string) []string {
+	return techniqueUsers(idx, techSTIXID, func(id string) (string, []attack.ExternalReference, bool) {
+		sw, ok := idx.Software[id]
+		return sw.Name, sw.ExternalRefs, ok
+	})
+}
+
+// campaignsUsingTechnique возвращает кампании, связанные с техникой techSTIXID отношением
+// "uses", в виде "Название (Cxxxx)", отсортированные по внешнему ID кампании.
+func campaignsUsingTechnique(idx *attack.Index, techSTIXID string) []string {
+	return techniqueUsers(idx, techSTIXID, func(id string) (string, []attack.ExternalReference, bool) {
+		ca, ok := idx.Campaigns[id]
+		return ca.Name, ca.ExternalRefs, ok
+	})
+}
+
+// techniqueUsers собирает источники связей "uses" с техникой techSTIXID (по индексу
+// attack.Index.UsersOf), которые находит lookup (название и внешние ссылки по STIX ID), —
+// отсортированные по внешнему ID.
+func techniqueUsers(idx *attack.Index, techSTIXID string,
+	lookup func(stixID string) (string, []attack.ExternalReference, bool)) []string {
+	type user struct{ ext, label string }
+	var users []user
+	for _, id := range idx.UsersOf(techSTIXID) {
+		name, refs, ok := lookup(id)
+		if !ok {
+			continue
+		}
+		u := user{label: name}
+		if ext, ok := attack.ExternalID(refs); ok {
+			u = user{ext: ext, label: fmt.Sprintf("%s (%s)", name, ext)}
+		}
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].ext != users[j].ext {
+			return users[i].ext < users[j].ext
+		}
+		return users[i].label < users[j].label
+	})
+	out := make([]string, len(users))
+	for i, u := range users {
+		out[i] = u.label
+	}
+	return out
+}
+
+// Допустимые значения -sort-by
+const (
+	sortByID     = "id"
+	sortByName   = "name"
+	sortByTactic = "tactic"
+)
+
+func validSortBy(by string) bool {
+	switch by {
+	case sortByID, sortByName, sortByTactic:
+		return true
+	}
+	return false
+}
+
+// sortTechniques упорядочивает техники по ключу by; при равенстве ключей порядок задаёт
+// ExternalID, поэтому результат детерминирован. Техники без тактик при sortByTactic идут последними.
+func sortTechniques(techs []techniqueInfo, by string) {
+	firstTactic := func(t techniqueInfo) string {
+		if len(t.Tactics) == 0 {
+			return "\uffff"
+		}
+		return t.Tactics[0]
+	}
+	sort.SliceStable(techs, func(i, j int) bool {
+		a, b := techs[i], techs[j]
+		switch by {
+		case sortByName:
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+		case sortByTactic:
+			if ta, tb := firstTactic(a), firstTactic(b); ta != tb {
+				return ta < tb
+			}
+		}
+		return a.ExternalID < b.ExternalID
+	})
+}
+
+// Допустимые значения -group-output-by
+const (
+	groupByNone       = "none"
+	groupByMitigation = "mitigation"
+	groupByTactic     = "tactic"
+	groupByPlatform   = "platform"
+)
+
+// noGroupKey — ключ группы для техник без тактик/платформ.
+const noGroupKey = "(none)"
+
+// techniqueGroup — техники, объединённые общим значением измерения группировки.
+type techniqueGroup struct {
+	Group      string          `json:"group"`
+	Techniques []techniqueInfo `json:"techniques"`
+}
+
+func validGroupBy(by string) bool {
+	switch by {
+	case groupByNone, groupByMitigation, groupByTactic, groupByPlatform:
+		return true
+	}
+	return false
+}
+
+// groupTechniques раскладывает техники по группам измерения by. Техника с несколькими
+// тактиками (платформами) попадает в каждую из своих групп; группы отсортированы по ключу,
+// порядок техник внутри группы сохраняется.
+func groupTechniques(data []techniqueInfo, by string) []techniqueGroup {
+	var keys []string
+	groups := make(map[string][]techniqueInfo)
+	add := func(key string, t techniqueInfo) {
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], t)
+	}
+	for _, t := range data {
+		var dims []string
+		switch by {
+		case groupByTactic:
+			dims = t.Tactics
+		case groupByPlatform:
+			dims = t.Platforms
+		default:
+			dims = []string{t.Mitigation}
+		}
+		if len(dims) == 0 {
+			dims = []string{noGroupKey}
+		}
+		for _, d := range dims {
+			add(d, t)
+		}
+	}
+	sort.Strings(keys)
+	out := make([]techniqueGroup, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, techniqueGroup{Group: k, Techniques: groups[k]})
+	}
+	return out
+}
+
+func main() {
+	started := time.Now()
+	handleInterrupt()
+
+	/* ---------------------------------------------------------
+	   Парсинг флагов
+	   --------------------------------------------------------- */
+	args, err := expandArgFiles(os.Args[1:])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading argument file: %v\n", err)
+		os.Exit(1)
+	}
+	os.Args = append(os.Args[:1], args...)
+	flag.Parse()
+
+	// MITREMIT_* применяются к флагам, не заданным в командной строке (до .mitremitrc)
+	envApplied, err := applyEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading environment: %v\n", err)
+		os.Exit(1)
+	}
+	if *flagDbg {
+		for _, name := range envApplied {
+			fmt.Fprintf(os.Stderr, ">>> flag from environment: %s\n", name)
+		}
+	}
+
+	// Значения из .mitremitrc применяются только к флагам, не заданным в командной строке
+	configPath, err := applyConfigFile()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error reading config file: %v\n", err)
+		os.Exit(1)
+	}
+	if configPath != "" && *flagDbg {
+		fmt.Fprintf(os.Stderr, ">>> config file: %s\n", configPath)
+	}
+
+	// Если запрошен help, показываем его и выходим с кодом 0
+	if *flagHelp {
+		printUsage()
+		os.Exit(0)
+	}
+
+	if *flagVersion {
+		printVersion(os.Stdout)
+		os.Exit(0)
+	}
+
+	if *flagJSONSchema {
+		printJSONSchema(os.Stdout)
+		os.Exit(0)
+	}
+
+	if !validGroupBy(*flagGroupBy) {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid -group-output-by %q (want none, mitigation, tactic or platform)\n", *flagGroupBy)
+		os.Exit(1)
+	}
+
+	if !validSortBy(*flagSortBy) {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid -sort-by %q (want id, name or tactic)\n", *flagSortBy)
+		os.Exit(1)
+	}
+
+	if !validColor(*flagColor) {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid -color %q (want auto, always or never)\n", *flagColor)
+		os.Exit(1)
+	}
+
+	if *flagNoColor {
+		if *flagColor == colorAlways {
+			fmt.Fprintln(os.Stderr, "ERROR: -no-color conflicts with -color always")
+			os.Exit(1)
+		}
+		*flagColor = colorNever
+	}
+
+	if !validDomain(*flagDomain) {
+		fmt.Fprintf(os.Stderr, "ERROR: invalid -domain %q (want enterprise, mobile or ics)\n", *flagDomain)
+		os.Exit(1)
+	}
+
+	if *flagProxy != "" {
+		if _, err := parseProxyURL(*flagProxy); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if *flagBundleURL != "" {
+		if err := validateBundleURL(*flagBundleURL); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if _, err := tlsConfigFromFlags(); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *flagTimeout <= 0 {
+		fmt.Fprintln(os.Stderr, "ERROR: -timeout must be positive")
+		os.Exit(1)
+	}
+
+	if *flagRetries < 0 || *flagRetryDelay < 0 {
+		fmt.Fprintln(os.Stderr, "ERROR: -retries and -retry-delay must not be negative")
+		os.Exit(1)
+	}
+
+	if *flagDescWidth < 0 {
+		fmt.Fprintln(os.Stderr, "ERROR: -desc-width must not be negative")
+		os.Exit(1)
+	}
+
+	if *flagStreamBuffer < 0 {
+		fmt.Fprintln(os.Stderr, "ERROR: -stream-buffer must not be negative")
+		os.Exit(1)
+	}
+
+	if *flagSuggestDistance < 0 {
+		fmt.Fprintln(os.Stderr, "ERROR: -suggest-distance must not be negative")
+		os.Exit(1)
+	}
+
+	if _, err := parseCSVDelimiter(*flagCSVDelimiter); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	filter, err := filterFromFlags()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *flagServe != "" {
+		os.Exit(runServer(*flagServe, filter))
+	}
+
+	if *flagDryRun {
+		runDryRun(os.Stdout)
+		os.Exit(0)
+	}
+
+	if *flagClearCache {
+		os.Exit(runClearCache(os.Stdout))
+	}
+
+	if *flagCacheInfo {
+		os.Exit(runCacheInfo(os.Stdout))
+	}
+
+	if *flagCheckFreshness {
+		os.Exit(runCheckFreshness(os.Stdout))
+	}
+
+	// Если не указаны обязательные флаги, показываем help и выходим с ошибкой
+	if *flagListMitigations || *flagListPlatforms || *flagCompare != "" {
+		// обязательных флагов нет
+	} else if *flagCaseStudy {
+		if *flagGroup == "" {
+			printUsage()
+			fmt.Fprintln(os.Stderr, "\nERROR: -case-study requires -group")
+			os.Exit(1)
+		}
+	} else if *flagMitigation == "" && *flagMitigationName == "" && *flagMitigationSearch == "" &&
+		*flagMitigationRegex == "" && *flagTechnique == "" && *flagDetections == "" {
+		printUsage()
+		fmt.Fprintln(os.Stderr, "\nERROR: must specify -mitigation, -mitigation-name, -mitigation-search, -mitigation-regex, -technique or -detections")
+		os.Exit(1)
+	}
+
+	// Явно неверные ID отклоняем до загрузки и разбора бандла
+	if err := validateIDFlags(); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Регулярное выражение компилируется до загрузки бандла: синтаксическая ошибка видна сразу
+	var mitigationRe *regexp.Regexp
+	if *flagMitigationRegex != "" {
+		if mitigationRe, err = compileMitigationRegex(*flagMitigationRegex); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// Шаблон вывода тоже разбирается до загрузки бандла
+	outTemplate, err := loadOutputTemplate(*flagTemplate, *flagTemplateFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+
+	/* ---------------------------------------------------------
+	   Load the ATT&CK bundle
+	   --------------------------------------------------------- */
+	src, err := fetchBundle()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error fetching ATT&CK bundle: %v\n", err)
+		os.Exit(1)
+	}
+	// -stix копирует объекты из исходного бандла, поэтому только в этом режиме бандл
+	// целиком читается в память; остальные режимы разбирают его потоком
+	var raw []byte
+	var bundle io.Reader = src
+	if *flagSTIX {
+		if raw, err = io.ReadAll(src); err != nil {
+			fmt.Fprintf(os.Stderr, "error fetching ATT&CK bundle: %v\n", err)
+			os.Exit(1)
+		}
+		bundle = bytes.NewReader(raw)
+	}
+	parseStart := time.Now()
+	idx, bundleHash, err := loadIndex(bundle)
+	recordPhase("parse", parseStart)
+	if cerr := src.Close(); err == nil && cerr != nil {
+		fmt.Fprintf(os.Stderr, "error fetching ATT&CK bundle: %v\n", cerr)
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error parsing bundle JSON: %v\n", err)
+		os.Exit(1)
+	}
+	checkSpecVersion(idx.SpecVersion)
+
+	// Код выхода выставляется после записи результата: отложенный вызов зарегистрирован
+	// раньше записи -output и поэтому выполняется после неё.
+	exitCode := exitOK
+	defer func() {
+		if exitCode != exitOK {
+			os.Exit(exitCode)
+		}
+	}()
+	// Замеры выводятся после записи -output (отложенные вызовы выполняются в обратном порядке)
+	var outputStart time.Time
+	if *flagMetrics {
+		defer func() {
+			if !outputStart.IsZero() {
+				recordPhase("output", outputStart)
+			}
+			printMetrics(os.Stderr, started)
+		}()
+	}
+
+	// С -output результат собирается в буфер и в конце атомарно пишется в файл (0600);
+	// отладочные сообщения и ошибки по-прежнему идут в stderr.
+	var out io.Writer = os.Stdout
+	if *flagOutput != "" {
+		buf := new(bytes.Buffer)
+		out = buf
+		defer func() {
+			if err := writeFileAtomic(*flagOutput, buf.Bytes(), 0o600); err != nil {
+				fmt.Fprintf(os.Stderr, "error writing output: %v\n", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	if *flagListMitigations {
+		exitCode = emptyExitCode(runListMitigations(out, idx))
+		return
+	}
+	if *flagListPlatforms {
+		exitCode = emptyExitCode(runListPlatforms(out, idx))
+		return
+	}
+	if *flagCaseStudy {
+		exitCode = emptyExitCode(runCaseStudy(out, idx, *flagGroup, filter))
+		return
+	}
+	if *flagCompare != "" {
+		exitCode = emptyExitCode(runCompare(out, idx, *flagCompare, filter))
+		return
+	}
+	if *flagTechnique != "" {
+		exitCode = emptyExitCode(runTechniqueLookup(out, idx, *flagTechnique))
+		return
+	}
+	if *flagDetections != "" {
+		exitCode = emptyExitCode(runDetections(out, idx, *flagDetections))
+		return
+	}
+
+	/* ---------------------------------------------------------
+	   Find the mitigation requested by the user
+	   --------------------------------------------------------- */
+	mitMap := idx.Mitigations
+	var chosen []resolvedMitigation
+	if *flagMitigation != "" {
+		// lookup by external ID (Mxxxx); несколько ID — пакетный режим
+		ids := splitList(*flagMitigation)
+		for _, want := range ids {
+			chosenMitSTIXID := mitigationByExtID(idx, want) // STIX ID we will match on source_ref
+			if chosenMitSTIXID == "" {
+				msg := fmt.Sprintf("mitigation %s not found in ATT&CK data", want)
+				if folded := idx.MitigationByExtID(want); folded != "" {
+					// -strict-id: ID отличается только регистром
+					msg += fmt.Sprintf(" (-strict-id is case-sensitive). Did you mean: %s?", mitigationExtID(mitMap[folded]))
+				} else if suggestions := suggestMitigationIDs(want, mitMap, idSuggestDistance()); len(suggestions) > 0 {
+					msg += fmt.Sprintf(". Did you mean: %s?", strings.Join(suggestions, ", "))
+				}
+				fmt.Fprintln(os.Stderr, msg)
+				continue
+			}
+			if slices.ContainsFunc(chosen, func(m resolvedMitigation) bool { return m.stixID == chosenMitSTIXID }) {
+				continue // повтор в списке
+			}
+			chosen = append(chosen, newResolvedMitigation(mitMap[chosenMitSTIXID]))
+		}
+		if len(chosen) == 0 {
+			os.Exit(1)
+		}
+	} else if mitigationRe != nil {
+		// все митигации, название которых подходит под выражение, — как пакетный режим
+		matches := regexMitigations(mitMap, mitigationRe)
+		if len(matches) == 0 {
+			fmt.Fprintf(os.Stderr, "no mitigation name matches %q\n", *flagMitigationRegex)
+			os.Exit(1)
+		}
+		if len(matches) == len(mitMap) && len(matches) > 1 {
+			warnf("-mitigation-regex %q matches all %d mitigations", *flagMitigationRegex, len(matches))
+		}
+		for _, co := range matches {
+			chosen = append(chosen, newResolvedMitigation(co))
+		}
+	} else if *flagMitigationSearch != "" {
+		// поиск по подстроке названия: ровно одно совпадение — обычный запрос
+		matches := searchMitigations(mitMap, *flagMitigationSearch)
+		switch len(matches) {
+		case 0:
+			fmt.Fprintf(os.Stderr, "no mitigation name contains %q\n", *flagMitigationSearch)
+			os.Exit(1)
+		case 1:
+			chosen = append(chosen, newResolvedMitigation(matches[0]))
+		default:
+			fmt.Fprintf(os.Stderr, "%d mitigations match %q; pick one with -mitigation:\n", len(matches), *flagMitigationSearch)
+			for _, co := range matches {
+				fmt.Fprintf(os.Stderr, "  %s\t%s\n", mitigationExtID(co), co.Name)
+			}
+			os.Exit(1)
+		}
+	} else {
+		// lookup by name (case‑insensitive)
+		target := strings.TrimSpace(*flagMitigationName)
+		chosenMitSTIXID, ambiguous := mitigationByName(idx, target)
+		if len(ambiguous) > 0 {
+			fmt.Fprintf(os.Stderr, "mitigation name %q is ambiguous (%d mitigations); pick one with -mitigation:\n", target, len(ambiguous))
+			for _, co := range ambiguous {
+				fmt.Fprintf(os.Stderr, "  %s\t%s\n", mitigationExtID(co), co.Name)
+			}
+			os.Exit(1)
+		}
+		if chosenMitSTIXID == "" {
+			msg := fmt.Sprintf("mitigation name %q not found (check spelling)", target)
+			maxDist := nameSuggestDistance(target)
+			if *flagDbg {
+				printNameDistances(os.Stderr, target, mitMap, maxDist)
+			}
+			if suggestions := suggestMitigationNames(target, mitMap, maxDist); len(suggestions) > 0 {
+				msg += fmt.Sprintf(". Did you mean: %s?", quotedOrList(suggestions))
+			}
+			fmt.Fprintln(os.Stderr, msg)
+			os.Exit(1)
+		}
+		chosen = append(chosen, newResolvedMitigation(mitMap[chosenMitSTIXID]))
+	}
+	for _, m := range chosen {
+		switch {
+		case m.co.Revoked:
+			warnf("mitigation %s is revoked in ATT&CK; its mappings may be stale", m.ext)
+		case m.co.Deprecated:
+			warnf("mitigation %s is deprecated in ATT&CK; its mappings may be stale", m.ext)
+		}
+	}
+
+	/* ---------------------------------------------------------
+	   Collect all techniques that each mitigation mitigates (без дубликатов, детерминированный порядок)
+	   --------------------------------------------------------- */
+	extractStart := time.Now()
+	var results []techniqueInfo
+	for _, m := range chosen {
+		techs := techniquesForMitigation(idx, m.stixID, filter)
+		sortTechniques(techs, *flagSortBy)
+		results = append(results, techs...)
+	}
+	if *flagNoSubtechniques {
+		results = topLevelTechniques(results)
+	}
+	recordPhase("extraction", extractStart)
+	outputStart = time.Now()
+
+	/* ---------------------------------------------------------
+	   Emit the requested output format
+	   --------------------------------------------------------- */
+	if *flagExec != "" {
+		code := runExec(*flagExec, results)
+		if code == exitOK {
+			code = emptyExitCode(len(results))
+		}
+		os.Exit(code)
+	}
+	if *flagDiffAgainst != "" {
+		if err := runDiffAgainst(out, *flagDiffAgainst, chosen, results); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	exitCode = emptyExitCode(len(results))
+	rep := mitigationReport{
+		mits:          chosen,
+		techniques:    results,
+		groupBy:       *flagGroupBy,
+		attackVersion: idx.AttackVersion,
+	}
+	if *flagGroupBy != groupByNone {
+		rep.groups = groupTechniques(results, *flagGroupBy)
+	}
+	if *flagCount {
+		emitCount(out, outputFormat(), rep)
+		return
+	}
+	if *flagTacticHistogram {
+		emitTacticHistogram(out, outputFormat(), results)
+		return
+	}
+	if *flagOutputDir != "" {
+		if err := writeReportDir(*flagOutputDir, bundleHash, rep); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing report: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if *flagSTIX {
+		if err := emitSTIXSubset(out, raw, idx, rep); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing STIX bundle: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if outTemplate != nil {
+		if err := emitTemplate(out, outTemplate, rep); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	format := outputFormat()
+	if format == formatTable {
+		if len(results) == 0 && filter.tactic != "" {
+			ids := make([]string, len(chosen))
+			for i, m := range chosen {
+				ids[i] = m.ext
+			}
+			fmt.Fprintf(out, "no techniques for mitigation %s under tactic %s\n", strings.Join(ids, ", "), filter.tactic)
+			return
+		}
+		printTable(out, rep)
+		return
+	}
+	emitReport(out, format, rep)
+}
+
+// Коды выхода: 0 — есть результаты, 1 — ошибка, 2 — результатов нет (только с -fail-empty),
+// 3 — доступно обновление бандла (только с -check-freshness).
+const (
+	exitOK    = 0
+	exitEmpty = 2
+	// exitUpdateAvailable — -check-freshness: на сервере новее кэша (или кэша нет)
+	exitUpdateAvailable = 3
+)
+
+// emptyExitCode возвращает exitEmpty, если задан -fail-empty и результатов n == 0.
+func emptyExitCode(n int) int {
+	if *flagFailEmpty && n == 0 {
+		return exitEmpty
+	}
+	return exitOK
+}
+
+// searchMitigations возвращает митигации, название которых содержит term без учёта регистра,
+// отсортированные по внешнему ID.
+func searchMitigations(mitMap map[string]attack.CourseOfAction, term string) []attack.CourseOfAction {
+	term = strings.ToLower(strings.TrimSpace(term))
+	var out []attack.CourseOfAction
+	for _, co := range mitMap {
+		if strings.Contains(strings.ToLower(co.Name), term) {
+			out = append(out, co)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return mitigationExtID(out[i]) < mitigationExtID(out[j])
+	})
+	return out
+}
+
+// maxMitigationRegexLen — предел длины -mitigation-regex. RE2 в regexp работает за линейное
+// время, но огромное выражение компилируется долго и почти наверняка ошибочно.
+const maxMitigationRegexLen = 1024
+
+// compileMitigationRegex проверяет и компилирует -mitigation-regex.
+func compileMitigationRegex(pattern string) (*regexp.Regexp, error) {
+	if len(pattern) > maxMitigationRegexLen {
+		return nil, fmt.Errorf("-mitigation-regex is too long (%d bytes, max %d)", len(pattern), maxMitigationRegexLen)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -mitigation-regex: %w", err)
+	}
+	return re, nil
+}
+
+// regexMitigations возвращает митигации, название которых подходит под re, отсортированные по ID.
+func regexMitigations(mitMap map[string]attack.CourseOfAction, re *regexp.Regexp) []attack.CourseOfAction {
+	var out []attack.CourseOfAction
+	for _, co := range mitMap {
+		if re.MatchString(co.Name) {
+			out = append(out, co)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return mitigationExtID(out[i]) < mitigationExtID(out[j])
+	})
+	return out
+}
+
+// splitList разбирает список значений через запятую, отбрасывая пустые элементы.
+func splitList(s string) []string {
+	var out []string
+	for _, part := range strings.Split(s, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+/*
+-------------------------------------------------------------
+Форматы вывода
+-------------------------------------------------------------
+*/
+const (
+	formatTable     = "table"
+	formatJSON      = "json"
+	formatJSONL     = "jsonl"
+	formatCSV       = "csv"
+	formatTSV       = "tsv"
+	formatNGQL      = "ngql"
+	formatDOT       = "dot"
+	formatCypher    = "cypher"
+	formatMarkdown  = "markdown"
+	formatHTML      = "html"
+	formatMermaid   = "mermaid"
+	formatJSONGraph = "json-graph"
+)
+
+// reportFormats — форматы, которые -output-dir пишет в отдельные файлы, и их расширения.
+var reportFormats = []struct{ format, ext string }{
+	{formatTable, "txt"},
+	{formatJSON, "json"},
+	{formatJSONL, "jsonl"},
+	{formatCSV, "csv"},
+	{formatTSV, "tsv"},
+	{formatNGQL, "ngql"},
+	{formatDOT, "dot"},
+	{formatCypher, "cypher"},
+	{formatMermaid, "mmd"},
+	{formatJSONGraph, "graph.json"},
+	{formatMarkdown, "md"},
+	{formatHTML, "html"},
+}
+
+// outputFormat возвращает формат вывода, выбранный флагами (графовые форматы имеют приоритет,
+// затем JSON, JSON Lines, CSV, TSV, Markdown, HTML).
+func outputFormat() string {
+	switch {
+	case *flagNGQL:
+		return formatNGQL
+	case *flagDOT:
+		return formatDOT
+	case *flagCypher:
+		return formatCypher
+	case *flagMermaid:
+		return formatMermaid
+	case *flagJSONGraph:
+		return formatJSONGraph
+	case *flagJSON:
+		return formatJSON
+	case *flagJSONL:
+		return formatJSONL
+	case *flagCSV:
+		return formatCSV
+	case *flagTSV:
+		return formatTSV
+	case *flagMarkdown:
+		return formatMarkdown
+	case *flagHTML:
+		return formatHTML
+	}
+	return formatTable
+}
+
+// resolvedMitigation — митигация, найденная по запросу пользователя.
+type resolvedMitigation struct {
+	stixID string
+	ext    string
+	co     attack.CourseOfAction
+}
+
+func newResolvedMitigation(co attack.CourseOfAction) resolvedMitigation {
+	return resolvedMitigation{stixID: co.ID, ext: mitigationExtID(co), co: co}
+}
+
+// mitigationReport — результат запроса по одной или нескольким митигациям, общий для всех
+// эмиттеров. Каждая техника помечена внешним ID митигации (techniqueInfo.Mitigation).
+type mitigationReport struct {
+	mits       []resolvedMitigation
+	techniques []techniqueInfo
+	groups     []techniqueGroup // nil, если группировка не задана
+	groupBy    string
+	// attackVersion — версия ATT&CK бандла для -with-meta ("" — в бандле нет x-mitre-collection)
+	attackVersion string
+}
+
+// techniquesOf возвращает техники, найденные для митигации с внешним ID mitExt.
+func (r mitigationReport) techniquesOf(mitExt string) []techniqueInfo {
+	var out []techniqueInfo
+	for _, t := range r.techniques {
+		if t.Mitigation == mitExt {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// mitigationName возвращает название митигации по её внешнему ID.
+func (r mitigationReport) mitigationName(mitExt string) string {
+	for _, m := range r.mits {
+		if m.ext == mitExt {
+			return m.co.Name
+		}
+	}
+	return ""
+}
+
+// emitReport пишет результат в формате format; таблица выводится в полной раскладке.
+func emitReport(w io.Writer, format string, rep mitigationReport) {
+	switch format {
+	case formatNGQL:
+		// nGQL — граф, группировка к нему не применяется
+		emitNGQL(w, rep)
+	case formatDOT:
+		emitDOT(w, rep)
+	case formatCypher:
+		emitCypher(w, rep)
+	case formatMermaid:
+		emitMermaid(w, rep)
+	case formatJSONGraph:
+		emitJSONGraph(w, rep)
+	case formatJSON:
+		emitJSON(w, rep)
+	case formatJSONL:
+		emitJSONL(w, rep)
+	case formatCSV:
+		emitCSV(w, rep)
+	case formatTSV:
+		emitTSV(w, rep)
+	case formatMarkdown:
+		emitMarkdown(w, rep)
+	case formatHTML:
+		emitHTML(w, rep)
+	default:
+		fmt.Fprint(w, renderTable(rep, layoutFull))
+	}
+}
+
+func emitJSON(w io.Writer, rep mitigationReport) {
+	var payload any
+	switch {
+	case rep.groups != nil:
+		payload = rep.groups
+	case *flagTree:
+		payload = techniqueTree(rep.techniques)
+	default:
+		payload = rep.techniques
+	}
+	if *flagWithMeta {
+		meta := newReportMeta(rep)
+		meta.Techniques = payload
+		payload = meta
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(payload)
+}
+
+// reportMeta — обёртка -with-meta: к какой митигации относятся техники, когда и по какой
+// версии ATT&CK построен отчёт. Для нескольких митигаций вместо mitigation — mitigations.
+type reportMeta struct {
+	Mitigation    *jsonGraphNode  `json:"mitigation,omitempty"`
+	Mitigations   []jsonGraphNode `json:"mitigations,omitempty"`
+	Techniques    any             `json:"techniques"`
+	GeneratedAt   string          `json:"generated_at"`
+	AttackVersion string          `json:"attack_version,omitempty"`
+}
+
+// newReportMeta заполняет метаданные отчёта без поля techniques.
+func newReportMeta(rep mitigationReport) reportMeta {
+	meta := reportMeta{
+		GeneratedAt:   time.Now().UTC().Format(time.RFC3339),
+		AttackVersion: rep.attackVersion,
+	}
+	for _, m := range rep.mits {
+		meta.Mitigations = append(meta.Mitigations, jsonGraphNode{ID: m.ext, Name: m.co.Name})
+	}
+	if len(meta.Mitigations) == 1 {
+		meta.Mitigation, meta.Mitigations = &meta.Mitigations[0], nil
+	}
+	return meta
+}
+
+// emitJSONL пишет по одному объекту techniqueInfo на строку, без обрамляющего массива.
+// Группировка не применяется: каждая запись и так содержит ID митигации (поле mitigation).
+func emitJSONL(w io.Writer, rep mitigationReport) {
+	enc := json.NewEncoder(w)
+	if *flagTree {
+		for _, n := range techniqueTree(rep.techniques) {
+			_ = enc.Encode(n)
+		}
+		return
+	}
+	for _, t := range rep.techniques {
+		_ = enc.Encode(t)
+	}
+}
+
+// tabularRecords собирает заголовок и строки CSV/TSV: колонки одинаковы для обоих форматов.
+func tabularRecords(rep mitigationReport) [][]string {
+	var records [][]string
+	header := []string{"Mitigation ID", "Mitigation Name", "Technique ID", "Technique Name", "Tactics", "Platforms"}
+	if *flagShowDescription {
+		header = append(header, "Description")
+	}
+	if *flagShowURL {
+		header = append(header, "URL")
+	}
+	if *flagShowGroups {
+		header = append(header, "Groups")
+	}
+	if *flagShowSoftware {
+		header = append(header, "Software")
+	}
+	if *flagShowCampaigns {
+		header = append(header, "Campaigns")
+	}
+	// -with-meta: метаданные отчёта — колонками в каждой строке, чтобы файл остался чистым CSV
+	var meta reportMeta
+	if *flagWithMeta {
+		meta = newReportMeta(rep)
+		header = append(header, "Generated At", "ATT&CK Version")
+	}
+	writeRows := func(prefix []string, techs []techniqueInfo) {
+		for _, t := range techs {
+			tacticsStr := strings.Join(t.Tactics, "; ")
+			for _, platform := range csvPlatformCells(t.Platforms, *flagFlattenPlatforms) {
+				row := append(append([]string{}, prefix...),
+					t.Mitigation, rep.mitigationName(t.Mitigation), t.ExternalID, t.Name, tacticsStr, platform)
+				if *flagShowDescription {
+					row = append(row, shortDescription(t.Description))
+				}
+				if *flagShowURL {
+					row = append(row, t.URL)
+				}
+				if *flagShowGroups {
+					row = append(row, strings.Join(t.Groups, "; "))
+				}
+				if *flagShowSoftware {
+					row = append(row, strings.Join(t.Software, "; "))
+				}
+				if *flagShowCampaigns {
+					row = append(row, strings.Join(t.Campaigns, "; "))
+				}
+				if *flagWithMeta {
+					row = append(row, meta.GeneratedAt, meta.AttackVersion)
+				}
+				records = append(records, row)
+			}
+		}
+	}
+	if rep.groups == nil {
+		records = append(records, header)
+		writeRows(nil, rep.techniques)
+	} else {
+		// Первая колонка — значение измерения группировки
+		records = append(records, append([]string{"Group"}, header...))
+		for _, g := range rep.groups {
+			writeRows([]string{g.Group}, g.Techniques)
+		}
+	}
+	return records
+}
+
+func emitCSV(out io.Writer, rep mitigationReport) {
+	w := newCSVWriter(out)
+	_ = w.WriteAll(withoutHeader(tabularRecords(rep)))
+}
+
+// parseCSVDelimiter проверяет значение -csv-delimiter: ровно один символ, допустимый как
+// разделитель encoding/csv (не кавычка, не перевод строки).
+func parseCSVDelimiter(s string) (rune, error) {
+	r, size := utf8.DecodeRuneInString(s)
+	if s == "" || size != len(s) || r == utf8.RuneError {
+		return 0, fmt.Errorf("invalid -csv-delimiter %q: want a single character", s)
+	}
+	if r == '"' || r == '\r' || r == '\n' {
+		return 0, fmt.Errorf("invalid -csv-delimiter %q: quotes and newlines are not allowed", s)
+	}
+	return r, nil
+}
+
+// newCSVWriter возвращает csv.Writer с разделителем -csv-delimiter (проверен в main).
+func newCSVWriter(out io.Writer) *csv.Writer {
+	w := csv.NewWriter(out)
+	w.Comma, _ = parseCSVDelimiter(*flagCSVDelimiter)
+	return w
+}
+
+// writeCSVHeader пишет строку заголовка, если не задан -no-header.
+func writeCSVHeader(w *csv.Writer, header []string) {
+	if !*flagNoHeader {
+		_ = w.Write(header)
+	}
+}
+
+// withoutHeader отбрасывает первую запись (заголовок) с -no-header.
+func withoutHeader(records [][]string) [][]string {
+	if *flagNoHeader && len(records) > 0 {
+		return records[1:]
+	}
+	return records
+}
+
+// emitTSV пишет те же колонки, что и emitCSV, через табуляцию и без кавычек.
+func emitTSV(w io.Writer, rep mitigationReport) {
+	writeTSV(w, withoutHeader(tabularRecords(rep)))
+}
+
+// writeTSV пишет записи через табуляцию, заменяя в значениях символы, которые сломали бы строку.
+func writeTSV(w io.Writer, records [][]string) {
+	for _, rec := range records {
+		cells := make([]string, len(rec))
+		for i, c := range rec {
+			cells[i] = tsvCell(c)
+		}
+		fmt.Fprintln(w, strings.Join(cells, "\t"))
+	}
+}
+
+// tsvCell заменяет табуляции и переводы строк пробелами: в TSV нет экранирования, и такие
+// символы в названии сдвинули бы колонки или разорвали строку.
+func tsvCell(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+// emitMarkdown пишет по Markdown-таблице на митигацию под заголовком "## Mxxxx — Название";
+// при группировке по тактике или платформе каждая группа получает подзаголовок "###".
+func emitMarkdown(w io.Writer, rep mitigationReport) {
+	header := []string{"Technique ID", "Technique Name", "Tactics"}
+	if *flagShowURL {
+		header = append(header, "URL")
+	}
+	if *flagShowGroups {
+		header = append(header, "Groups")
+	}
+	if *flagShowSoftware {
+		header = append(header, "Software")
+	}
+	if *flagShowCampaigns {
+		header = append(header, "Campaigns")
+	}
+	if *flagShowDescription {
+		header = append(header, "Description")
+	}
+	writeTable := func(techs []techniqueInfo) {
+		writeMarkdownRow(w, header)
+		sep := make([]string, len(header))
+		for i := range sep {
+			sep[i] = "---"
+		}
+		writeMarkdownRow(w, sep)
+		for _, t := range techs {
+			row := []string{t.ExternalID, tableTechniqueName(t), strings.Join(t.Tactics, ", ")}
+			if *flagShowURL {
+				row = append(row, t.URL)
+			}
+			if *flagShowGroups {
+				row = append(row, strings.Join(t.Groups, ", "))
+			}
+			if *flagShowSoftware {
+				row = append(row, strings.Join(t.Software, ", "))
+			}
+			if *flagShowCampaigns {
+				row = append(row, strings.Join(t.Campaigns, ", "))
+			}
+			if *flagShowDescription {
+				row = append(row, shortDescription(t.Description))
+			}
+			writeMarkdownRow(w, row)
+		}
+	}
+	sectioned := rep.groupBy == groupByTactic || rep.groupBy == groupByPlatform
+	for i, m := range rep.mits {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "## %s — %s\n\n", m.ext, markdownCell(m.co.Name))
+		techs := rep.techniquesOf(m.ext)
+		if !sectioned {
+			writeTable(techs)
+			continue
+		}
+		for j, g := range groupTechniques(techs, rep.groupBy) {
+			if j > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "### %s\n\n", markdownCell(g.Group))
+			writeTable(g.Techniques)
+		}
+	}
+}
+
+// writeMarkdownRow пишет строку Markdown-таблицы "| a | b |".
+func writeMarkdownRow(w io.Writer, cells []string) {
+	escaped := make([]string, len(cells))
+	for i, c := range cells {
+		escaped[i] = markdownCell(c)
+	}
+	fmt.Fprintf(w, "| %s |\n", strings.Join(escaped, " | "))
+}
+
+// markdownCell экранирует "|" и убирает переводы строк, чтобы значение не ломало таблицу.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// htmlReportTemplate — самодостаточная HTML-страница отчёта (стили встроены, внешних ресурсов нет).
+// html/template экранирует все строки из бандла, в том числе в href.
+var htmlReportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h2 { border-bottom: 2px solid #c00; padding-bottom: .2em; }
+table { border-collapse: collapse; margin-bottom: 2em; width: 100%; }
+th, td { border: 1px solid #ccc; padding: .4em .6em; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+tr:nth-child(even) td { background: #fafafa; }
+a { color: #0645ad; text-decoration: none; }
+a:hover { text-decoration: underline; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{range .Sections}}
+<h2>{{if .URL}}<a href="{{.URL}}">{{.ID}}</a>{{else}}{{.ID}}{{end}} — {{.Name}}</h2>
+{{range .Groups}}{{if .Name}}<h3>{{.Name}}</h3>
+{{end}}<table>
+<tr>{{range $.Header}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{if .URL}}<a href="{{.URL}}">{{.Text}}</a>{{else}}{{.Text}}{{end}}</td>{{end}}</tr>
+{{end}}</table>
+{{end}}{{end}}
+</body>
+</html>
+`))
+
+// htmlCell — ячейка HTML-таблицы; при непустом URL текст становится ссылкой.
+type htmlCell struct {
+	Text string
+	URL  string
+}
+
+// htmlGroup — таблица внутри секции митигации; Name пуст, если группировки нет.
+type htmlGroup struct {
+	Name string
+	Rows [][]htmlCell
+}
+
+// htmlSection — секция одной митигации.
+type htmlSection struct {
+	ID, Name, URL string
+	Groups        []htmlGroup
+}
+
+// emitHTML пишет HTML-страницу с таблицей на митигацию; ID техник и митигаций — ссылки на сайт
+// ATT&CK из external_references. Группировка по тактике или платформе даёт подзаголовки.
+func emitHTML(w io.Writer, rep mitigationReport) {
+	header := []string{"Technique ID", "Technique Name", "Tactics", "Platforms"}
+	if *flagShowGroups {
+		header = append(header, "Groups")
+	}
+	if *flagShowSoftware {
+		header = append(header, "Software")
+	}
+	if *flagShowCampaigns {
+		header = append(header, "Campaigns")
+	}
+	if *flagShowDescription {
+		header = append(header, "Description")
+	}
+	rows := func(techs []techniqueInfo) [][]htmlCell {
+		var out [][]htmlCell
+		for _, t := range techs {
+			row := []htmlCell{
+				{Text: t.ExternalID, URL: t.URL},
+				{Text: tableTechniqueName(t)},
+				{Text: strings.Join(t.Tactics, ", ")},
+				{Text: strings.Join(t.Platforms, ", ")},
+			}
+			if *flagShowGroups {
+				row = append(row, htmlCell{Text: strings.Join(t.Groups, ", ")})
+			}
+			if *flagShowSoftware {
+				row = append(row, htmlCell{Text: strings.Join(t.Software, ", ")})
+			}
+			if *flagShowCampaigns {
+				row = append(row, htmlCell{Text: strings.Join(t.Campaigns, ", ")})
+			}
+			if *flagShowDescription {
+				row = append(row, htmlCell{Text: shortDescription(t.Description)})
+			}
+			out = append(out, row)
+		}
+		return out
+	}
+	sectioned := rep.groupBy == groupByTactic || rep.groupBy == groupByPlatform
+	var ids []string
+	sections := make([]htmlSection, 0, len(rep.mits))
+	for _, m := range rep.mits {
+		ids = append(ids, m.ext)
+		s := htmlSection{ID: m.ext, Name: m.co.Name}
+		if ref, ok := attack.ExternalRef(m.co.ExternalRefs); ok {
+			s.URL = ref.URL
+		}
+		techs := rep.techniquesOf(m.ext)
+		if sectioned {
+			for _, g := range groupTechniques(techs, rep.groupBy) {
+				s.Groups = append(s.Groups, htmlGroup{Name: g.Group, Rows: rows(g.Techniques)})
+			}
+		} else {
+			s.Groups = []htmlGroup{{Rows: rows(techs)}}
+		}
+		sections = append(sections, s)
+	}
+	page := struct {
+		Title    string
+		Header   []string
+		Sections []htmlSection
+	}{
+		Title:    "MITRE ATT&CK mitigations: " + strings.Join(ids, ", "),
+		Header:   header,
+		Sections: sections,
+	}
+	if err := htmlReportTemplate.Execute(w, page); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: render HTML: %v\n", err)
+	}
+}
+
+// mitigationCount — сводка -count по одной митигации.
+type mitigationCount struct {
+	Mitigation string `json:"mitigation"`
+	Count      int    `json:"count"`
+}
+
+// emitCount выводит только число техник каждой митигации: в таблице — одно число (при
+// нескольких митигациях — "ID число" построчно), в JSON — объект (массив для нескольких),
+// в CSV — строка сводки на митигацию.
+func emitCount(w io.Writer, format string, rep mitigationReport) {
+	counts := make([]mitigationCount, len(rep.mits))
+	for i, m := range rep.mits {
+		counts[i] = mitigationCount{Mitigation: m.ext, Count: len(rep.techniquesOf(m.ext))}
+	}
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if len(counts) == 1 {
+			_ = enc.Encode(counts[0])
+		} else {
+			_ = enc.Encode(counts)
+		}
+	case formatJSONL:
+		enc := json.NewEncoder(w)
+		for _, c := range counts {
+			_ = enc.Encode(c)
+		}
+	case formatCSV:
+		cw := newCSVWriter(w)
+		writeCSVHeader(cw, []string{"Mitigation ID", "Mitigation Name", "Count"})
+		for _, c := range counts {
+			_ = cw.Write([]string{c.Mitigation, rep.mitigationName(c.Mitigation), strconv.Itoa(c.Count)})
+		}
+		cw.Flush()
+	default:
+		if len(counts) == 1 {
+			fmt.Fprintln(w, counts[0].Count)
+			return
+		}
+		for _, c := range counts {
+			fmt.Fprintf(w, "%s %d\n", c.Mitigation, c.Count)
+		}
+	}
+}
+
+// tacticCount — строка -tactic-histogram: число техник под тактикой.
+type tacticCount struct {
+	Tactic string `json:"tactic"`
+	Count  int    `json:"count"`
+}
+
+// tacticHistogram считает техники по тактикам (фазам kill chain); техника учитывается под
+// каждой своей тактикой, повтор техники у нескольких митигаций — один раз. Порядок — по
+// убыванию числа, при равенстве по названию тактики.
+func tacticHistogram(techs []techniqueInfo) []tacticCount {
+	seen := make(map[string]bool)
+	counts := make(map[string]int)
+	for _, t := range techs {
+		if seen[t.ExternalID] {
+			continue
+		}
+		seen[t.ExternalID] = true
+		for _, tactic := range t.Tactics {
+			counts[tactic]++
+		}
+	}
+	out := make([]tacticCount, 0, len(counts))
+	for tactic, n := range counts {
+		out = append(out, tacticCount{Tactic: tactic, Count: n})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Tactic < out[j].Tactic
+	})
+	return out
+}
+
+// emitTacticHistogram выводит -tactic-histogram: в таблице — тактика, число и полоса из "#",
+// в JSON — массив [{"tactic": ..., "count": ...}], в JSON Lines и CSV — по строке на тактику.
+func emitTacticHistogram(w io.Writer, format string, techs []techniqueInfo) {
+	hist := tacticHistogram(techs)
+	switch format {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(hist)
+	case formatJSONL:
+		enc := json.NewEncoder(w)
+		for _, c := range hist {
+			_ = enc.Encode(c)
+		}
+	case formatCSV:
+		cw := newCSVWriter(w)
+		writeCSVHeader(cw, []string{"Tactic", "Count"})
+		for _, c := range hist {
+			_ = cw.Write([]string{c.Tactic, strconv.Itoa(c.Count)})
+		}
+		cw.Flush()
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "TACTIC\tCOUNT")
+		for _, c := range hist {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", c.Tactic, c.Count, strings.Repeat("#", c.Count))
+		}
+		_ = tw.Flush()
+	}
+}
+
+// csvPlatformCells возвращает значения колонки Platforms для строк одной техники: одну ячейку
+// с платформами через "; " или, при flatten, по строке на каждую платформу.
+func csvPlatformCells(platforms []string, flatten bool) []string {
+	if !flatten || len(platforms) == 0 {
+		return []string{strings.Join(platforms, "; ")}
+	}
+	return platforms
+}
+
+/*
+-------------------------------------------------------------
+Отчёт в директорию (-output-dir) с manifest.json
+-------------------------------------------------------------
+*/
+// manifestFile — описание одного файла отчёта.
+type manifestFile struct {
+	Name   string `json:"name"`
+	Format string `json:"format"`
+	Size   int    `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// reportManifest — индекс отчёта: файлы, параметры запроса и происхождение данных.
+type reportManifest struct {
+	ToolVersion  string            `json:"tool_version"`
+	GeneratedAt  string            `json:"generated_at"`
+	Query        map[string]string `json:"query"`
+	BundleSHA256 string            `json:"bundle_sha256"`
+	Files        []manifestFile    `json:"files"`
+}
+
+// writeReportDir пишет отчёт во всех форматах в dir (каждый файл атомарно) и последним —
+// manifest.json, так что наличие манифеста означает полный набор файлов.
+func writeReportDir(dir string, bundleHash string, rep mitigationReport) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create output directory %s: %w", dir, err)
+	}
+	manifest := reportManifest{
+		ToolVersion:  version,
+		GeneratedAt:  time.Now().UTC().Format(time.RFC3339),
+		Query:        make(map[string]string),
+		BundleSHA256: bundleHash,
+	}
+	flag.Visit(func(f *flag.Flag) {
+		manifest.Query[f.Name] = f.Value.String()
+	})
+	for _, rf := range reportFormats {
+		var buf bytes.Buffer
+		emitReport(&buf, rf.format, rep)
+		name := "report." + rf.ext
+		if err := writeFileAtomic(filepath.Join(dir, name), buf.Bytes(), 0o600); err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, manifestFile{
+			Name:   name,
+			Format: rf.format,
+			Size:   buf.Len(),
+			SHA256: sha256Hex(buf.Bytes()),
+		})
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := writeFileAtomic(filepath.Join(dir, "manifest.json"), append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	if *flagDbg {
+		fmt.Fprintf(os.Stderr, ">>> report written to %s (%d files + manifest.json)\n", dir, len(manifest.Files))
+	}
+	return nil
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+/*
+-------------------------------------------------------------
+Пост-обработка через внешнюю команду (-exec)
+-------------------------------------------------------------
+*/
+// execArgs разбивает шаблон команды по пробелам и подставляет поля техники в каждый аргумент.
+// Подстановка идёт в уже разделённые аргументы, поэтому значения с пробелами и
+// спецсимволами передаются команде как есть, без участия shell.
+func execArgs(tmpl string, t techniqueInfo) []string {
+	r := strings.NewReplacer(
+		"{id}", t.ExternalID,
+		"{name}", t.Name,
+		"{tactics}", strings.Join(t.Tactics, ","),
+		"{platforms}", strings.Join(t.Platforms, ","),
+		"{mitigation}", t.Mitigation,
+	)
+	fields := strings.Fields(tmpl)
+	for i, f := range fields {
+		fields[i] = r.Replace(f)
+	}
+	return fields
+}
+
+// runExec запускает команду для каждой техники и возвращает код выхода: 0, если все запуски
+// успешны, иначе 1. Неуспешные запуски сообщаются в stderr, обработка продолжается.
+func runExec(tmpl string, techs []techniqueInfo) int {
+	if len(strings.Fields(tmpl)) == 0 {
+		fmt.Fprintln(os.Stderr, "ERROR: -exec command is empty")
+		return 1
+	}
+	code := 0
+	for _, t := range techs {
+		args := execArgs(tmpl, t)
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "exec %s: %v\n", t.ExternalID, err)
+			code = 1
+		}
+	}
+	return code
+}
+
+/*
+-------------------------------------------------------------
+Пользовательский формат вывода (-template, -template-file)
+-------------------------------------------------------------
+*/
+// templateRow — данные шаблона для одной техники: поля techniqueInfo (.ExternalID, .Name,
+// .Tactics, .Platforms, .Mitigation, ...) и название митигации, давшей строку.
+type templateRow struct {
+	techniqueInfo
+	MitigationName string
+}
+
+// templateFuncs — функции, доступные в шаблоне: {{join .Tactics ", "}}.
+var templateFuncs = texttemplate.FuncMap{
+	"join": strings.Join,
+}
+
+// loadOutputTemplate разбирает шаблон из -template или файла -template-file; без обоих
+// флагов возвращает nil.
+func loadOutputTemplate(text, path string) (*texttemplate.Template, error) {
+	switch {
+	case text != "" && path != "":
+		return nil, errors.New("-template and -template-file are mutually exclusive")
+	case path != "":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read -template-file: %w", err)
+		}
+		text = string(data)
+	case text == "":
+		return nil, nil
+	}
+	tmpl, err := texttemplate.New("output").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// emitTemplate выполняет шаблон для каждой техники отчёта; после каждой техники, если
+// шаблон сам не закончил строку, выводится перевод строки.
+func emitTemplate(w io.Writer, tmpl *texttemplate.Template, rep mitigationReport) error {
+	var buf bytes.Buffer
+	for _, t := range rep.techniques {
+		buf.Reset()
+		row := templateRow{techniqueInfo: t, MitigationName: rep.mitigationName(t.Mitigation)}
+		if err := tmpl.Execute(&buf, row); err != nil {
+			return fmt.Errorf("execute template for %s: %w", t.ExternalID, err)
+		}
+		if buf.Len() > 0 && !bytes.HasSuffix(buf.Bytes(), []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+		if _, err := w.Write(buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+-------------------------------------------------------------
+Обратный поиск: митигации для техники (-technique)
+-------------------------------------------------------------
+*/
+// mitigationInfo — строка результата обратного поиска. Status — "revoked" или "deprecated"
+// для митигаций, которые ATT&CK больше не рекомендует.
+type mitigationInfo struct {
+	ExternalID string `json:"external_id"`
+	Name       string `json:"name"`
+	Status     string `json:"status,omitempty"`
+}
+
+// displayName возвращает название митигации с пометкой статуса для таблицы.
+func (m mitigationInfo) displayName() string {
+	if m.Status == "" {
+		return m.Name
+	}
+	return m.Name + " [" + m.Status + "]"
+}
+
+// mitigationsForTechnique возвращает митигации, связи "mitigates" которых указывают на
+// технику techSTIXID, — без дубликатов, отсортированные по ExternalID; отозванные и
+// устаревшие помечены в Status.
+func mitigationsForTechnique(idx *attack.Index, techSTIXID string) []mitigationInfo {
+	var results []mitigationInfo
+	seen := make(map[string]bool)
+	for _, r := range idx.Rels {
+		if r.RelationshipType != "mitigates" || r.TargetRef != techSTIXID {
+			continue
+		}
+		co, ok := idx.Mitigations[r.SourceRef]
+		if !ok {
+			continue
+		}
+		ext := mitigationExtID(co)
+		if seen[ext] {
+			continue
+		}
+		seen[ext] = true
+		results = append(results, mitigationInfo{ExternalID: ext, Name: co.Name, Status: mitigationStatus(co)})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ExternalID < results[j].ExternalID
+	})
+	return results
+}
+
+// validateIDFlags проверяет формат внешних ID в -mitigation, -compare (Mxxxx), -technique и
+// -detections (Txxxx или Txxxx.xxx), чтобы опечатка не стоила загрузки и разбора бандла.
+func validateIDFlags() error {
+	for _, f := range []struct {
+		name, value string
+	}{
+		{"mitigation", *flagMitigation},
+		{"compare", *flagCompare},
+	} {
+		for _, id := range splitList(f.value) {
+			if !attack.ValidMitigationID(id) {
+				return fmt.Errorf("-%s: invalid mitigation ID %q (expected M followed by 4 digits or a bare number, e.g. M1037 or 1037)", f.name, id)
+			}
+		}
+	}
+	for _, f := range []struct {
+		name, value string
+	}{
+		{"technique", *flagTechnique},
+		{"detections", *flagDetections},
+	} {
+		if f.value != "" && !attack.ValidTechniqueID(f.value) {
+			return fmt.Errorf("-%s: invalid technique ID %q (expected T1234 or T1234.001)", f.name, f.value)
+		}
+	}
+	return nil
+}
+
+// techniqueByExtID возвращает STIX ID техники с внешним ID techID (без учёта регистра) или
+// пустую строку. Если ID носят несколько объектов (например, отозванная копия), выбирается
+// лучший кандидат: действующий раньше отозванного и устаревшего, затем более поздний modified,
+// при равенстве — меньший STIX ID, так что результат не зависит от порядка обхода map.
+func techniqueByExtID(idx *attack.Index, techID string) string {
+	var ids []string
+	for id, ap := range idx.Techniques {
+		if ext, ok := attack.ExternalID(ap.ExternalRefs); ok && strings.EqualFold(ext, techID) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return ""
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := idx.Techniques[ids[i]], idx.Techniques[ids[j]]
+		if a.Obsolete() != b.Obsolete() {
+			return !a.Obsolete()
+		}
+		if ma, mb := stixTime(a.Modified), stixTime(b.Modified); !ma.Equal(mb) {
+			return ma.After(mb)
+		}
+		return ids[i] < ids[j]
+	})
+	return ids[0]
+}
+
+// runTechniqueLookup находит технику по внешнему ID, выводит её митигации в выбранном формате
+// и возвращает их число.
+func runTechniqueLookup(out io.Writer, idx *attack.Index, techID string) int {
+	techSTIXID := techniqueByExtID(idx, techID)
+	if techSTIXID == "" {
+		fmt.Fprintf(os.Stderr, "technique %s not found in ATT&CK data\n", techID)
+		os.Exit(1)
+	}
+	tech := newTechniqueInfo(idx.Techniques[techSTIXID])
+	if *flagTacticDisplayNames {
+		tech.Tactics = displayTactics(tech.Tactics, idx.TacticDisplayNames())
+	}
+	mits := mitigationsForTechnique(idx, techSTIXID)
+
+	switch outputFormat() {
+	case formatNGQL:
+		emitTechniqueNGQL(out, tech, mits)
+	case formatJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(mits)
+	case formatJSONL:
+		enc := json.NewEncoder(out)
+		for _, m := range mits {
+			_ = enc.Encode(m)
+		}
+	case formatCSV:
+		w := newCSVWriter(out)
+		writeCSVHeader(w, []string{"Technique ID", "Technique Name", "Mitigation ID", "Mitigation Name", "Status"})
+		for _, m := range mits {
+			_ = w.Write([]string{tech.ExternalID, tech.Name, m.ExternalID, m.Name, m.Status})
+		}
+		w.Flush()
+	default:
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "TECHNIQUE\t%s (%s)\n", tech.Name, tech.ExternalID)
+		fmt.Fprintln(w, "---------------------------------------------------------------")
+		fmt.Fprintln(w, "MITIGATION ID\tMITIGATION NAME")
+		for _, m := range mits {
+			fmt.Fprintf(w, "%s\t%s\n", m.ExternalID, m.displayName())
+		}
+		_ = w.Flush()
+	}
+	return len(mits)
+}
+
+/*
+-------------------------------------------------------------
+Обнаружение: источники данных для техники (-detections)
+-------------------------------------------------------------
+*/
+// detectionInfo — компонент данных, обнаруживающий технику, и его источник данных.
+type detectionInfo struct {
+	DataComponent string `json:"data_component"`
+	DataSource    string `json:"data_source,omitempty"`
+	DataSourceID  string `json:"data_source_id,omitempty"`
+}
+
+// detectionsForTechnique возвращает компоненты данных, связи "detects" которых указывают на
+// технику techSTIXID, — без дубликатов, отсортированные по источнику и компоненту.
+func detectionsForTechnique(idx *attack.Index, techSTIXID string) []detectionInfo {
+	var results []detectionInfo
+	seen := make(map[string]bool)
+	for _, r := range idx.Rels {
+		if r.RelationshipType != "detects" || r.TargetRef != techSTIXID || seen[r.SourceRef] {
+			continue
+		}
+		dc, ok := idx.DataComponents[r.SourceRef]
+		if !ok {
+			continue
+		}
+		seen[r.SourceRef] = true
+		d := detectionInfo{DataComponent: dc.Name}
+		if ds, ok := idx.DataSources[dc.DataSourceRef]; ok {
+			d.DataSource = ds.Name
+			d.DataSourceID, _ = attack.ExternalID(ds.ExternalRefs)
+		}
+		results = append(results, d)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].DataSource != results[j].DataSource {
+			return results[i].DataSource < results[j].DataSource
+		}
+		return results[i].DataComponent < results[j].DataComponent
+	})
+	return results
+}
+
+// runDetections находит технику по внешнему ID, выводит обнаруживающие её компоненты данных
+// в выбранном формате и возвращает их число.
+func runDetections(out io.Writer, idx *attack.Index, techID string) int {
+	techSTIXID := techniqueByExtID(idx, techID)
+	if techSTIXID == "" {
+		fmt.Fprintf(os.Stderr, "technique %s not found in ATT&CK data\n", techID)
+		os.Exit(1)
+	}
+	tech := newTechniqueInfo(idx.Techniques[techSTIXID])
+	dets := detectionsForTechnique(idx, techSTIXID)
+
+	switch outputFormat() {
+	case formatJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(dets)
+	case formatJSONL:
+		enc := json.NewEncoder(out)
+		for _, d := range dets {
+			_ = enc.Encode(d)
+		}
+	case formatCSV:
+		w := newCSVWriter(out)
+		writeCSVHeader(w, []string{"Technique ID", "Technique Name", "Data Source ID", "Data Source", "Data Component"})
+		for _, d := range dets {
+			_ = w.Write([]string{tech.ExternalID, tech.Name, d.DataSourceID, d.DataSource, d.DataComponent})
+		}
+		w.Flush()
+	default:
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "TECHNIQUE\t%s (%s)\n", tech.Name, tech.ExternalID)
+		fmt.Fprintln(w, "---------------------------------------------------------------")
+		fmt.Fprintln(w, "DATA SOURCE\tDATA COMPONENT")
+		for _, d := range dets {
+			source := d.DataSource
+			if d.DataSourceID != "" {
+				source = fmt.Sprintf("%s (%s)", d.DataSource, d.DataSourceID)
+			}
+			fmt.Fprintf(w, "%s\t%s\n", source, d.DataComponent)
+		}
+		_ = w.Flush()
+	}
+	return len(dets)
+}
+
+/*
+-------------------------------------------------------------
+Сравнение двух митигаций (-compare)
+-------------------------------------------------------------
+*/
+// Множества техник в -compare
+const (
+	compareOnlyA = "only_a"
+	compareOnlyB = "only_b"
+	compareBoth  = "both"
+)
+
+// mitigationComparison — результат -compare: техники, которые покрывает только A, только B и обе.
+type mitigationComparison struct {
+	A     mitigationInfo  `json:"mitigation_a"`
+	B     mitigationInfo  `json:"mitigation_b"`
+	OnlyA []techniqueInfo `json:"only_a"`
+	OnlyB []techniqueInfo `json:"only_b"`
+	Both  []techniqueInfo `json:"both"`
+}
+
+// compareMitigations сравнивает техники двух митигаций по внешним ID. Порядок каждого множества —
+// как у обычного вывода (-sort-by); у техник из both поле mitigation не заполняется.
+func compareMitigations(a, b []techniqueInfo) (onlyA, onlyB, both []techniqueInfo) {
+	inA := make(map[string]bool, len(a))
+	for _, t := range a {
+		inA[t.ExternalID] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, t := range b {
+		inB[t.ExternalID] = true
+	}
+	onlyA, onlyB, both = []techniqueInfo{}, []techniqueInfo{}, []techniqueInfo{}
+	for _, t := range a {
+		if inB[t.ExternalID] {
+			t.Mitigation = ""
+			both = append(both, t)
+		} else {
+			onlyA = append(onlyA, t)
+		}
+	}
+	for _, t := range b {
+		if !inA[t.ExternalID] {
+			onlyB = append(onlyB, t)
+		}
+	}
+	return onlyA, onlyB, both
+}
+
+// runCompare (-compare MA,MB) выводит три множества техник двух митигаций и возвращает число
+// различных техник в них.
+func runCompare(out io.Writer, idx *attack.Index, spec string, filter techniqueFilter) int {
+	ids := splitList(spec)
+	if len(ids) != 2 {
+		fmt.Fprintf(os.Stderr, "ERROR: -compare needs exactly two mitigation IDs (e.g. M1037,M1040), got %q\n", spec)
+		os.Exit(1)
+	}
+	var mits [2]resolvedMitigation
+	var techs [2][]techniqueInfo
+	for i, want := range ids {
+		stixID := mitigationByExtID(idx, want)
+		if stixID == "" {
+			fmt.Fprintf(os.Stderr, "mitigation %s not found in ATT&CK data\n", want)
+			os.Exit(1)
+		}
+		mits[i] = newResolvedMitigation(idx.Mitigations[stixID])
+		techs[i] = techniquesForMitigation(idx, stixID, filter)
+		sortTechniques(techs[i], *flagSortBy)
+		if *flagNoSubtechniques {
+			techs[i] = topLevelTechniques(techs[i])
+		}
+	}
+	cmp := mitigationComparison{
+		A: mitigationInfo{ExternalID: mits[0].ext, Name: mits[0].co.Name},
+		B: mitigationInfo{ExternalID: mits[1].ext, Name: mits[1].co.Name},
+	}
+	cmp.OnlyA, cmp.OnlyB, cmp.Both = compareMitigations(techs[0], techs[1])
+	sets := []struct {
+		name  string
+		label string
+		techs []techniqueInfo
+	}{
+		{compareOnlyA, "ONLY " + cmp.A.ExternalID, cmp.OnlyA},
+		{compareOnlyB, "ONLY " + cmp.B.ExternalID, cmp.OnlyB},
+		{compareBoth, "BOTH", cmp.Both},
+	}
+
+	switch outputFormat() {
+	case formatJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(cmp)
+	case formatJSONL:
+		// По строке на технику; поле set — имя множества
+		enc := json.NewEncoder(out)
+		for _, set := range sets {
+			for _, t := range set.techs {
+				_ = enc.Encode(struct {
+					Set string `json:"set"`
+					techniqueInfo
+				}{set.name, t})
+			}
+		}
+	case formatCSV:
+		w := newCSVWriter(out)
+		writeCSVHeader(w, []string{"Set", "Technique ID", "Technique Name"})
+		for _, set := range sets {
+			for _, t := range set.techs {
+				_ = w.Write([]string{set.name, t.ExternalID, t.Name})
+			}
+		}
+		w.Flush()
+	default:
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "COMPARE\t%s (%s) vs %s (%s)\n", cmp.A.Name, cmp.A.ExternalID, cmp.B.Name, cmp.B.ExternalID)
+		for _, set := range sets {
+			fmt.Fprintln(w, "---------------------------------------------------------------")
+			fmt.Fprintf(w, "%s\t%d technique(s)\n", set.label, len(set.techs))
+			for _, t := range set.techs {
+				fmt.Fprintf(w, "%s\t%s\n", t.ExternalID, tableTechniqueName(t))
+			}
+		}
+		_ = w.Flush()
+	}
+	return len(cmp.OnlyA) + len(cmp.OnlyB) + len(cmp.Both)
+}
+
+/*
+-------------------------------------------------------------
+Изменения относительно прошлого вывода (-diff-against)
+-------------------------------------------------------------
+*/
+// coverageDiff — техники, появившиеся и пропавшие по сравнению с прошлым выводом -json.
+type coverageDiff struct {
+	Added   []techniqueInfo `json:"added"`
+	Removed []techniqueInfo `json:"removed"`
+}
+
+// previousEntry — элемент массива прошлого вывода -json: техника (с под-техниками при -tree)
+// или группа -group-output-by с вложенным списком техник.
+type previousEntry struct {
+	techniqueInfo
+	Subtechniques []techniqueInfo `json:"subtechniques"`
+	Group         *string         `json:"group"`
+	Techniques    []techniqueInfo `json:"techniques"`
+}
+
+// loadPreviousJSON читает прошлый вывод -json: плоский массив техник, дерево -tree или группы
+// -group-output-by (повторы техники в нескольких группах схлопываются), в том числе в обёртке
+// -with-meta (берётся её поле techniques). Записи без поля
+// mitigation (вывод до пакетного режима) относятся к единственной запрошенной митигации.
+func loadPreviousJSON(path string, chosen []resolvedMitigation) ([]techniqueInfo, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read -diff-against: %w", err)
+	}
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var wrapped struct {
+			Techniques json.RawMessage `json:"techniques"`
+		}
+		if err := json.Unmarshal(trimmed, &wrapped); err != nil || wrapped.Techniques == nil {
+			return nil, fmt.Errorf("-diff-against %s is a JSON object without \"techniques\" (only -with-meta output is accepted; regenerate it with -json, dropping other flags)", path)
+		}
+		data = wrapped.Techniques
+	}
+	var entries []previousEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("-diff-against %s is not a -json technique array: %w", path, err)
+	}
+	var prev []techniqueInfo
+	for _, e := range entries {
+		switch {
+		case e.Group != nil:
+			prev = append(prev, e.Techniques...)
+		case e.ExternalID != "":
+			prev = append(prev, e.techniqueInfo)
+			prev = append(prev, e.Subtechniques...)
+		default:
+			return nil, fmt.Errorf("-diff-against %s: unrecognized entry (want techniques, a -tree or a -group-output-by array)", path)
+		}
+	}
+	if len(chosen) == 1 {
+		for i := range prev {
+			if prev[i].Mitigation == "" {
+				prev[i].Mitigation = chosen[0].ext
+			}
+		}
+	}
+	seen := make(map[string]bool, len(prev))
+	out := prev[:0]
+	for _, t := range prev {
+		if key := t.Mitigation + "\x00" + t.ExternalID; !seen[key] {
+			seen[key] = true
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+// diffCoverage сравнивает прошлый и текущий результат по паре (митигация, внешний ID техники).
+// Учитываются только запрошенные сейчас митигации; порядок — как в исходных списках.
+func diffCoverage(prev, cur []techniqueInfo, chosen []resolvedMitigation) coverageDiff {
+	key := func(t techniqueInfo) string { return t.Mitigation + "\x00" + t.ExternalID }
+	inPrev := make(map[string]bool, len(prev))
+	for _, t := range prev {
+		inPrev[key(t)] = true
+	}
+	inCur := make(map[string]bool, len(cur))
+	for _, t := range cur {
+		inCur[key(t)] = true
+	}
+	d := coverageDiff{Added: []techniqueInfo{}, Removed: []techniqueInfo{}}
+	for _, t := range cur {
+		if !inPrev[key(t)] {
+			d.Added = append(d.Added, t)
+		}
+	}
+	for _, t := range prev {
+		queried := slices.ContainsFunc(chosen, func(m resolvedMitigation) bool { return m.ext == t.Mitigation })
+		if queried && !inCur[key(t)] {
+			d.Removed = append(d.Removed, t)
+		}
+	}
+	return d
+}
+
+// runDiffAgainst выводит изменения покрытия: в таблице — строки "+"/"-", в JSON — объект
+// {added, removed}, в JSON Lines и CSV — запись на изменение. Без изменений текстовый вывод пуст.
+func runDiffAgainst(out io.Writer, path string, chosen []resolvedMitigation, cur []techniqueInfo) error {
+	prev, err := loadPreviousJSON(path, chosen)
+	if err != nil {
+		return err
+	}
+	d := diffCoverage(prev, cur, chosen)
+	changes := []struct {
+		sign, name string
+		techs      []techniqueInfo
+	}{
+		{"+", "added", d.Added},
+		{"-", "removed", d.Removed},
+	}
+	switch outputFormat() {
+	case formatJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(d)
+	case formatJSONL:
+		enc := json.NewEncoder(out)
+		for _, c := range changes {
+			for _, t := range c.techs {
+				_ = enc.Encode(struct {
+					Change string `json:"change"`
+					techniqueInfo
+				}{c.name, t})
+			}
+		}
+	case formatCSV:
+		w := newCSVWriter(out)
+		writeCSVHeader(w, []string{"Change", "Mitigation ID", "Technique ID", "Technique Name"})
+		for _, c := range changes {
+			for _, t := range c.techs {
+				_ = w.Write([]string{c.name, t.Mitigation, t.ExternalID, t.Name})
+			}
+		}
+		w.Flush()
+	default:
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		for _, c := range changes {
+			for _, t := range c.techs {
+				fmt.Fprintf(w, "%s %s\t%s\t%s\n", c.sign, t.Mitigation, t.ExternalID, t.Name)
+			}
+		}
+		_ = w.Flush()
+	}
+	return nil
+}
+
+/*
+-------------------------------------------------------------
+Список митигаций (-list-mitigations)
+-------------------------------------------------------------
+*/
+// runListMitigations выводит все митигации бандла (внешний ID и название), отсортированные
+// по ID, в выбранном формате и возвращает их число.
+func runListMitigations(out io.Writer, idx *attack.Index) int {
+	mits := make([]mitigationInfo, 0, len(idx.Mitigations))
+	for _, co := range idx.Mitigations {
+		mits = append(mits, mitigationInfo{ExternalID: mitigationExtID(co), Name: co.Name})
+	}
+	sort.Slice(mits, func(i, j int) bool {
+		return mits[i].ExternalID < mits[j].ExternalID
+	})
+
+	switch outputFormat() {
+	case formatJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(mits)
+	case formatJSONL:
+		enc := json.NewEncoder(out)
+		for _, m := range mits {
+			_ = enc.Encode(m)
+		}
+	case formatCSV:
+		w := newCSVWriter(out)
+		writeCSVHeader(w, []string{"Mitigation ID", "Mitigation Name"})
+		for _, m := range mits {
+			_ = w.Write([]string{m.ExternalID, m.Name})
+		}
+		w.Flush()
+	default:
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "MITIGATION ID\tMITIGATION NAME")
+		for _, m := range mits {
+			fmt.Fprintf(w, "%s\t%s\n", m.ExternalID, m.Name)
+		}
+		_ = w.Flush()
+	}
+	return len(mits)
+}
+
+// datasetPlatforms возвращает набор значений x_mitre_platforms всех техник бандла, отсортированный
+// без учёта регистра (macOS рядом с Linux, а не после Windows).
+func datasetPlatforms(idx *attack.Index) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, ap := range idx.Techniques {
+		for _, p := range ap.Platforms {
+			if !seen[p] {
+				seen[p] = true
+				out = append(out, p)
+			}
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if a, b := strings.ToLower(out[i]), strings.ToLower(out[j]); a != b {
+			return a < b
+		}
+		return out[i] < out[j]
+	})
+	return out
+}
+
+// runListPlatforms выводит допустимые значения -platform (по строке, JSON-массив строк,
+// JSON Lines или CSV) и возвращает их число.
+func runListPlatforms(out io.Writer, idx *attack.Index) int {
+	platforms := datasetPlatforms(idx)
+	switch outputFormat() {
+	case formatJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if platforms == nil {
+			platforms = []string{}
+		}
+		_ = enc.Encode(platforms)
+	case formatJSONL:
+		enc := json.NewEncoder(out)
+		for _, p := range platforms {
+			_ = enc.Encode(p)
+		}
+	case formatCSV:
+		w := newCSVWriter(out)
+		writeCSVHeader(w, []string{"Platform"})
+		for _, p := range platforms {
+			_ = w.Write([]string{p})
+		}
+		w.Flush()
+	default:
+		for _, p := range platforms {
+			fmt.Fprintln(out, p)
+		}
+	}
+	return len(platforms)
+}
+
+/*
+-------------------------------------------------------------
+Case study: техники группы и их покрытие митигациями
+-------------------------------------------------------------
+*/
+// caseStudyRow — техника, используемая группой, и митигации, которые её покрывают.
+type caseStudyRow struct {
+	techniqueInfo
+	UsedByGroup bool     `json:"used_by_group"`
+	Mitigations []string `json:"mitigations"`
+	Covered     bool     `json:"covered"`
+}
+
+// caseStudyReport — результат -case-study в JSON.
+type caseStudyReport struct {
+	Group struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"group"`
+	Techniques []caseStudyRow `json:"techniques"`
+}
+
+// buildCaseStudy соединяет связи "uses" группы groupSTIXID со связями "mitigates":
+// для каждой используемой техники — отсортированный список внешних ID покрывающих митигаций.
+func buildCaseStudy(idx *attack.Index, groupSTIXID string, filter techniqueFilter) []caseStudyRow {
+	used := make(map[string]bool) // STIX ID техник, используемых группой
+	for _, r := range idx.Rels {
+		if r.RelationshipType == "uses" && r.SourceRef == groupSTIXID {
+			if tp, ok := idx.Techniques[r.TargetRef]; ok && filter.keep(tp) {
+				used[r.TargetRef] = true
+			}
+		}
+	}
+	covering := make(map[string]map[string]bool) // STIX ID техники -> внешние ID митигаций
+	for _, r := range idx.Rels {
+		if r.RelationshipType != "mitigates" || !used[r.TargetRef] {
+			continue
+		}
+		co, ok := idx.Mitigations[r.SourceRef]
+		if !ok {
+			continue
+		}
+		mitExt, ok := attack.ExternalID(co.ExternalRefs)
+		if !ok {
+			continue
+		}
+		if covering[r.TargetRef] == nil {
+			covering[r.TargetRef] = make(map[string]bool)
+		}
+		covering[r.TargetRef][mitExt] = true
+	}
+
+	rows := make([]caseStudyRow, 0, len(used))
+	for id := range used {
+		row := caseStudyRow{
+			techniqueInfo: newTechniqueInfo(idx.Techniques[id]),
+			UsedByGroup:   true,
+			Mitigations:   []string{},
+		}
+		for mitExt := range covering[id] {
+			row.Mitigations = append(row.Mitigations, mitExt)
+		}
+		sort.Strings(row.Mitigations)
+		row.Covered = len(row.Mitigations) > 0
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].ExternalID < rows[j].ExternalID
+	})
+	return rows
+}
+
+// stixTime разбирает метку времени STIX (RFC 3339); некорректная или пустая метка считается
+// самой старой.
+func stixTime(ts string) time.Time {
+	t, err := time.Parse(time.RFC3339, ts)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// groupByExtID находит группу по внешнему ID (без учёта регистра). Если ID носят несколько
+// объектов, выбирается лучший кандидат: действующий раньше отозванного и устаревшего, затем
+// более поздний modified, при равенстве — меньший STIX ID, так что результат не зависит от
+// порядка обхода map.
+func groupByExtID(idx *attack.Index, groupID string) (attack.IntrusionSet, bool) {
+	var candidates []attack.IntrusionSet
+	for _, is := range idx.Groups {
+		if ext, ok := attack.ExternalID(is.ExternalRefs); ok && strings.EqualFold(ext, groupID) {
+			candidates = append(candidates, is)
+		}
+	}
+	if len(candidates) == 0 {
+		return attack.IntrusionSet{}, false
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if obsA, obsB := a.Revoked || a.Deprecated, b.Revoked || b.Deprecated; obsA != obsB {
+			return !obsA
+		}
+		if ma, mb := stixTime(a.Modified), stixTime(b.Modified); !ma.Equal(mb) {
+			return ma.After(mb)
+		}
+		return a.ID < b.ID
+	})
+	return candidates[0], true
+}
+
+// runCaseStudy находит группу по внешнему ID, выводит отчёт в выбранном формате и возвращает
+// число техник группы.
+func runCaseStudy(out io.Writer, idx *attack.Index, groupID string, filter techniqueFilter) int {
+	group, found := groupByExtID(idx, groupID)
+	if !found {
+		fmt.Fprintf(os.Stderr, "group %s not found in ATT&CK data\n", groupID)
+		os.Exit(1)
+	}
+	groupExt, _ := attack.ExternalID(group.ExternalRefs)
+	rows := buildCaseStudy(idx, group.ID, filter)
+
+	switch outputFormat() {
+	case formatJSON:
+		var report caseStudyReport
+		report.Group.ID = groupExt
+		report.Group.Name = group.Name
+		report.Techniques = rows
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(report)
+		return len(rows)
+	case formatJSONL:
+		enc := json.NewEncoder(out)
+		for _, r := range rows {
+			_ = enc.Encode(r)
+		}
+		return len(rows)
+	case formatCSV, formatTSV:
+		records := [][]string{{"Group ID", "Group Name", "Technique ID", "Technique Name", "Used By Group", "Mitigations", "Covered"}}
+		for _, r := range rows {
+			records = append(records, []string{groupExt, group.Name, r.ExternalID, r.Name,
+				strconv.FormatBool(r.UsedByGroup), strings.Join(r.Mitigations, "; "), strconv.FormatBool(r.Covered)})
+		}
+		if outputFormat() == formatTSV {
+			writeTSV(out, withoutHeader(records))
+		} else {
+			_ = newCSVWriter(out).WriteAll(withoutHeader(records))
+		}
+		return len(rows)
+	case formatMarkdown:
+		fmt.Fprintf(out, "## %s — %s\n\n", groupExt, markdownCell(group.Name))
+		writeMarkdownRow(out, []string{"Technique ID", "Technique Name", "Mitigations"})
+		writeMarkdownRow(out, []string{"---", "---", "---"})
+		uncovered := 0
+		for _, r := range rows {
+			mits := strings.Join(r.Mitigations, ", ")
+			if !r.Covered {
+				mits = "**UNCOVERED**"
+				uncovered++
+			}
+			writeMarkdownRow(out, []string{r.ExternalID, r.Name, mits})
+		}
+		fmt.Fprintf(out, "\nUncovered: %d of %d techniques\n", uncovered, len(rows))
+		return len(rows)
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "GROUP\t%s (%s)\n", group.Name, groupExt)
+	fmt.Fprintln(w, "---------------------------------------------------------------")
+	fmt.Fprintln(w, "TECHNIQUE ID\tTECHNIQUE NAME\tUSED BY GROUP\tMITIGATIONS")
+	uncovered := 0
+	for _, r := range rows {
+		mits := strings.Join(r.Mitigations, ", ")
+		if !r.Covered {
+			mits = "!! UNCOVERED"
+			uncovered++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.ExternalID, r.Name, "yes", mits)
+	}
+	_ = w.Flush()
+	fmt.Fprintf(out, "\nUncovered: %d of %d techniques\n", uncovered, len(rows))
+	return len(rows)
+}
+
+/*
+-------------------------------------------------------------
+Функция для вывода справки
+-------------------------------------------------------------
+*/
+func printUsage() {
+	fmt.Printf(`Usage: %s -mitigation Mxxxx [options]
+Options:
+   -mitigation          ATT&CK mitigation external ID (Mxxxx); batch: comma-separated M1037,M1040;
+                        a bare number gets the M prefix (1037 == M1037)
+   -strict-id           Match -mitigation IDs case-sensitively (default: m1037 matches M1037)
+   -mitigation-name    Full mitigation name (case‑insensitive)
+   -suggest-distance N  Maximum edit distance for "Did you mean" suggestions on unknown names and IDs
+                        (default 0: one edit per 5 characters of the name, 2 for IDs; -debug shows
+                        the closest names with their distances)
+   -mitigation-search TERM
+                        Substring of the mitigation name (case-insensitive); several matches
+                        are listed with their IDs on stderr (exit 1)
+   -mitigation-regex RE
+                        All mitigations whose name matches the Go regexp RE (case-sensitive; use
+                        (?i) to ignore case), reported like a batch; warns when RE matches them all
+   -technique Txxxx     Reverse lookup: mitigations for a technique (all output formats)
+   -detections Txxxx    Data components/sources that detect the technique ("detects" relationships)
+   -compare MA,MB       Techniques covered only by MA, only by MB, and by both (table, JSON, JSON
+                        Lines, CSV): helps decide whether overlapping controls are redundant
+   -case-study -group Gxxxx
+                        Techniques used by the group with their covering mitigations
+                        (uncovered techniques are highlighted)
+   -list-mitigations    List every mitigation ID and name sorted by ID (table, JSON, JSON Lines, CSV)
+   -list-platforms      List the unique platform values of all techniques (valid -platform input)
+   -domain DOMAIN       ATT&CK matrix: enterprise (default), mobile or ics
+   
+Output formats:
+   -json                Output JSON
+   -json-schema         Print the JSON Schema of the -json output (no bundle needed) and exit
+   -jsonl               Output JSON Lines (one technique per line, same fields as -json)
+   -csv                 Output CSV
+   -tsv                 Output TSV (same columns as CSV; tabs/newlines in values become spaces)
+   -markdown            Output a Markdown table per mitigation ("## Mxxxx — Name" heading;
+                        "## Gxxxx — Name" with -case-study)
+   -html                Output a self-contained HTML page (styled table, IDs link to attack.mitre.org)
+   -ngql                Output Nebula Graph INSERT statements
+   -dot                 Output Graphviz DOT digraph (render with: dot -Tpng)
+   -cypher              Output Neo4j Cypher MERGE statements (idempotent import)
+   -stix                Output a STIX bundle subset: the mitigation, its techniques and the
+                        mitigates relationships, copied verbatim (original IDs) from the source bundle
+   -mermaid             Output a Mermaid "graph LR" definition (renders in GitHub "mermaid" code blocks)
+   -json-graph          Output a JSON object {mitigation, techniques, edges: [{from, to}]} keyed by
+                        external IDs (an array of such objects for several mitigations)
+   -no-subtechniques    Keep top-level techniques only (drop IDs with a dot, e.g. T1059.001)
+   -tree                Nest sub-techniques under their parent (indented table; "subtechniques"
+                        array in JSON/JSON Lines without -group-output-by)
+   -sort-by KEY         Order techniques by id (default), name or tactic (alphabetically first tactic, then ID)
+   -color MODE          Table colors: auto (default; terminal only), always, never; in auto mode
+                        CLICOLOR_FORCE=1 forces color, then NO_COLOR or CLICOLOR=0 turn it off
+   -no-color            Alias for -color never
+   -group-output-by DIM Group table/JSON/CSV output by none (default), mitigation, tactic or platform
+   -flatten-platforms   CSV: one row per (technique, platform); row count grows by the number of platforms
+   -csv-delimiter C     CSV field delimiter (default ","), e.g. ";" for spreadsheet locales
+   -no-header           Omit the header row from CSV and TSV output
+   -output FILE         Write the result to FILE (mode 0600, atomic rename) instead of stdout
+   -output-dir DIR      Write report.{txt,json,jsonl,csv,tsv,ngql,dot,cypher,mmd,graph.json,md,html} and manifest.json
+                        (sizes, SHA-256, query flags, bundle hash, tool version) into DIR
+   -show-description    Include technique descriptions (JSON field, CSV column, wrapped in the table)
+   -desc-width N        Truncate descriptions to N characters with "…" in table/CSV (default 80,
+                        0 = no limit); JSON keeps the full text
+   -show-url            Include the ATT&CK URL of each technique (JSON field, CSV and table column)
+   -show-relationship-desc
+                        Include how the mitigation applies to each technique (the mitigates
+                        relationship description): "mitigation_detail" in JSON, "how:" text in the table
+   -show-groups         Include the groups that use each technique (JSON field, CSV and table column)
+   -show-software       Include the malware/tools that use each technique (JSON field, CSV and table column)
+   -show-campaigns      Include the campaigns that used each technique (JSON field, CSV and table column)
+   -show-datasources    Include the data sources each technique touches (x_mitre_data_sources) as the
+                        "data_sources" JSON/JSON Lines field
+   -count               Print only the number of techniques (JSON object / one-row CSV summary)
+   -tactic-histogram    Print each tactic with its technique count, sorted descending (table with
+                        "#" bars, JSON [{"tactic": ..., "count": ...}], JSON Lines, CSV)
+   -tactic-display-names
+                        Show tactics by display name ("Defense Evasion") instead of the kill chain
+                        shortname ("defense-evasion") in every format; -tactic still takes shortnames
+   -with-meta           Wrap -json output in an object: {"mitigation": {"id", "name"}, "techniques",
+                        "generated_at", "attack_version"} ("mitigations" for a batch); CSV/TSV get
+                        "Generated At" and "ATT&CK Version" columns. Default output stays a bare array
+   -diff-against FILE   Compare with a previous -json output: "+" added and "-" removed techniques
+                        per mitigation (JSON: {"added": [...], "removed": [...]}); coverage drift in CI.
+                        The previous output may also be -tree, -group-output-by or -with-meta JSON
+   -exec "CMD ARGS"     Run CMD once per technique instead of printing (no shell); placeholders:
+                        {id} {name} {tactics} {platforms} {mitigation}
+   -template TMPL       Render each technique through a Go text/template instead of a built-in format:
+                        .ExternalID .Name .Tactics .Platforms .Mitigation .MitigationName ...;
+                        {{join .Tactics ","}} joins lists; a newline is added after each technique
+   -template-file PATH  Like -template, with the template read from PATH
+   -fail-empty          Exit with code 2 when nothing is found after filtering (output still printed)
+
+Filters:
+   -created-after DATE  Only techniques created on or after DATE (YYYY-MM-DD or RFC3339)
+   -created-before DATE Only techniques created on or before DATE (inclusive)
+   -modified-since DATE Only techniques whose STIX "modified" is on or after DATE: what changed in
+                        the mitigation's coverage since an ATT&CK release
+   -deprecated-only     Show only revoked/deprecated techniques (audit of stale mappings)
+   -include-deprecated  Include techniques marked x_mitre_deprecated (excluded by default)
+   -tactic NAME         Only techniques in tactic NAME (shortname, e.g. defense-evasion)
+   -platform LIST       Only techniques for any of the platforms (comma-separated, e.g. Windows,Linux)
+   
+Cache control:
+   --cache-dir DIR      Cache directory (default: MITRE_CACHE_DIR env or .mitre-cache)
+   --no-cache           Disable caching
+   --force-refresh      Force download fresh bundle ignoring cache
+   -cache-info          Print the cache dir, bundle size, mtime, age, TTL remaining and spec_version
+                        (no network)
+   -clear-cache         Delete the cached bundles of all domains with their .sha256/.meta sidecars
+                        and index snapshots, list them, and exit; refuses a directory holding
+                        anything else
+   -dry-run             Print the cache dir, cache age/validity and whether a download would
+                        happen (with the reason), then exit 0 without downloading
+   -check-freshness     Conditional HEAD with the cached ETag/Last-Modified: prints "cache is up to
+                        date" (exit 0) or "update available" (exit 3) without downloading the bundle
+   -allow-stale         If the download fails, use the expired cache anyway (warning with its age on stderr)
+   -embedded            Use the enterprise bundle embedded at build time (make build-embedded); a
+                        point-in-time snapshot, no network and no cache
+   -bundle-file PATH    Read the STIX bundle from a local file (air-gapped use; cache flags are ignored);
+                        "-" reads it from stdin (e.g. curl ... | gunzip | mitremit -bundle-file -)
+
+Network:
+   -retries N           Retry the download N times on network errors and HTTP 5xx, not 4xx (default 3)
+   -retry-delay D       Delay before the first retry, doubled each attempt (default 1s)
+   -timeout D           HTTP client timeout for the download, e.g. 30s, 2m (default 5m)
+   -proxy URL           HTTP(S) proxy for the download (default: HTTP_PROXY/HTTPS_PROXY/NO_PROXY)
+   -bundle-url URL      Download the bundle from an http(s) mirror instead of mitre/cti; the cache
+                        file name includes a hash of the URL, so switching mirrors never reuses
+                        another source's cache
+   -ca-file PATH        Trust only the CA certificates in this PEM file for the download
+   -pin-sha256 HASHES   Pin the server public key: base64 SHA-256 of its SPKI, comma-separated for
+                        rotation; the download fails (without retries) on mismatch
+
+Parsing:
+   -stream-buffer N     Parse objects through a bounded channel of N objects consumed by
+                        parallel workers (default 64; 0 = in-memory json.Unmarshal fallback)
+   -index-cache         Save the parsed index in the cache dir and reuse it while the bundle
+                        SHA-256 matches (faster warm starts)
+   
+Debug:
+   -debug               Extra diagnostic output
+   -metrics             Print the wall-clock time of each phase (cache read, download, parse,
+                        extraction, output) and the total to stderr; the streamed body is read
+                        during parse, so parse includes the transfer and the cache write
+   -quiet               Suppress warnings and informational stderr messages (errors and -debug are kept)
+                        and the download progress shown when stderr is a terminal
+   -version             Print the tool version and the spec_version of the cached bundle
+   -h                   Show this help
+
+Server mode:
+   -serve ADDR          Serve GET /mitigation/{id} (same JSON as -json) on ADDR, e.g. :8080;
+                        the bundle is loaded once and refreshed in the background when the
+                        cache gets older than 24h (not with -bundle-file)
+
+Argument files:
+   @FILE                Read additional flags from FILE (whitespace/newline separated)
+
+Config file:
+   .mitremitrc in the working directory or $XDG_CONFIG_HOME/mitremit/config (first found wins):
+   a JSON object of flag defaults, e.g. {"cache-dir": "/cache", "domain": "ics", "format": "json"};
+   only cache-dir, domain and format are accepted, any other key is an error;
+   flags given on the command line and MITREMIT_* variables take precedence
+
+Exit codes:
+   0                    Results found
+   1                    Error (bad flags, bundle unavailable, mitigation not found)
+   2                    No results after filtering (only with -fail-empty)
+   3                    Update available (only with -check-freshness)
+   130                  Interrupted (Ctrl-C): the download is aborted and no partial cache file is left
+
+Environment variables:
+   MITRE_CACHE_DIR      Cache directory (overrides default)
+   CLICOLOR_FORCE       Non-empty and not 0: color the table even when not a terminal (-color auto)
+   NO_COLOR, CLICOLOR=0 Disable table colors (-color auto)
+   MITREMIT_CACHE_DIR   Default for -cache-dir (absolute path only)
+   MITREMIT_DOMAIN      Default for -domain
+   MITREMIT_FORMAT      Default output format (json, csv, ...); command-line flags take precedence,
+                        and MITREMIT_* for any other flag is an error (same keys as the config file)
+
+Examples:
+   %s -mitigation M1037
+   %s -mitigation M1037 -json
+   %s -mitigation M1037,M1040,M1049 -csv
+   %s -mitigation M1037 --no-cache
+   %s -domain ics -mitigation M0930
+   MITRE_CACHE_DIR=/cache %s -mitigation M1037 --force-refresh
+`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+}
+
+/*
+-------------------------------------------------------------
+Pretty‑print table (default output)
+-------------------------------------------------------------
+*/
+// Варианты раскладки таблицы — от полной к самой компактной по ширине.
+type tableLayout int
+
+const (
+	layoutFull      tableLayout = iota // все колонки
+	layoutNoTactics                    // без колонки TACTICS (наименее приоритетной)
+	layoutStacked                      // "ключ: значение" построчно для каждой техники
+)
+
+// printTable печатает таблицу (блок на митигацию, внутри — по секции на группу). Если w —
+// терминал и таблица шире него, последовательно пробует более узкие раскладки.
+func printTable(w io.Writer, rep mitigationReport) {
+	out := renderTable(rep, layoutFull)
+	if width, ok := terminalWidth(w); ok {
+		for _, layout := range []tableLayout{layoutNoTactics, layoutStacked} {
+			got := tableWidth(out)
+			if got <= width {
+				break
+			}
+			if *flagDbg {
+				fmt.Fprintf(os.Stderr, ">>> WARNING: table width %d exceeds terminal width %d – switching to narrower layout\n",
+					got, width)
+			}
+			out = renderTable(rep, layout)
+		}
+	}
+	if colorEnabled(w) {
+		out = colorizeTable(out)
+	}
+	fmt.Fprint(w, out)
+}
+
+// renderTable формирует текст таблицы в заданной раскладке. Каждая митигация выводится
+// отдельным блоком, поэтому группировка по митигации в таблице дополнительных секций не даёт.
+func renderTable(rep mitigationReport, layout tableLayout) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+
+	sectioned := rep.groupBy == groupByTactic || rep.groupBy == groupByPlatform
+	for i, m := range rep.mits {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "MITIGATION\t%s (%s)\n", m.co.Name, m.ext)
+		techs := rep.techniquesOf(m.ext)
+		groups := []techniqueGroup{{Techniques: techs}}
+		if sectioned {
+			groups = groupTechniques(techs, rep.groupBy)
+		}
+		renderTableSections(w, groups, rep.groupBy, sectioned, layout)
+		fmt.Fprintln(w, "---------------------------------------------------------------")
+		fmt.Fprintln(w, tableFooter(techs))
+	}
+	_ = w.Flush()
+	return b.String()
+}
+
+// tableFooter — итоговая строка блока митигации: число техник и различных тактик
+// (фаз kill chain) среди них.
+func tableFooter(techs []techniqueInfo) string {
+	tactics := make(map[string]bool)
+	for _, t := range techs {
+		for _, tac := range t.Tactics {
+			tactics[tac] = true
+		}
+	}
+	return fmt.Sprintf("Total: %s across %s",
+		pluralize(len(techs), "technique"), pluralize(len(tactics), "tactic"))
+}
+
+// pluralize возвращает "1 technique" / "2 techniques".
+func pluralize(n int, noun string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, noun)
+	}
+	return fmt.Sprintf("%d %ss", n, noun)
+}
+
+// renderTableSections пишет секции техник одной митигации.
+func renderTableSections(w io.Writer, groups []techniqueGroup, by string, sectioned bool, layout tableLayout) {
+	for _, g := range groups {
+		fmt.Fprintln(w, "---------------------------------------------------------------")
+		if sectioned {
+			fmt.Fprintf(w, "%s\t%s\n", strings.ToUpper(by), g.Group)
+		}
+		switch layout {
+		case layoutStacked:
+			for i, t := range g.Techniques {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprintf(w, "TECHNIQUE ID:\t%s\n", t.ExternalID)
+				fmt.Fprintf(w, "TECHNIQUE NAME:\t%s\n", tableTechniqueName(t))
+				fmt.Fprintf(w, "TACTICS:\t%s\n", strings.Join(t.Tactics, ", "))
+				if *flagShowURL {
+					fmt.Fprintf(w, "URL:\t%s\n", t.URL)
+				}
+				if *flagShowGroups {
+					fmt.Fprintf(w, "GROUPS:\t%s\n", strings.Join(t.Groups, ", "))
+				}
+				if *flagShowSoftware {
+					fmt.Fprintf(w, "SOFTWARE:\t%s\n", strings.Join(t.Software, ", "))
+				}
+				if *flagShowCampaigns {
+					fmt.Fprintf(w, "CAMPAIGNS:\t%s\n", strings.Join(t.Campaigns, ", "))
+				}
+				for i, line := range wrapText(shortDescription(t.Description), descWrapWidth) {
+					label := ""
+					if i == 0 {
+						label = "DESCRIPTION:"
+					}
+					fmt.Fprintf(w, "%s\t%s\n", label, line)
+				}
+				for i, line := range wrapText(shortDescription(t.MitigationDetail), descWrapWidth) {
+					label := ""
+					if i == 0 {
+						label = "MITIGATION DETAIL:"
+					}
+					fmt.Fprintf(w, "%s\t%s\n", label, line)
+				}
+			}
+		default:
+			header := []string{"TECHNIQUE ID", "TECHNIQUE NAME"}
+			if layout == layoutFull {
+				header = append(header, "TACTICS")
 			}
-		case "attack-pattern":
-			var ap attackPattern
-			if err = json.Unmarshal(rawObj, &ap); err == nil {
-				techMap[ap.ID] = ap
+			if *flagShowURL {
+				header = append(header, "URL")
+			}
+			if *flagShowGroups {
+				header = append(header, "GROUPS")
+			}
+			if *flagShowSoftware {
+				header = append(header, "SOFTWARE")
+			}
+			if *flagShowCampaigns {
+				header = append(header, "CAMPAIGNS")
+			}
+			fmt.Fprintln(w, strings.Join(header, "\t"))
+			techs, depth := g.Techniques, make([]int, len(g.Techniques))
+			if *flagTree {
+				techs, depth = treeOrder(g.Techniques)
+			}
+			for i, t := range techs {
+				id := t.ExternalID
+				if depth[i] > 0 {
+					id = "  └ " + id
+				}
+				row := []string{id, tableTechniqueName(t)}
+				if layout == layoutFull {
+					row = append(row, strings.Join(t.Tactics, ", "))
+				}
+				if *flagShowURL {
+					row = append(row, t.URL)
+				}
+				if *flagShowGroups {
+					row = append(row, strings.Join(t.Groups, ", "))
+				}
+				if *flagShowSoftware {
+					row = append(row, strings.Join(t.Software, ", "))
+				}
+				if *flagShowCampaigns {
+					row = append(row, strings.Join(t.Campaigns, ", "))
+				}
+				fmt.Fprintln(w, strings.Join(row, "\t"))
+				writeTableDescription(w, t, len(row))
+				writeTableDetail(w, t, len(row))
 			}
-		case "relationship":
-			var r relationship
-			if err = json.Unmarshal(rawObj, &r); err == nil {
-				rels = append(rels, r)
+		}
+	}
+}
+
+// tableTechniqueName возвращает название техники для таблицы; для преемника отозванной
+// техники добавляется пометка, чтобы замена в отчёте была видна.
+func tableTechniqueName(t techniqueInfo) string {
+	if t.Replaces != "" {
+		return fmt.Sprintf("%s (replaces revoked %s)", t.Name, t.Replaces)
+	}
+	return t.Name
+}
+
+// descWrapWidth — ширина переноса описания техники в таблице.
+const descWrapWidth = 60
+
+// writeTableDescription печатает описание техники под её строкой в колонке названия.
+// cols — число колонок таблицы: если за названием есть ещё колонки, ячейка описания должна
+// быть закрыта табуляцией, иначе tabwriter разорвёт выравнивание колонок на этой строке.
+func writeTableDescription(w io.Writer, t techniqueInfo, cols int) {
+	eol := strings.Repeat("\t", cols-2) + "\n"
+	for _, line := range wrapText(shortDescription(t.Description), descWrapWidth) {
+		fmt.Fprintf(w, "\t%s%s", line, eol)
+	}
+}
+
+// writeTableDetail печатает описание связи mitigates (-show-relationship-desc) под строкой
+// техники, как writeTableDescription, с пометкой "how:" на первой строке.
+func writeTableDetail(w io.Writer, t techniqueInfo, cols int) {
+	eol := strings.Repeat("\t", cols-2) + "\n"
+	for i, line := range wrapText(shortDescription(t.MitigationDetail), descWrapWidth) {
+		prefix := "     "
+		if i == 0 {
+			prefix = "how: "
+		}
+		fmt.Fprintf(w, "\t%s%s%s", prefix, line, eol)
+	}
+}
+
+// terminalWidth возвращает ширину терминала w; ok == false, если w не терминал.
+func terminalWidth(w io.Writer) (int, bool) {
+	f, ok := w.(*os.File)
+	if !ok {
+		return 0, false
+	}
+	fd := int(f.Fd())
+	if !term.IsTerminal(fd) {
+		return 0, false
+	}
+	width, _, err := term.GetSize(fd)
+	if err != nil || width <= 0 {
+		return 0, false
+	}
+	return width, true
+}
+
+/*
+-------------------------------------------------------------
+Цвет в таблице (-color)
+-------------------------------------------------------------
+*/
+// Допустимые значения -color
+const (
+	colorAuto   = "auto"
+	colorAlways = "always"
+	colorNever  = "never"
+)
+
+func validColor(mode string) bool {
+	switch mode {
+	case colorAuto, colorAlways, colorNever:
+		return true
+	}
+	return false
+}
+
+// ANSI-последовательности для таблицы
+const (
+	ansiReset  = "\x1b[0m"
+	ansiBold   = "\x1b[1m"
+	ansiCyan   = "\x1b[36m"
+	ansiYellow = "\x1b[33m"
+)
+
+// colorEnabled решает, раскрашивать ли таблицу для w. Порядок: -color always/never (и -no-color)
+// безусловно; CLICOLOR_FORCE (непустое и не "0") включает цвет даже без терминала; NO_COLOR
+// выключает; CLICOLOR=0 выключает; иначе цвет только если w — терминал (файл -output и конвейер
+// цвета не получают).
+func colorEnabled(w io.Writer) bool {
+	switch *flagColor {
+	case colorAlways:
+		return true
+	case colorNever:
+		return false
+	}
+	if force := os.Getenv("CLICOLOR_FORCE"); force != "" && force != "0" {
+		return true
+	}
+	if _, set := os.LookupEnv("NO_COLOR"); set {
+		return false
+	}
+	if os.Getenv("CLICOLOR") == "0" {
+		return false
+	}
+	_, isTerm := terminalWidth(w)
+	return isTerm
+}
+
+// reStackedLabel — строка построчной раскладки "МЕТКА:<пробелы>значение".
+var reStackedLabel = regexp.MustCompile(`^([A-Z][A-Z ]*:)(\s+)(.*)$`)
+
+// colorizeTable раскрашивает уже выровненную таблицу: заголовки жирным, ID техник голубым,
+// тактики жёлтым. Цвет добавляется после tabwriter, поэтому escape-последовательности не
+// сбивают ширину колонок; колонка TACTICS находится по её смещению в строке заголовка.
+func colorizeTable(s string) string {
+	lines := strings.Split(s, "\n")
+	inRows := false
+	tacticStart, tacticEnd := -1, -1
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "---"), line == "":
+			inRows = false
+		case strings.HasPrefix(line, "MITIGATION"):
+			inRows = false
+			lines[i] = ansiBold + "MITIGATION" + ansiReset + strings.TrimPrefix(line, "MITIGATION")
+		case strings.HasPrefix(line, "TECHNIQUE ID") && !strings.HasPrefix(line, "TECHNIQUE ID:"):
+			inRows = true
+			tacticStart, tacticEnd = headerColumn([]rune(line), "TACTICS")
+			lines[i] = ansiBold + line + ansiReset
+		case reStackedLabel.MatchString(line):
+			m := reStackedLabel.FindStringSubmatch(line)
+			value := m[3]
+			switch m[1] {
+			case "TECHNIQUE ID:":
+				value = ansiCyan + value + ansiReset
+			case "TACTICS:":
+				value = ansiYellow + value + ansiReset
 			}
+			lines[i] = ansiBold + m[1] + ansiReset + m[2] + value
+		case inRows:
+			lines[i] = colorizeRow(line, tacticStart, tacticEnd)
 		}
 	}
+	return strings.Join(lines, "\n")
+}
 
-	/* ---------------------------------------------------------
-	   Find the mitigation requested by the user
-	   --------------------------------------------------------- */
-	var chosenMitSTIXID string // STIX ID we will match on source_ref
-	if *flagMitigation != "" {
-		// lookup by external ID (Mxxxx)
-		for id, co := range mitMap {
-			if ext, ok := externalID(co.ExternalRefs); ok && strings.EqualFold(ext, *flagMitigation) {
-				chosenMitSTIXID = id
-				break
-			}
+// headerColumn возвращает границы колонки name в строке заголовка (в рунах): от начала
+// названия до начала следующей колонки или -1, если колонка последняя. start == -1 — колонки нет.
+func headerColumn(header []rune, name string) (start, end int) {
+	start = strings.Index(string(header), name)
+	if start < 0 {
+		return -1, -1
+	}
+	start = len([]rune(string(header)[:start]))
+	end = -1
+	for j := start + len([]rune(name)); j+1 < len(header); j++ {
+		if header[j] == ' ' && header[j+1] != ' ' {
+			end = j + 1
+			break
 		}
-		if chosenMitSTIXID == "" {
-			fmt.Fprintf(os.Stderr, "mitigation %s not found in ATT&CK data\n", *flagMitigation)
-			os.Exit(1)
+	}
+	return start, end
+}
+
+// colorizeRow раскрашивает строку техники: ID в начале строки (в том числе после "└" в -tree)
+// и ячейку тактик по границам из заголовка. Строки описания (пустая первая ячейка) — без ID.
+func colorizeRow(line string, tacticStart, tacticEnd int) string {
+	r := []rune(line)
+	var b strings.Builder
+	pos := 0
+	// ID техники
+	idStart := 0
+	if strings.HasPrefix(line, "  └ ") {
+		idStart = len([]rune("  └ "))
+	}
+	if idStart < len(r) && r[idStart] != ' ' {
+		idEnd := idStart
+		for idEnd < len(r) && r[idEnd] != ' ' {
+			idEnd++
 		}
-	} else {
-		// lookup by name (case‑insensitive)
-		target := strings.TrimSpace(*flagMitigationName)
-		for id, co := range mitMap {
-			if strings.EqualFold(co.Name, target) {
-				chosenMitSTIXID = id
-				break
-			}
+		b.WriteString(string(r[:idStart]))
+		b.WriteString(ansiCyan + string(r[idStart:idEnd]) + ansiReset)
+		pos = idEnd
+	}
+	// Ячейка тактик
+	if tacticStart >= pos && tacticStart < len(r) {
+		end := len(r)
+		if tacticEnd >= 0 && tacticEnd < end {
+			end = tacticEnd
 		}
-		if chosenMitSTIXID == "" {
-			msg := fmt.Sprintf("mitigation name %q not found (check spelling)", target)
-			if suggestion := suggestMitigationName(target, mitMap, didYouMeanMaxDist); suggestion != "" {
-				msg += fmt.Sprintf(". Did you mean: %q?", suggestion)
-			}
-			fmt.Fprintln(os.Stderr, msg)
-			os.Exit(1)
+		cell := strings.TrimRight(string(r[tacticStart:end]), " ")
+		if cell != "" {
+			b.WriteString(string(r[pos:tacticStart]))
+			b.WriteString(ansiYellow + cell + ansiReset)
+			pos = tacticStart + len([]rune(cell))
 		}
 	}
+	b.WriteString(string(r[pos:]))
+	return b.String()
+}
 
-	/* ---------------------------------------------------------
-	   Collect all techniques that this mitigation mitigates (без дубликатов, детерминированный порядок)
-	   --------------------------------------------------------- */
-	var results []techniqueInfo
-	seenTechniques := make(map[string]bool)
-	for _, r := range rels {
-		if r.RelationshipType != "mitigates" {
+// tableWidth возвращает ширину самой длинной строки таблицы в рунах (разделители "----" не учитываются).
+func tableWidth(s string) int {
+	maxWidth := 0
+	for _, line := range strings.Split(s, "\n") {
+		if strings.Trim(line, "-") == "" {
 			continue
 		}
-		if r.SourceRef != chosenMitSTIXID {
-			continue
+		maxWidth = max(maxWidth, len([]rune(line)))
+	}
+	return maxWidth
+}
+
+/*
+-------------------------------------------------------------
+Nebula Graph nGQL generation
+-------------------------------------------------------------
+*/
+// quoteID экранирует идентификатор для nGQL: только буквы, цифры, '-', '_', '.'; остальное заменяется на '_'.
+func quoteID(s string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.' {
+			return r
 		}
-		if tp, ok := techMap[r.TargetRef]; ok {
-			ext, _ := externalID(tp.ExternalRefs)
-			if ext == "" {
-				ext = strings.TrimPrefix(tp.ID, "attack-pattern--")
-			}
-			if seenTechniques[ext] {
-				continue
-			}
-			seenTechniques[ext] = true
-			results = append(results, techniqueInfo{
-				ExternalID: ext,
-				Name:       tp.Name,
-				Tactics:    tacticsFromKillChain(tp.KillChainPhases),
-			})
+		return '_'
+	}, s)
+	return "`" + strings.ReplaceAll(sanitized, "`", "``") + "`"
+}
+func quoteLiteral(s string) string { return strconv.Quote(s) }
+
+// emitNGQL выводит один граф для всех митигаций отчёта: вершина на митигацию, вершина на
+// технику (общая для нескольких митигаций техника вставляется один раз) и рёбра mitigates
+// по каждой митигации.
+func emitNGQL(w io.Writer, rep mitigationReport) {
+	var b strings.Builder
+
+	// mitigation vertices
+	for _, m := range rep.mits {
+		fmt.Fprintf(&b, "INSERT VERTEX mitigation(id, name) VALUES %s:(%s, %s);\n",
+			quoteID(m.ext), quoteLiteral(m.ext), quoteLiteral(m.co.Name))
+	}
+
+	// technique vertices (tactics as comma-separated string)
+	seen := make(map[string]bool)
+	for _, t := range rep.techniques {
+		if seen[t.ExternalID] {
+			continue
 		}
+		seen[t.ExternalID] = true
+		tacticsStr := strings.Join(t.Tactics, ",")
+		fmt.Fprintf(&b, "INSERT VERTEX technique(id, name, tactics) VALUES %s:(%s, %s, %s);\n",
+			quoteID(t.ExternalID), quoteLiteral(t.ExternalID), quoteLiteral(t.Name), quoteLiteral(tacticsStr))
 	}
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].ExternalID < results[j].ExternalID
-	})
 
-	/* ---------------------------------------------------------
-	   Emit the requested output format
-	   --------------------------------------------------------- */
-	if *flagNGQL {
-		emitNGQL(chosenMitSTIXID, results, mitMap[chosenMitSTIXID])
-		return
+	// edges: mitigation -> technique, по митигациям в порядке запроса
+	for _, m := range rep.mits {
+		for _, t := range rep.techniquesOf(m.ext) {
+			fmt.Fprintf(&b, "INSERT EDGE mitigates() VALUES %s -> %s;\n",
+				quoteID(m.ext), quoteID(t.ExternalID))
+		}
 	}
-	if *flagJSON {
-		enc := json.NewEncoder(os.Stdout)
-		enc.SetIndent("", "  ")
-		_ = enc.Encode(results)
-		return
+	fmt.Fprint(w, b.String())
+}
+
+// emitTechniqueNGQL — nGQL для обратного поиска: вершина техники, вершины её митигаций
+// и рёбра mitigates (направление то же: митигация -> техника).
+func emitTechniqueNGQL(w io.Writer, tech techniqueInfo, mits []mitigationInfo) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "INSERT VERTEX technique(id, name, tactics) VALUES %s:(%s, %s, %s);\n",
+		quoteID(tech.ExternalID), quoteLiteral(tech.ExternalID), quoteLiteral(tech.Name),
+		quoteLiteral(strings.Join(tech.Tactics, ",")))
+	for _, m := range mits {
+		fmt.Fprintf(&b, "INSERT VERTEX mitigation(id, name) VALUES %s:(%s, %s);\n",
+			quoteID(m.ExternalID), quoteLiteral(m.ExternalID), quoteLiteral(m.Name))
 	}
-	if *flagCSV {
-		w := csv.NewWriter(os.Stdout)
-		_ = w.Write([]string{"Mitigation ID", "Mitigation Name", "Technique ID", "Technique Name", "Tactics"})
-		mitExt, _ := externalID(mitMap[chosenMitSTIXID].ExternalRefs)
-		for _, t := range results {
-			tacticsStr := strings.Join(t.Tactics, "; ")
-			_ = w.Write([]string{mitExt, mitMap[chosenMitSTIXID].Name, t.ExternalID, t.Name, tacticsStr})
-		}
-		w.Flush()
-		return
+	for _, m := range mits {
+		fmt.Fprintf(&b, "INSERT EDGE mitigates() VALUES %s -> %s;\n",
+			quoteID(m.ExternalID), quoteID(tech.ExternalID))
 	}
-	// default: pretty table
-	printTable(chosenMitSTIXID, mitMap[chosenMitSTIXID], results)
+	fmt.Fprint(w, b.String())
 }
 
 /*
 -------------------------------------------------------------
-Функция для вывода справки
+Graphviz DOT generation
 -------------------------------------------------------------
 */
-func printUsage() {
-	fmt.Printf(`Usage: %s -mitigation Mxxxx [options]
-Options:
-   -mitigation          ATT&CK mitigation external ID (Mxxxx)
-   -mitigation-name    Full mitigation name (case‑insensitive)
-   
-Output formats:
-   -json                Output JSON
-   -csv                 Output CSV
-   -ngql                Output Nebula Graph INSERT statements
-   
-Cache control:
-   --cache-dir DIR      Cache directory (default: MITRE_CACHE_DIR env or .mitre-cache)
-   --no-cache           Disable caching
-   --force-refresh      Force download fresh bundle ignoring cache
-   
-Debug:
-   -debug               Extra diagnostic output
-   -h                   Show this help
+// quoteDOTID экранирует идентификатор узла DOT: та же санитизация, что и в quoteID,
+// но в двойных кавычках.
+func quoteDOTID(s string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, s)
+	return `"` + sanitized + `"`
+}
 
-Environment variables:
-   MITRE_CACHE_DIR      Cache directory (overrides default)
+// escapeDOTLabel экранирует текст для строки-метки DOT: обратный слэш и кавычки экранируются,
+// переводы строк превращаются в DOT-последовательность \n, чтобы `dot` не падал на многострочных именах.
+func escapeDOTLabel(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+		"\r", `\n`,
+	).Replace(s)
+}
 
-Examples:
-   %s -mitigation M1037
-   %s -mitigation M1037 -json
-   %s -mitigation M1037 --no-cache
-   MITRE_CACHE_DIR=/cache %s -mitigation M1037 --force-refresh
-`, os.Args[0], os.Args[0], os.Args[0], os.Args[0], os.Args[0])
+// emitDOT выводит digraph: узел на митигацию, узел на технику (без повторов между митигациями)
+// и рёбра "mitigates". Метка узла — внешний ID и название.
+func emitDOT(w io.Writer, rep mitigationReport) {
+	var b strings.Builder
+	b.WriteString("digraph mitigations {\n  rankdir=LR;\n")
+	for _, m := range rep.mits {
+		fmt.Fprintf(&b, "  %s [shape=box, label=\"%s\\n%s\"];\n",
+			quoteDOTID(m.ext), escapeDOTLabel(m.ext), escapeDOTLabel(m.co.Name))
+	}
+	seen := make(map[string]bool)
+	for _, t := range rep.techniques {
+		if seen[t.ExternalID] {
+			continue
+		}
+		seen[t.ExternalID] = true
+		fmt.Fprintf(&b, "  %s [shape=ellipse, label=\"%s\\n%s\"];\n",
+			quoteDOTID(t.ExternalID), escapeDOTLabel(t.ExternalID), escapeDOTLabel(t.Name))
+	}
+	for _, t := range rep.techniques {
+		fmt.Fprintf(&b, "  %s -> %s [label=\"mitigates\"];\n",
+			quoteDOTID(t.Mitigation), quoteDOTID(t.ExternalID))
+	}
+	b.WriteString("}\n")
+	fmt.Fprint(w, b.String())
 }
 
 /*
 -------------------------------------------------------------
-Pretty‑print table (default output)
+Подмножество STIX-бандла (-stix)
 -------------------------------------------------------------
 */
-func printTable(mitSTIX string, mit courseOfAction, data []techniqueInfo) {
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+// stixBundle — обёртка бандла STIX 2.0/2.1; spec_version есть только у бандлов 2.0.
+type stixBundle struct {
+	Type        string            `json:"type"`
+	ID          string            `json:"id"`
+	SpecVersion string            `json:"spec_version,omitempty"`
+	Objects     []json.RawMessage `json:"objects"`
+}
 
-	mitExt, _ := externalID(mit.ExternalRefs)
-	fmt.Fprintf(w, "MITIGATION\t%s (%s)\n", mit.Name, mitExt)
-	fmt.Fprintln(w, "---------------------------------------------------------------")
-	fmt.Fprintln(w, "TECHNIQUE ID\tTECHNIQUE NAME\tTACTICS")
-	for _, t := range data {
-		tacticsStr := strings.Join(t.Tactics, ", ")
-		fmt.Fprintf(w, "%s\t%s\t%s\n", t.ExternalID, t.Name, tacticsStr)
+// stixBundleID возвращает детерминированный ID бандла: UUID в стиле версии 5 (SHA-1) от ID
+// входящих объектов, так что одинаковый запрос даёт одинаковый бандл.
+func stixBundleID(objectIDs []string) string {
+	sum := sha1.Sum([]byte(strings.Join(objectIDs, "\n")))
+	sum[6] = sum[6]&0x0f | 0x50
+	sum[8] = sum[8]&0x3f | 0x80
+	return fmt.Sprintf("bundle--%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// emitSTIXSubset выводит бандл только из выбранных митигаций (course-of-action), найденных техник
+// (attack-pattern) и связей mitigates между ними. Объекты копируются из raw без изменений и в
+// исходном порядке, поэтому STIX ID и все поля сохраняются.
+func emitSTIXSubset(w io.Writer, raw []byte, idx *attack.Index, rep mitigationReport) error {
+	keep := make(map[string]bool)
+	mitIDs := make(map[string]bool)
+	for _, m := range rep.mits {
+		keep[m.stixID] = true
+		mitIDs[m.stixID] = true
 	}
-	_ = w.Flush()
+	techIDs := make(map[string]bool)
+	for _, t := range rep.techniques {
+		keep[t.stixID] = true
+		techIDs[t.stixID] = true
+	}
+	for _, r := range idx.Rels {
+		if r.RelationshipType == "mitigates" && mitIDs[r.SourceRef] && techIDs[r.TargetRef] {
+			keep[r.ID] = true
+		}
+	}
+
+	var src attack.Bundle
+	if err := json.Unmarshal(raw, &src); err != nil {
+		return fmt.Errorf("parse bundle: %w", err)
+	}
+	out := stixBundle{Type: "bundle", SpecVersion: src.SpecVersion, Objects: []json.RawMessage{}}
+	var ids []string
+	for _, obj := range src.Objects {
+		var head struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(obj, &head); err != nil || !keep[head.ID] {
+			continue
+		}
+		// Дубликат STIX ID в исходном бандле попадает в вывод один раз
+		delete(keep, head.ID)
+		ids = append(ids, head.ID)
+		out.Objects = append(out.Objects, obj)
+	}
+	out.ID = stixBundleID(ids)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(out)
 }
 
 /*
 -------------------------------------------------------------
-Nebula Graph nGQL generation
+Mermaid generation
 -------------------------------------------------------------
 */
-// quoteID экранирует идентификатор для nGQL: только буквы, цифры, '-', '_', '.'; остальное заменяется на '_'.
-func quoteID(s string) string {
-	sanitized := strings.Map(func(r rune) rune {
-		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_' || r == '.' {
+// mermaidID санитизирует идентификатор узла Mermaid: как в quoteID, но допустимы только
+// буквы и цифры, остальное заменяется на '_' (T1059.001 -> T1059_001).
+func mermaidID(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
 			return r
 		}
 		return '_'
 	}, s)
-	return "`" + strings.ReplaceAll(sanitized, "`", "``") + "`"
 }
-func quoteLiteral(s string) string { return strconv.Quote(s) }
 
-func emitNGQL(mitSTIX string, techs []techniqueInfo, mit courseOfAction) {
+// escapeMermaidLabel экранирует текст метки в кавычках кодами сущностей Mermaid (#quot; и т.п.),
+// чтобы кавычки, скобки и '|' из данных не закрывали метку; переводы строк заменяются пробелом.
+func escapeMermaidLabel(s string) string {
+	return strings.NewReplacer(
+		"#", "#35;",
+		`"`, "#quot;",
+		"<", "#lt;",
+		">", "#gt;",
+		"\r\n", " ",
+		"\n", " ",
+		"\r", " ",
+	).Replace(s)
+}
+
+// emitMermaid выводит определение "graph LR": узел на митигацию, узел на технику (без повторов
+// между митигациями) и рёбра -->|mitigates|. Рендерится прямо в GitHub Markdown.
+func emitMermaid(w io.Writer, rep mitigationReport) {
 	var b strings.Builder
-	mitExt, _ := externalID(mit.ExternalRefs)
+	b.WriteString("graph LR\n")
+	for _, m := range rep.mits {
+		fmt.Fprintf(&b, "  %s[\"%s<br/>%s\"]\n",
+			mermaidID(m.ext), escapeMermaidLabel(m.ext), escapeMermaidLabel(m.co.Name))
+	}
+	seen := make(map[string]bool)
+	for _, t := range rep.techniques {
+		if seen[t.ExternalID] {
+			continue
+		}
+		seen[t.ExternalID] = true
+		fmt.Fprintf(&b, "  %s(\"%s<br/>%s\")\n",
+			mermaidID(t.ExternalID), escapeMermaidLabel(t.ExternalID), escapeMermaidLabel(t.Name))
+	}
+	for _, t := range rep.techniques {
+		fmt.Fprintf(&b, "  %s -->|mitigates| %s\n", mermaidID(t.Mitigation), mermaidID(t.ExternalID))
+	}
+	fmt.Fprint(w, b.String())
+}
+
+/*
+-------------------------------------------------------------
+JSON graph (-json-graph)
+-------------------------------------------------------------
+*/
+// jsonGraphNode — вершина митигации в -json-graph.
+type jsonGraphNode struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
 
-	// mitigation vertex
-	fmt.Fprintf(&b, "INSERT VERTEX mitigation(id, name) VALUES %s:(%s, %s);\n",
-		quoteID(mitExt), quoteLiteral(mitExt), quoteLiteral(mit.Name))
+// jsonGraphEdge — ребро mitigates между внешними ID митигации и техники.
+type jsonGraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
 
-	// technique vertices (tactics as comma-separated string)
-	for _, t := range techs {
-		tacticsStr := strings.Join(t.Tactics, ",")
-		fmt.Fprintf(&b, "INSERT VERTEX technique(id, name, tactics) VALUES %s:(%s, %s, %s);\n",
-			quoteID(t.ExternalID), quoteLiteral(t.ExternalID), quoteLiteral(t.Name), quoteLiteral(tacticsStr))
+// jsonGraph — граф одной митигации: та же структура вершин и рёбер, что строит emitNGQL, но в JSON.
+type jsonGraph struct {
+	Mitigation jsonGraphNode   `json:"mitigation"`
+	Techniques []techniqueInfo `json:"techniques"`
+	Edges      []jsonGraphEdge `json:"edges"`
+}
+
+// emitJSONGraph выводит объект графа на митигацию (массив объектов для нескольких, как в
+// emitCount). Ключи вершин — внешние ID; повторяющиеся техники и рёбра выводятся один раз.
+func emitJSONGraph(w io.Writer, rep mitigationReport) {
+	graphs := make([]jsonGraph, len(rep.mits))
+	for i, m := range rep.mits {
+		g := jsonGraph{
+			Mitigation: jsonGraphNode{ID: m.ext, Name: m.co.Name},
+			Techniques: []techniqueInfo{},
+			Edges:      []jsonGraphEdge{},
+		}
+		seen := make(map[string]bool)
+		for _, t := range rep.techniquesOf(m.ext) {
+			if seen[t.ExternalID] {
+				continue
+			}
+			seen[t.ExternalID] = true
+			g.Techniques = append(g.Techniques, t)
+			g.Edges = append(g.Edges, jsonGraphEdge{From: m.ext, To: t.ExternalID})
+		}
+		graphs[i] = g
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if len(graphs) == 1 {
+		_ = enc.Encode(graphs[0])
+	} else {
+		_ = enc.Encode(graphs)
 	}
+}
 
-	// edges: mitigation -> technique
-	for _, t := range techs {
-		fmt.Fprintf(&b, "INSERT EDGE mitigates() VALUES %s -> %s;\n",
-			quoteID(mitExt), quoteID(t.ExternalID))
+/*
+-------------------------------------------------------------
+Neo4j Cypher generation
+-------------------------------------------------------------
+*/
+// cypherLiteral возвращает строковый литерал Cypher в одинарных кавычках. Экранируются
+// обратный слэш, кавычки и управляющие символы (как \uXXXX), так что значение из данных
+// не может закрыть литерал и продолжить запрос.
+func cypherLiteral(s string) string {
+	var b strings.Builder
+	b.WriteByte('\'')
+	for _, r := range s {
+		switch r {
+		case '\\':
+			b.WriteString(`\\`)
+		case '\'':
+			b.WriteString(`\'`)
+		case '"':
+			b.WriteString(`\"`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if unicode.IsControl(r) {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('\'')
+	return b.String()
+}
+
+// cypherList возвращает список литералов Cypher: ['a', 'b'].
+func cypherList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, it := range items {
+		quoted[i] = cypherLiteral(it)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// emitCypher выводит идемпотентный импорт для Neo4j: MERGE (а не CREATE) узлов и рёбер,
+// поэтому повторный запуск импорта не создаёт дубликатов.
+func emitCypher(w io.Writer, rep mitigationReport) {
+	var b strings.Builder
+	for _, m := range rep.mits {
+		fmt.Fprintf(&b, "MERGE (m:Mitigation {id: %s}) SET m.name = %s;\n",
+			cypherLiteral(m.ext), cypherLiteral(m.co.Name))
+	}
+	seen := make(map[string]bool)
+	for _, t := range rep.techniques {
+		if seen[t.ExternalID] {
+			continue
+		}
+		seen[t.ExternalID] = true
+		fmt.Fprintf(&b, "MERGE (t:Technique {id: %s}) SET t.name = %s, t.tactics = %s;\n",
+			cypherLiteral(t.ExternalID), cypherLiteral(t.Name), cypherList(t.Tactics))
+	}
+	for _, t := range rep.techniques {
+		fmt.Fprintf(&b, "MATCH (m:Mitigation {id: %s}), (t:Technique {id: %s}) MERGE (m)-[:MITIGATES]->(t);\n",
+			cypherLiteral(t.Mitigation), cypherLiteral(t.ExternalID))
+	}
+	fmt.Fprint(w, b.String())
+}
+
+/*
+-------------------------------------------------------------
+HTTP-сервер (-serve)
+-------------------------------------------------------------
+*/
+
+// bundleServer отдаёт результаты запросов по индексу, загруженному при старте; индекс
+// обновляется в фоне, когда устаревает кэш бандла.
+type bundleServer struct {
+	filter techniqueFilter
+
+	mu  sync.RWMutex
+	idx *attack.Index
+}
+
+// reload загружает бандл (через кэш fetchBundle) и заменяет индекс; при ошибке старый
+// индекс остаётся в работе.
+func (s *bundleServer) reload() error {
+	src, err := fetchBundle()
+	if err != nil {
+		return fmt.Errorf("fetch bundle: %w", err)
+	}
+	idx, _, err := loadIndex(src)
+	if cerr := src.Close(); err == nil && cerr != nil {
+		return fmt.Errorf("fetch bundle: %w", cerr)
+	}
+	if err != nil {
+		return fmt.Errorf("parse bundle: %w", err)
+	}
+	s.mu.Lock()
+	s.idx = idx
+	s.mu.Unlock()
+	return nil
+}
+
+// handleMitigation обслуживает GET /mitigation/{id}: тот же JSON, что и -mitigation ID -json.
+func (s *bundleServer) handleMitigation(w http.ResponseWriter, r *http.Request) {
+	// Голый номер дополняется префиксом M, как в -mitigation
+	id := attack.NormalizeMitigationID(r.PathValue("id"))
+	if !attack.ValidMitigationID(id) {
+		http.Error(w, fmt.Sprintf("malformed mitigation ID %q (want Mxxxx)", id), http.StatusBadRequest)
+		return
+	}
+	s.mu.RLock()
+	idx := s.idx
+	s.mu.RUnlock()
+
+	// Тот же выбор, что и в CLI: -strict-id и вывод кандидатов под -debug
+	stixID := mitigationByExtID(idx, id)
+	if stixID == "" {
+		http.Error(w, fmt.Sprintf("mitigation %s not found in ATT&CK data", id), http.StatusNotFound)
+		return
+	}
+	results := techniquesForMitigation(idx, stixID, s.filter)
+	sortTechniques(results, *flagSortBy)
+	rep := mitigationReport{
+		mits:          []resolvedMitigation{newResolvedMitigation(idx.Mitigations[stixID])},
+		techniques:    results,
+		groupBy:       *flagGroupBy,
+		attackVersion: idx.AttackVersion,
+	}
+	if *flagGroupBy != groupByNone {
+		rep.groups = groupTechniques(results, *flagGroupBy)
+	}
+	var buf bytes.Buffer
+	emitJSON(&buf, rep)
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(buf.Bytes())
+}
+
+// minServerRefresh — наименьшая пауза между фоновыми обновлениями: если загрузка не удалась
+// и кэш остался устаревшим, сервер не повторяет её непрерывно.
+const minServerRefresh = time.Minute
+
+// nextServerRefresh возвращает паузу до фонового обновления: до момента, когда кэш бандла
+// станет старше cacheTTL (отсчёт от mtime файла, а не от запуска сервера); без кэша — cacheTTL.
+func nextServerRefresh() time.Duration {
+	if dir := getCacheDir(); dir != "/dev/null" {
+		if info, err := os.Stat(cachedBundlePath(dir)); err == nil {
+			return max(time.Until(info.ModTime().Add(cacheTTL)), minServerRefresh)
+		}
+	}
+	return cacheTTL
+}
+
+// runServer загружает бандл и обслуживает HTTP-запросы на addr; возвращает код выхода.
+func runServer(addr string, filter techniqueFilter) int {
+	s := &bundleServer{filter: filter}
+	if err := s.reload(); err != nil {
+		fmt.Fprintf(os.Stderr, "error loading ATT&CK bundle: %v\n", err)
+		return 1
+	}
+	// Локальный файл и встроенный снимок не меняются — обновлять нечего
+	if *flagBundleFile == "" && !*flagEmbedded {
+		go func() {
+			for {
+				delay := nextServerRefresh()
+				if *flagDbg {
+					fmt.Fprintf(os.Stderr, ">>> next bundle refresh in %s\n", delay.Round(time.Second))
+				}
+				time.Sleep(delay)
+				if err := s.reload(); err != nil {
+					warnf("background bundle refresh failed: %v", err)
+				}
+			}
+		}()
+	} else if *flagDbg {
+		fmt.Fprintln(os.Stderr, ">>> bundle source is static – background refresh disabled")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /mitigation/{id}", s.handleMitigation)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if !*flagQuiet {
+		fmt.Fprintf(os.Stderr, "serving ATT&CK mitigations on %s\n", addr)
 	}
-	fmt.Print(b.String())
-}
\ No newline at end of file
+	if err := srv.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,54 @@
+// Тесты отката на устаревший кэш при недоступном источнике (-allow-stale).
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// staleFixture возвращает окружение с кэшем-фикстурой старше TTL.
+func staleFixture(t *testing.T) map[string]string {
+	t.Helper()
+	env := fixtureCacheDir(t)
+	oldTime := time.Now().Add(-(cacheTTL + 2*time.Hour))
+	if err := os.Chtimes(filepath.Join(env[envMITRECacheDir], cacheFilename), oldTime, oldTime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	return env
+}
+
+// unreachable — аргументы, при которых загрузка сразу падает: прокси на закрытом порту, без повторов.
+var unreachable = []string{"-proxy", "http://127.0.0.1:1", "-retries", "0"}
+
+func TestAllowStale_FallsBackToExpiredCache(t *testing.T) {
+	bin := getBinary(t)
+	args := append(append([]string{}, unreachable...), "-allow-stale", "-mitigation", "M1037")
+	stdout, stderr := runMitremit(t, bin, staleFixture(t), args...)
+	if !strings.Contains(stdout, "T1071") {
+		t.Errorf("expected techniques from the stale cache; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if !strings.Contains(stderr, "using STALE cache") || !strings.Contains(stderr, "age 26h0m0s") {
+		t.Errorf("expected a stale-cache warning with the cache age; stderr:\n%s", stderr)
+	}
+}
+
+func TestAllowStale_WithoutFlagDownloadErrorIsFatal(t *testing.T) {
+	bin := getBinary(t)
+	args := append(append([]string{}, unreachable...), "-mitigation", "M1037")
+	stdout, stderr := runMitremit(t, bin, staleFixture(t), args...)
+	if stdout != "" || !strings.Contains(stderr, "error fetching ATT&CK bundle") {
+		t.Errorf("expected a fetch error without -allow-stale; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+}
+
+func TestAllowStale_QuietSuppressesWarning(t *testing.T) {
+	bin := getBinary(t)
+	args := append(append([]string{}, unreachable...), "-allow-stale", "-quiet", "-mitigation", "M1037")
+	stdout, stderr := runMitremit(t, bin, staleFixture(t), args...)
+	if !strings.Contains(stdout, "T1071") || strings.Contains(stderr, "STALE") {
+		t.Errorf("-quiet must keep the result and drop the stale-cache warning; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+}
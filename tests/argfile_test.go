@@ -0,0 +1,34 @@
+// Тесты чтения флагов из файла (@argfile).
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestArgFile_FlagsSplicedFromFile(t *testing.T) {
+	bin := getBinary(t)
+	argFile := filepath.Join(t.TempDir(), "flags.txt")
+	// Флаги разделены и пробелами, и переводами строк
+	if err := os.WriteFile(argFile, []byte("-debug\n-h  \n"), 0o644); err != nil {
+		t.Fatalf("write arg file: %v", err)
+	}
+	stdout, stderr := runMitremit(t, bin, nil, "@"+argFile)
+	if !strings.Contains(stdout, "Usage:") {
+		t.Errorf("expected -h from arg file to print usage; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if strings.Contains(stderr, "must specify -mitigation") {
+		t.Errorf("flags from arg file were not applied; stderr:\n%s", stderr)
+	}
+}
+
+func TestArgFile_MissingFileIsError(t *testing.T) {
+	bin := getBinary(t)
+	missing := filepath.Join(t.TempDir(), "no-such-file")
+	_, stderr := runMitremit(t, bin, nil, "@"+missing)
+	if !strings.Contains(stderr, "error reading argument file") {
+		t.Errorf("expected error about missing arg file; stderr:\n%s", stderr)
+	}
+}
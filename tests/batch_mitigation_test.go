@@ -0,0 +1,92 @@
+// Тесты пакетного запроса (-mitigation со списком через запятую) на локальном бандле из testdata.
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBatchMitigation_JSONTagsEachTechnique(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040,M1037", "-json")
+	var techs []struct {
+		ExternalID string `json:"external_id"`
+		Mitigation string `json:"mitigation"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil {
+		t.Fatalf("invalid JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	perMitigation := make(map[string][]string)
+	var order []string
+	for _, tech := range techs {
+		if _, ok := perMitigation[tech.Mitigation]; !ok {
+			order = append(order, tech.Mitigation)
+		}
+		perMitigation[tech.Mitigation] = append(perMitigation[tech.Mitigation], tech.ExternalID)
+	}
+	// Митигации идут в порядке запроса; T1090 и T1565 есть у обеих
+	if strings.Join(order, ",") != "M1040,M1037" {
+		t.Errorf("mitigation order = %v, want [M1040 M1037]", order)
+	}
+	if got := strings.Join(perMitigation["M1040"], ","); got != "T1090,T1565" {
+		t.Errorf("M1040 techniques = %s, want T1090,T1565", got)
+	}
+	for _, want := range []string{"T1071", "T1090", "T1565"} {
+		if !strings.Contains(","+strings.Join(perMitigation["M1037"], ",")+",", ","+want+",") {
+			t.Errorf("M1037 techniques %v lack %s", perMitigation["M1037"], want)
+		}
+	}
+}
+
+func TestBatchMitigation_TableBlockPerMitigation(t *testing.T) {
+	bin := getBinary(t)
+	// Повтор ID в другом регистре не даёт второго блока
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040,M1037,m1040")
+	blocks := regexp.MustCompile(`(?m)^MITIGATION\s+(.+)$`).FindAllStringSubmatch(stdout, -1)
+	var got []string
+	for _, b := range blocks {
+		got = append(got, b[1])
+	}
+	want := "Behavior Prevention on Endpoint (M1040)|Filter Network Traffic (M1037)"
+	if strings.Join(got, "|") != want {
+		t.Errorf("table blocks = %q, want %q\nstdout:\n%s\nstderr:\n%s", got, want, stdout, stderr)
+	}
+}
+
+func TestBatchMitigation_UnknownIDWarnsButSucceeds(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040,M9999", "-csv")
+	if !strings.Contains(stderr, "mitigation M9999 not found") {
+		t.Errorf("expected not-found notice for M9999; stderr:\n%s", stderr)
+	}
+	if got := strings.Join(csvColumn(t, stdout, "Mitigation ID"), ","); got != "M1040,M1040" {
+		t.Errorf("Mitigation ID column = %s, want M1040,M1040", got)
+	}
+}
+
+func TestBatchMitigation_ExitCodeOnlyWhenNoneFound(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	exitCode := func(ids string) int {
+		cmd := exec.Command(bin, "-mitigation", ids)
+		cmd.Env = append(os.Environ(), envMITRECacheDir+"="+env[envMITRECacheDir])
+		var exitErr *exec.ExitError
+		if err := cmd.Run(); errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		} else if err != nil {
+			t.Fatalf("run mitremit: %v", err)
+		}
+		return 0
+	}
+	if code := exitCode("M1040,M9999"); code != 0 {
+		t.Errorf("one known ID: exit code = %d, want 0", code)
+	}
+	if code := exitCode("M9998,M9999"); code != 1 {
+		t.Errorf("no known IDs: exit code = %d, want 1", code)
+	}
+}
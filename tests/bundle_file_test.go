@@ -0,0 +1,67 @@
+// Тесты чтения бандла из локального файла (-bundle-file) без сети и кэша.
+package tests
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBundleFile_ReadsLocalBundle(t *testing.T) {
+	bin := getBinary(t)
+	bundle := filepath.Join(repoRoot(t), "tests", "testdata", "enterprise-attack.json")
+	stdout, stderr := runMitremit(t, bin, nil, "-bundle-file", bundle, "-no-cache", "-mitigation", "M1040")
+	if !strings.Contains(stdout, "T1565") {
+		t.Errorf("expected techniques from local bundle; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+}
+
+func TestBundleFile_RejectsNonBundleJSON(t *testing.T) {
+	bin := getBinary(t)
+	path := filepath.Join(t.TempDir(), "not-a-bundle.json")
+	if err := os.WriteFile(path, []byte(`{"type": "identity"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr := runMitremit(t, bin, nil, "-bundle-file", path, "-mitigation", "M1037")
+	if !strings.Contains(stderr, "is not a STIX bundle") {
+		t.Errorf("expected clear error for non-bundle file; stderr:\n%s", stderr)
+	}
+}
+
+func TestBundleFile_DashReadsStdin(t *testing.T) {
+	bin := getBinary(t)
+	data, err := os.ReadFile(filepath.Join(repoRoot(t), "tests", "testdata", "enterprise-attack.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Пустой каталог кэша: бандл должен прийти только из stdin
+	cmd := exec.Command(bin, "-bundle-file", "-", "-mitigation", "M1040")
+	cmd.Env = append(os.Environ(), envMITRECacheDir+"="+t.TempDir())
+	cmd.Stdin = bytes.NewReader(data)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		t.Fatalf("run: %v\nstderr:\n%s", err, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "T1565") {
+		t.Errorf("expected techniques from stdin bundle; stdout:\n%s\nstderr:\n%s", stdout.String(), stderr.String())
+	}
+}
+
+func TestBundleFile_DashRejectsInvalidStdin(t *testing.T) {
+	bin := getBinary(t)
+	cmd := exec.Command(bin, "-bundle-file", "-", "-mitigation", "M1037")
+	cmd.Stdin = strings.NewReader("{")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err == nil {
+		t.Fatal("expected non-zero exit for invalid JSON on stdin")
+	}
+	if !strings.Contains(stderr.String(), "error parsing bundle JSON") {
+		t.Errorf("expected a bundle parse error; stderr:\n%s", stderr.String())
+	}
+}
@@ -0,0 +1,56 @@
+// Тесты загрузки бандла с зеркала (-bundle-url).
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestBundleURL_DownloadsFromMirrorIntoHashedCache(t *testing.T) {
+	bin := getBinary(t)
+	data, err := os.ReadFile(filepath.Join(repoRoot(t), "tests", "testdata", cacheFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	env := map[string]string{envMITRECacheDir: dir}
+	stdout, stderr := runMitremit(t, bin, env, "-bundle-url", srv.URL+"/mirror/enterprise-attack.json",
+		"-mitigation", "M1037", "-count", "-retries", "0")
+	if strings.TrimSpace(stdout) != "5" || hits.Load() != 1 {
+		t.Fatalf("expected one download from the mirror; hits=%d stdout:\n%s\nstderr:\n%s", hits.Load(), stdout, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(dir, cacheFilename+".gz")); err == nil {
+		t.Errorf("mirror bundle must not be cached under the default name")
+	}
+	matches, _ := filepath.Glob(filepath.Join(dir, "enterprise-attack-*.json.gz"))
+	if len(matches) != 1 {
+		t.Fatalf("expected one cache file with a URL hash, got %v", matches)
+	}
+
+	// Другое зеркало — другой файл кэша, значит, новая загрузка
+	runMitremit(t, bin, env, "-bundle-url", srv.URL+"/other/enterprise-attack.json",
+		"-mitigation", "M1037", "-count", "-retries", "0")
+	if hits.Load() != 2 {
+		t.Errorf("switching mirrors reused the cache; hits=%d", hits.Load())
+	}
+}
+
+func TestBundleURL_RejectsNonHTTPScheme(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-bundle-url", "file:///etc/passwd", "-mitigation", "M1037")
+	if !strings.Contains(stderr, "invalid -bundle-url") {
+		t.Errorf("expected error about non-http(s) URL; stderr:\n%s", stderr)
+	}
+}
@@ -100,17 +100,21 @@ func TestMITRECacheDir_AbsolutePathAccepted(t *testing.T) {
 	stdout, stderr := runMitremit(t, bin, map[string]string{envMITRECacheDir: absDir},
 		"-debug", "-mitigation", "M1037")
 
-	// Должны увидеть использование заданной абсолютной директории (нормализованной)
+	// Должны увидеть использование заданной абсолютной директории (нормализованной);
+	// отладочные строки идут в stderr
 	expectDir := filepath.Clean(absDir)
-	if !strings.Contains(stdout, cacheDirLine) {
-		t.Fatalf("expected stdout to contain %q; stdout:\n%s\nstderr:\n%s", cacheDirLine, stdout, stderr)
+	if !strings.Contains(stderr, cacheDirLine) {
+		t.Fatalf("expected stderr to contain %q; stderr:\n%s\nstdout:\n%s", cacheDirLine, stderr, stdout)
 	}
-	if !strings.Contains(stdout, expectDir) {
-		t.Errorf("expected stdout to contain cache directory %q; stdout:\n%s", expectDir, stdout)
+	if !strings.Contains(stderr, cacheDirLine+" "+expectDir) {
+		t.Errorf("expected stderr to contain cache directory %q; stderr:\n%s", expectDir, stderr)
 	}
 	if strings.Contains(stderr, warningPrefix) {
 		t.Errorf("absolute path must not trigger WARNING; stderr:\n%s", stderr)
 	}
+	if strings.Contains(stdout, ">>>") {
+		t.Errorf("debug output must not go to stdout; stdout:\n%s", stdout)
+	}
 }
 
 func TestMITRECacheDir_RelativePathIgnoredWithWarning(t *testing.T) {
@@ -127,12 +131,12 @@ func TestMITRECacheDir_RelativePathIgnoredWithWarning(t *testing.T) {
 		t.Errorf("expected stderr to show ignored value; stderr:\n%s", stderr)
 	}
 	// Должна использоваться fallback-директория (не относительный путь атакующего)
-	if !strings.Contains(stdout, cacheDirLine) {
-		t.Fatalf("expected stdout to contain %q; stdout:\n%s", cacheDirLine, stdout)
+	if !strings.Contains(stderr, cacheDirLine) {
+		t.Fatalf("expected stderr to contain %q; stderr:\n%s", cacheDirLine, stderr)
 	}
 	// Fallback: .mitre-cache или /tmp/.mitre-cache в контейнере
-	if strings.Contains(stdout, ">>> cache directory: ../../tmp/evil") {
-		t.Errorf("relative path must not be used as cache directory; stdout:\n%s", stdout)
+	if strings.Contains(stderr, cacheDirLine+" ../../tmp/evil") {
+		t.Errorf("relative path must not be used as cache directory; stderr:\n%s", stderr)
 	}
 }
 
@@ -142,13 +146,13 @@ func TestMITRECacheDir_CleanedAbsolutePath(t *testing.T) {
 	tmpBase := t.TempDir()
 	absWithDots := filepath.Join(tmpBase, "a", "..", "b") // в итоге <tmp>/b
 	cleaned := filepath.Clean(absWithDots)
-	stdout, _ := runMitremit(t, bin, map[string]string{envMITRECacheDir: absWithDots},
+	_, stderr := runMitremit(t, bin, map[string]string{envMITRECacheDir: absWithDots},
 		"-debug", "-mitigation", "M1037")
 
-	if !strings.Contains(stdout, cacheDirLine) {
-		t.Fatalf("expected stdout to contain %q; stdout:\n%s", cacheDirLine, stdout)
+	if !strings.Contains(stderr, cacheDirLine) {
+		t.Fatalf("expected stderr to contain %q; stderr:\n%s", cacheDirLine, stderr)
 	}
-	if !strings.Contains(stdout, cleaned) {
-		t.Errorf("expected cleaned absolute path %q in stdout; got:\n%s", cleaned, stdout)
+	if !strings.Contains(stderr, cacheDirLine+" "+cleaned) {
+		t.Errorf("expected cleaned absolute path %q in stderr; got:\n%s", cleaned, stderr)
 	}
 }
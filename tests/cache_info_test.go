@@ -0,0 +1,29 @@
+// Тесты метаданных кэша (-cache-info).
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCacheInfo_ReportsMetadata(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	oldTime := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(filepath.Join(env[envMITRECacheDir], cacheFilename), oldTime, oldTime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	stdout, stderr := runMitremit(t, bin, env, "-cache-info", "-debug")
+	for _, want := range []string{"cache dir:", "bundle file:", "size:", "modified:", "age:            2h0m0s",
+		"ttl remaining:  22h0m0s", "spec_version:   2.0"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q; stdout:\n%s\nstderr:\n%s", want, stdout, stderr)
+		}
+	}
+	if strings.Contains(stderr, "downloading") {
+		t.Errorf("-cache-info must not touch the network; stderr:\n%s", stderr)
+	}
+}
@@ -2,6 +2,9 @@
 package tests
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
@@ -24,14 +27,14 @@ func TestCacheTTL_FreshCacheUsed(t *testing.T) {
 		t.Fatalf("write cache file: %v", err)
 	}
 	// Модификация только что — кэш считается свежим
-	stdout, _ := runMitremit(t, bin, map[string]string{envMITRECacheDir: cacheDir},
+	_, stderr := runMitremit(t, bin, map[string]string{envMITRECacheDir: cacheDir},
 		"-debug", "-mitigation", "M1037")
 
-	if !strings.Contains(stdout, "cached bundle found") {
-		t.Errorf("expected stdout to contain 'cached bundle found' when cache is fresh; stdout:\n%s", stdout)
+	if !strings.Contains(stderr, "cached bundle found") {
+		t.Errorf("expected stderr to contain 'cached bundle found' when cache is fresh; stderr:\n%s", stderr)
 	}
-	if strings.Contains(stdout, "cache expired or missing") {
-		t.Errorf("fresh cache must not be treated as expired; stdout:\n%s", stdout)
+	if strings.Contains(stderr, "cache expired or missing") {
+		t.Errorf("fresh cache must not be treated as expired; stderr:\n%s", stderr)
 	}
 }
 
@@ -47,14 +50,14 @@ func TestCacheTTL_ExpiredCacheIgnored(t *testing.T) {
 	if err := os.Chtimes(bundlePath, oldTime, oldTime); err != nil {
 		t.Fatalf("chtimes: %v", err)
 	}
-	stdout, _ := runMitremit(t, bin, map[string]string{envMITRECacheDir: cacheDir},
+	_, stderr := runMitremit(t, bin, map[string]string{envMITRECacheDir: cacheDir},
 		"-debug", "-mitigation", "M1037")
 
-	if !strings.Contains(stdout, "cache expired or missing") {
-		t.Errorf("expected stdout to contain 'cache expired or missing' when cache is older than TTL; stdout:\n%s", stdout)
+	if !strings.Contains(stderr, "cache expired or missing") {
+		t.Errorf("expected stderr to contain 'cache expired or missing' when cache is older than TTL; stderr:\n%s", stderr)
 	}
-	if strings.Contains(stdout, "cached bundle found") {
-		t.Errorf("expired cache must not be used; stdout:\n%s", stdout)
+	if strings.Contains(stderr, "cached bundle found") {
+		t.Errorf("expired cache must not be used; stderr:\n%s", stderr)
 	}
 }
 
@@ -66,17 +69,85 @@ func TestCacheFilePermissions(t *testing.T) {
 		t.Skip("file mode check meaningful only on Unix")
 	}
 	bin := getBinary(t)
+	data := fixtureBundle(t)
+	env := fakeUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write(data)
+	}))
 	cacheDir := t.TempDir()
-	// Пустой кэш — программа скачает бандл и запишет файл
-	runMitremit(t, bin, map[string]string{envMITRECacheDir: cacheDir},
-		"-mitigation", "M1037")
-	bundlePath := filepath.Join(cacheDir, cacheFilename)
-	info, err := os.Stat(bundlePath)
+	env[envMITRECacheDir] = cacheDir
+	// Пустой кэш — программа скачает бандл с подменного upstream и запишет файлы кэша
+	if stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1037"); stdout == "" {
+		t.Fatalf("no output; stderr:\n%s", stderr)
+	}
+	// Кэш хранится сжатым gzip, рядом — валидаторы ответа
+	bundlePath := filepath.Join(cacheDir, cacheFilename+".gz")
+	for _, path := range []string{bundlePath, bundlePath + ".meta"} {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("cache file not found or unreadable: %v", err)
+		}
+		perm := info.Mode().Perm()
+		if perm != expectedCacheFileMode {
+			t.Errorf("%s permissions: got %o, want %o (owner-only for multi-user safety)", filepath.Base(path), perm, expectedCacheFileMode)
+		}
+	}
+}
+
+func TestCacheGzip_UncompressedCacheMigrated(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	cacheDir := env[envMITRECacheDir]
+	stdout, stderr := runMitremit(t, bin, env, "-debug", "-mitigation", "M1040")
+	if !strings.Contains(stderr, "cached bundle found") || !strings.Contains(stdout, "T1565") {
+		t.Errorf("expected migrated cache to be used; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if _, err := os.Stat(filepath.Join(cacheDir, cacheFilename)); !os.IsNotExist(err) {
+		t.Errorf("uncompressed cache file must be removed after migration (stat err: %v)", err)
+	}
+	info, err := os.Stat(filepath.Join(cacheDir, cacheFilename+".gz"))
 	if err != nil {
-		t.Fatalf("cache file not found or unreadable: %v", err)
+		t.Fatalf("gzip cache file not created: %v", err)
+	}
+	if perm := info.Mode().Perm(); perm != expectedCacheFileMode {
+		t.Errorf("migrated cache permissions: got %o, want %o", perm, expectedCacheFileMode)
+	}
+}
+
+func TestCacheChecksum_MismatchDiscardsCache(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	cacheDir := env[envMITRECacheDir]
+	data := fixtureBundle(t)
+	for k, v := range fakeUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	})) {
+		env[k] = v
+	}
+	// Первый запуск переносит кэш в gzip и пишет файл-спутник с SHA-256
+	if stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040"); !strings.Contains(stdout, "T1565") {
+		t.Fatalf("warm-up run failed; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	sumPath := filepath.Join(cacheDir, cacheFilename+".gz.sha256")
+	if _, err := os.Stat(sumPath); err != nil {
+		t.Fatalf("checksum sidecar not written: %v", err)
+	}
+	if err := os.WriteFile(sumPath, []byte("deadbeef\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr := runMitremit(t, bin, env, "-debug", "-mitigation", "M1040")
+	if !strings.Contains(stderr, "cache checksum mismatch") {
+		t.Errorf("expected checksum mismatch to be logged under -debug; stderr:\n%s", stderr)
+	}
+	if strings.Contains(stderr, "cached bundle found") {
+		t.Errorf("corrupted cache must not be used; stderr:\n%s", stderr)
+	}
+	// Скачанный заново бандл сохраняется с верной суммой, посчитанной по ходу загрузки
+	sum, err := os.ReadFile(sumPath)
+	if want := sha256.Sum256(data); err != nil || strings.TrimSpace(string(sum)) != hex.EncodeToString(want[:]) {
+		t.Errorf("checksum sidecar after re-download = %q (%v), want sha256 of the bundle", sum, err)
 	}
-	perm := info.Mode().Perm()
-	if perm != expectedCacheFileMode {
-		t.Errorf("cache file permissions: got %o, want %o (owner-only for multi-user safety)", perm, expectedCacheFileMode)
+	if _, stderr := runMitremit(t, bin, env, "-debug", "-mitigation", "M1040"); !strings.Contains(stderr, "cached bundle found") {
+		t.Errorf("re-downloaded cache must pass the checksum; stderr:\n%s", stderr)
 	}
 }
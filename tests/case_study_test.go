@@ -0,0 +1,128 @@
+// Тесты режима -case-study на локальном бандле из testdata.
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type caseStudyJSON struct {
+	Group struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"group"`
+	Techniques []struct {
+		ExternalID  string   `json:"external_id"`
+		Mitigations []string `json:"mitigations"`
+		Covered     bool     `json:"covered"`
+	} `json:"techniques"`
+}
+
+func TestCaseStudy_JSONJoinsUsesWithMitigations(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	// В бандле два объекта с G0016: действующий и более новый отозванный — выбор не должен
+	// зависеть от порядка обхода map, поэтому запускаем несколько раз
+	for range 3 {
+		stdout, stderr := runMitremit(t, bin, env, "-case-study", "-group", "g0016", "-json")
+		var report caseStudyJSON
+		if err := json.Unmarshal([]byte(stdout), &report); err != nil {
+			t.Fatalf("output is not JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+		}
+		if report.Group.ID != "G0016" || report.Group.Name != "APT29" {
+			t.Fatalf("group = %+v, want the current (non-revoked) APT29 (G0016)", report.Group)
+		}
+		var rows []string
+		for _, tech := range report.Techniques {
+			cov := "uncovered"
+			if tech.Covered {
+				cov = strings.Join(tech.Mitigations, "+")
+			}
+			rows = append(rows, tech.ExternalID+":"+cov)
+		}
+		want := "T1071.001:M1037,T1090:M1037+M1040,T1190:uncovered"
+		if got := strings.Join(rows, ","); got != want {
+			t.Fatalf("case study rows = %s, want %s", got, want)
+		}
+	}
+}
+
+func TestCaseStudy_TableHighlightsUncovered(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-case-study", "-group", "G0016")
+	if !strings.Contains(stdout, "GROUP  APT29 (G0016)") {
+		t.Errorf("missing group header; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if !strings.Contains(stdout, "!! UNCOVERED") || !strings.Contains(stdout, "Uncovered: 1 of 3 techniques") {
+		t.Errorf("uncovered technique must be highlighted and counted; stdout:\n%s", stdout)
+	}
+}
+
+func TestCaseStudy_CSV(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-case-study", "-group", "G0016", "-csv")
+	if got := strings.Join(csvColumn(t, stdout, "Mitigations"), "|"); got != "M1037|M1037; M1040|" {
+		t.Errorf("Mitigations column = %q", got)
+	}
+}
+
+func TestCaseStudy_TSV(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-case-study", "-group", "G0016", "-tsv")
+	lines := strings.Split(strings.TrimRight(stdout, "\n"), "\n")
+	if len(lines) != 4 || lines[0] != "Group ID\tGroup Name\tTechnique ID\tTechnique Name\tUsed By Group\tMitigations\tCovered" {
+		t.Fatalf("expected a TSV header and 3 rows; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if want := "G0016\tAPT29\tT1090\tProxy, \"Relay\" | Hop\ttrue\tM1037; M1040\ttrue"; lines[2] != want {
+		t.Errorf("row = %q, want %q", lines[2], want)
+	}
+}
+
+func TestCaseStudy_Markdown(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-case-study", "-group", "G0016", "-markdown")
+	if !strings.HasPrefix(stdout, "## G0016 — APT29\n\n| Technique ID | Technique Name | Mitigations |\n| --- | --- | --- |\n") {
+		t.Fatalf("expected group heading and table header; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if !strings.Contains(stdout, "| T1090 | Proxy, \"Relay\" \\| Hop | M1037, M1040 |") {
+		t.Errorf("covered row missing or pipe not escaped:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "**UNCOVERED** |") || !strings.Contains(stdout, "Uncovered: 1 of 3 techniques") {
+		t.Errorf("uncovered technique must be highlighted and counted:\n%s", stdout)
+	}
+}
+
+func TestCaseStudy_JSONLOneRowPerLine(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-case-study", "-group", "G0016", "-jsonl")
+	var ids []string
+	for _, line := range strings.Split(strings.TrimRight(stdout, "\n"), "\n") {
+		var row struct {
+			ExternalID string `json:"external_id"`
+		}
+		if err := json.Unmarshal([]byte(line), &row); err != nil {
+			t.Fatalf("line is not a JSON object (%v): %q\nstderr:\n%s", err, line, stderr)
+		}
+		ids = append(ids, row.ExternalID)
+	}
+	if got := strings.Join(ids, ","); got != "T1071.001,T1090,T1190" {
+		t.Errorf("JSONL rows = %s", got)
+	}
+}
+
+func TestCaseStudy_UnknownGroupIsError(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-case-study", "-group", "G9999")
+	if !strings.Contains(stderr, "group G9999 not found") {
+		t.Errorf("expected not-found error; stderr:\n%s", stderr)
+	}
+}
+
+func TestCaseStudy_RequiresGroup(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-case-study")
+	if !strings.Contains(stderr, "-case-study requires -group") {
+		t.Errorf("expected error about missing -group; stderr:\n%s", stderr)
+	}
+}
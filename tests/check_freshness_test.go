@@ -0,0 +1,62 @@
+// Тесты проверки актуальности кэша (-check-freshness).
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCheckFreshness_ETag(t *testing.T) {
+	bin := getBinary(t)
+	data, err := os.ReadFile(filepath.Join(repoRoot(t), "tests", "testdata", cacheFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var etag atomic.Value
+	etag.Store(`"v1"`)
+	var gets atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cur := etag.Load().(string)
+		w.Header().Set("ETag", cur)
+		if r.Header.Get("If-None-Match") == cur {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		if r.Method == http.MethodGet {
+			gets.Add(1)
+		}
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+
+	env := map[string]string{envMITRECacheDir: t.TempDir()}
+	url := srv.URL + "/enterprise-attack.json"
+	check := func() (string, int) {
+		t.Helper()
+		return runExitCodeEnv(t, bin, env, "-bundle-url", url, "-check-freshness", "-retries", "0")
+	}
+
+	// Кэша нет — обновление доступно
+	if out, code := check(); code != 3 || !strings.Contains(out, "update available") {
+		t.Fatalf("empty cache: code=%d out=%q, want 3 and 'update available'", code, out)
+	}
+	runMitremit(t, bin, env, "-bundle-url", url, "-mitigation", "M1037", "-count", "-retries", "0")
+	if gets.Load() != 1 {
+		t.Fatalf("expected one bundle download, got %d", gets.Load())
+	}
+	if out, code := check(); code != 0 || !strings.Contains(out, "cache is up to date") {
+		t.Errorf("same ETag: code=%d out=%q, want 0 and 'cache is up to date'", code, out)
+	}
+	etag.Store(`"v2"`)
+	if out, code := check(); code != 3 || !strings.Contains(out, "update available") {
+		t.Errorf("new ETag: code=%d out=%q, want 3 and 'update available'", code, out)
+	}
+	if gets.Load() != 1 {
+		t.Errorf("-check-freshness must not download the bundle; GETs=%d", gets.Load())
+	}
+}
@@ -0,0 +1,49 @@
+// Тесты очистки кэша (-clear-cache).
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClearCache_RemovesBundleAndSidecars(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	dir := env[envMITRECacheDir]
+	// Первый запуск переносит кэш в .json.gz и пишет .sha256
+	runMitremit(t, bin, env, "-mitigation", "M1037")
+	stdout, stderr := runMitremit(t, bin, env, "-clear-cache")
+	if !strings.Contains(stdout, "removed "+filepath.Join(dir, "enterprise-attack.json.gz")) {
+		t.Errorf("expected removed bundle in output; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("cache dir not empty after -clear-cache: %v", entries)
+	}
+}
+
+func TestClearCache_RefusesForeignDirectory(t *testing.T) {
+	bin := getBinary(t)
+	dir := t.TempDir()
+	bundle := filepath.Join(dir, "enterprise-attack.json")
+	foreign := filepath.Join(dir, "notes.txt")
+	for _, p := range []string{bundle, foreign} {
+		if err := os.WriteFile(p, []byte(minimalBundleJSON), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, stderr := runMitremit(t, bin, nil, "-cache-dir", dir, "-clear-cache")
+	if !strings.Contains(stderr, "not a mitremit cache directory") {
+		t.Errorf("expected refusal for a directory with foreign files; stderr:\n%s", stderr)
+	}
+	for _, p := range []string{bundle, foreign} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s must not be deleted: %v", p, err)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Тесты цветной таблицы (-color).
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestColor_AlwaysColorsIDsTacticsAndHeader(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-color", "always")
+	for _, want := range []string{
+		"\x1b[1mTECHNIQUE ID",
+		"\x1b[36mT1071\x1b[0m",
+		"\x1b[33mcommand-and-control\x1b[0m",
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q in colored table; stdout:\n%q\nstderr:\n%s", want, stdout, stderr)
+		}
+	}
+}
+
+func TestColor_NoANSIWhenPipedOrNever(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	// Вывод тестов — конвейер, поэтому auto цвета не даёт
+	for _, args := range [][]string{
+		{"-mitigation", "M1037"},
+		{"-mitigation", "M1037", "-color", "never"},
+	} {
+		stdout, _ := runMitremit(t, bin, env, args...)
+		if strings.Contains(stdout, "\x1b[") {
+			t.Errorf("%v: unexpected ANSI codes in piped output:\n%q", args, stdout)
+		}
+	}
+}
+
+func TestColor_InvalidModeIsError(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-color", "sometimes")
+	if !strings.Contains(stderr, "invalid -color") {
+		t.Errorf("expected error for invalid -color; stderr:\n%s", stderr)
+	}
+}
+
+func TestColor_EnvPrecedence(t *testing.T) {
+	bin := getBinary(t)
+	cases := []struct {
+		env   map[string]string
+		args  []string
+		color bool
+	}{
+		// CLICOLOR_FORCE включает цвет и в конвейере, важнее NO_COLOR
+		{map[string]string{"CLICOLOR_FORCE": "1"}, nil, true},
+		{map[string]string{"CLICOLOR_FORCE": "1", "NO_COLOR": "1"}, nil, true},
+		{map[string]string{"CLICOLOR_FORCE": "0"}, nil, false},
+		// Явный флаг важнее окружения
+		{map[string]string{"CLICOLOR_FORCE": "1"}, []string{"-no-color"}, false},
+		{map[string]string{"CLICOLOR_FORCE": "1"}, []string{"-color", "never"}, false},
+		{map[string]string{"NO_COLOR": "1", "CLICOLOR": "0"}, []string{"-color", "always"}, true},
+	}
+	for _, c := range cases {
+		// Значения из окружения разработчика не должны влиять на случай
+		env := fixtureCacheDir(t)
+		env["CLICOLOR_FORCE"], env["CLICOLOR"] = "", ""
+		for k, v := range c.env {
+			env[k] = v
+		}
+		args := append([]string{"-mitigation", "M1037"}, c.args...)
+		stdout, stderr := runMitremit(t, bin, env, args...)
+		if got := strings.Contains(stdout, "\x1b["); got != c.color {
+			t.Errorf("env %v args %v: colored = %v, want %v; stderr:\n%s", c.env, c.args, got, c.color, stderr)
+		}
+	}
+}
+
+func TestColor_NoColorConflictsWithAlways(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-no-color", "-color", "always")
+	if !strings.Contains(stderr, "-no-color conflicts with -color always") {
+		t.Errorf("expected a conflict error; stderr:\n%s", stderr)
+	}
+}
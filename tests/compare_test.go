@@ -0,0 +1,54 @@
+// Тесты сравнения двух митигаций (-compare).
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCompare_SetsInJSON(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-compare", "M1037,M1040", "-json")
+	type tech struct {
+		ExternalID string `json:"external_id"`
+	}
+	var cmp struct {
+		A struct {
+			ExternalID string `json:"external_id"`
+		} `json:"mitigation_a"`
+		OnlyA []tech `json:"only_a"`
+		OnlyB []tech `json:"only_b"`
+		Both  []tech `json:"both"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &cmp); err != nil {
+		t.Fatalf("bad JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	ids := func(ts []tech) string {
+		var out []string
+		for _, x := range ts {
+			out = append(out, x.ExternalID)
+		}
+		return strings.Join(out, ",")
+	}
+	if cmp.A.ExternalID != "M1037" {
+		t.Errorf("mitigation_a = %q, want M1037", cmp.A.ExternalID)
+	}
+	if got := ids(cmp.Both); got != "T1090,T1565" {
+		t.Errorf("both = %s, want T1090,T1565", got)
+	}
+	if got := ids(cmp.OnlyA); !strings.HasPrefix(got, "T1071,T1071.001") {
+		t.Errorf("only_a = %s, want T1071 and T1071.001 first", got)
+	}
+	if cmp.OnlyB == nil || len(cmp.OnlyB) != 0 {
+		t.Errorf("only_b = %v, want an empty array", cmp.OnlyB)
+	}
+}
+
+func TestCompare_NeedsTwoIDs(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-compare", "M1037")
+	if !strings.Contains(stderr, "-compare needs exactly two mitigation IDs") {
+		t.Errorf("expected error about the ID count; stderr:\n%s", stderr)
+	}
+}
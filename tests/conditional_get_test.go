@@ -0,0 +1,84 @@
+// Тесты условного GET (ETag / 304) при обновлении кэша бандла.
+package tests
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestConditionalGet_NotModifiedThenFailedCacheWrite(t *testing.T) {
+	bin := getBinary(t)
+	data := fixtureBundle(t)
+	var etag atomic.Value
+	etag.Store(`"v1"`)
+	var full, notModified atomic.Int32
+	env := fakeUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cur := etag.Load().(string)
+		if r.Header.Get("If-None-Match") == cur {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("ETag", cur)
+		_, _ = w.Write(data)
+	}))
+	dir := t.TempDir()
+	env[envMITRECacheDir] = dir
+	bundlePath := filepath.Join(dir, cacheFilename+".gz")
+
+	run := func() {
+		t.Helper()
+		stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-count")
+		if strings.TrimSpace(stdout) != "2" {
+			t.Fatalf("unexpected output; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+		}
+	}
+	expire := func(path string) {
+		t.Helper()
+		old := time.Now().Add(-(cacheTTL + time.Hour))
+		if err := os.Chtimes(path, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 1. Первая загрузка: 200, ETag сохраняется рядом с кэшем
+	run()
+	meta, err := os.ReadFile(bundlePath + ".meta")
+	if err != nil || !strings.Contains(string(meta), "v1") {
+		t.Fatalf("validators not saved after 200: %q, %v", meta, err)
+	}
+
+	// 2. Кэш устарел, бандл не менялся: 304, TTL продлён
+	expire(bundlePath)
+	run()
+	if full.Load() != 1 || notModified.Load() != 1 {
+		t.Fatalf("expected 200 then 304; full=%d notModified=%d", full.Load(), notModified.Load())
+	}
+	if info, err := os.Stat(bundlePath); err != nil || time.Since(info.ModTime()) > time.Hour {
+		t.Errorf("cache TTL not renewed after 304: %v", err)
+	}
+
+	// 3. Бандл изменился, но записать кэш нельзя (на месте файла — непустой каталог):
+	// ETag новой версии не должен остаться без записанного бандла
+	etag.Store(`"v2"`)
+	if err := os.Remove(bundlePath); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(bundlePath, "block"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	expire(bundlePath)
+	run()
+	if full.Load() != 2 {
+		t.Fatalf("expected a full download of the new version; full=%d", full.Load())
+	}
+	if meta, err := os.ReadFile(bundlePath + ".meta"); err == nil {
+		t.Errorf("validators must not be saved when the cache write failed; .meta:\n%s", meta)
+	}
+}
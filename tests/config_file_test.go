@@ -0,0 +1,96 @@
+// Тесты файла конфигурации ($XDG_CONFIG_HOME/mitremit/config, .mitremitrc).
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// xdgConfigEnv пишет конфигурацию в $XDG_CONFIG_HOME/mitremit/config и возвращает окружение
+// с тестовым бандлом.
+func xdgConfigEnv(t *testing.T, config string) map[string]string {
+	t.Helper()
+	env := fixtureCacheDir(t)
+	xdg := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(xdg, "mitremit"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(xdg, "mitremit", "config"), []byte(config), 0o644); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	env["XDG_CONFIG_HOME"] = xdg
+	return env
+}
+
+func TestConfigFile_SetsDefaultFormat(t *testing.T) {
+	bin := getBinary(t)
+	env := xdgConfigEnv(t, `{"format": "json"}`)
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1037")
+	var techs []map[string]any
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil || len(techs) == 0 {
+		t.Errorf("expected JSON output from config default (err %v); stdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+}
+
+func TestConfigFile_CommandLineOverrides(t *testing.T) {
+	bin := getBinary(t)
+	env := xdgConfigEnv(t, `{"format": "json", "domain": "ics"}`)
+	stdout, stderr := runMitremit(t, bin, env, "-csv", "-domain", "enterprise", "-mitigation", "M1037")
+	if !strings.HasPrefix(stdout, "Mitigation ID,") {
+		t.Errorf("-csv on the command line must override config format; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+}
+
+func TestConfigFile_UnknownOptionIsError(t *testing.T) {
+	bin := getBinary(t)
+	env := xdgConfigEnv(t, `{"no-such-option": true}`)
+	_, stderr := runMitremit(t, bin, env, "-mitigation", "M1037")
+	if !strings.Contains(stderr, "error reading config file") || !strings.Contains(stderr, "no-such-option") {
+		t.Errorf("expected error about unknown config option; stderr:\n%s", stderr)
+	}
+}
+
+// .mitremitrc в рабочей директории не должен задавать флаги, запускающие команды, пишущие файлы
+// или меняющие источник бандла: иначе запуск в чужом репозитории выполняет произвольный код.
+func TestConfigFile_DisallowedOptionsRejected(t *testing.T) {
+	bin := getBinary(t)
+	for _, option := range []string{"exec", "output", "output-dir", "bundle-file", "bundle-url", "proxy", "ca-file"} {
+		t.Run(option, func(t *testing.T) {
+			dir := t.TempDir()
+			marker := filepath.Join(dir, "pwned")
+			values := map[string]any{
+				"exec":        "touch " + marker,
+				"output":      marker,
+				"output-dir":  marker,
+				"bundle-file": marker,
+				"bundle-url":  "http://127.0.0.1:1/" + marker,
+				"proxy":       "http://127.0.0.1:1",
+				"ca-file":     marker,
+			}
+			config, _ := json.Marshal(map[string]any{option: values[option]})
+			if err := os.WriteFile(filepath.Join(dir, ".mitremitrc"), config, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			cmd := exec.Command(bin, "-mitigation", "M1037")
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), envMITRECacheDir+"="+fixtureCacheDir(t)[envMITRECacheDir],
+				"XDG_CONFIG_HOME="+t.TempDir())
+			var stderr bytes.Buffer
+			cmd.Stderr = &stderr
+			if err := cmd.Run(); err == nil {
+				t.Errorf("expected non-zero exit for %q in .mitremitrc; stderr:\n%s", option, stderr.String())
+			}
+			if !strings.Contains(stderr.String(), "cannot be set in a config file") || !strings.Contains(stderr.String(), option) {
+				t.Errorf("expected error about disallowed option %q; stderr:\n%s", option, stderr.String())
+			}
+			if _, err := os.Stat(marker); err == nil {
+				t.Errorf("option %q from .mitremitrc took effect", option)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+// Тесты режима -count на локальном бандле из testdata.
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCount_TablePrintsNumber(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-count")
+	if strings.TrimSpace(stdout) != "2" {
+		t.Errorf("-count = %q, want 2; stderr:\n%s", stdout, stderr)
+	}
+}
+
+func TestCount_AppliesFilters(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-tactic", "impact", "-count")
+	if strings.TrimSpace(stdout) != "1" {
+		t.Errorf("-count with -tactic impact = %q, want 1", stdout)
+	}
+}
+
+func TestCount_JSONObjectAndBatchArray(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-count", "-json")
+	var one struct {
+		Mitigation string `json:"mitigation"`
+		Count      int    `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &one); err != nil || one.Mitigation != "M1040" || one.Count != 2 {
+		t.Errorf("-count -json = %s (err %v), want {mitigation: M1040, count: 2}; stderr:\n%s", stdout, err, stderr)
+	}
+
+	stdout, _ = runMitremit(t, bin, env, "-mitigation", "M1040,M1099", "-count", "-json")
+	var many []struct {
+		Mitigation string `json:"mitigation"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &many); err != nil || len(many) != 2 ||
+		many[0].Mitigation != "M1040" || many[1].Mitigation != "M1099" {
+		t.Errorf("batch -count -json = %s (err %v), want an array for M1040 and M1099", stdout, err)
+	}
+}
+
+func TestCount_CSVSummaryRow(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-count", "-csv")
+	if got := csvColumn(t, stdout, "Count"); len(got) != 1 || got[0] != "2" {
+		t.Errorf("CSV Count column = %v, want [2]", got)
+	}
+	if got := csvColumn(t, stdout, "Mitigation Name"); got[0] != "Behavior Prevention on Endpoint" {
+		t.Errorf("CSV Mitigation Name = %v", got)
+	}
+}
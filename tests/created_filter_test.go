@@ -0,0 +1,44 @@
+// Тесты фильтров -created-after / -created-before на локальном бандле из testdata.
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCreatedFilter_AfterDate(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t),
+		"-mitigation", "M1040", "-created-after", "2020-01-01", "-json")
+	// T1090 создана в 2017-м, T1565 — в 2020-м
+	if got := strings.Join(jsonTechniqueIDs(t, stdout, stderr), ","); got != "T1565" {
+		t.Errorf("techniques created after 2020-01-01 = %s, want T1565", got)
+	}
+}
+
+func TestCreatedFilter_BeforeDateIsInclusive(t *testing.T) {
+	bin := getBinary(t)
+	// T1090 создана 2017-05-31T21:31 — дата без времени включает весь день
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t),
+		"-mitigation", "M1040", "-created-before", "2017-05-31", "-json")
+	if got := strings.Join(jsonTechniqueIDs(t, stdout, stderr), ","); got != "T1090" {
+		t.Errorf("techniques created before 2017-05-31 = %s, want T1090", got)
+	}
+}
+
+func TestCreatedFilter_RFC3339Range(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t),
+		"-mitigation", "M1040", "-created-after", "2017-05-31T21:31:00Z", "-created-before", "2017-05-31T21:32:00Z", "-json")
+	if got := strings.Join(jsonTechniqueIDs(t, stdout, stderr), ","); got != "T1090" {
+		t.Errorf("techniques created in the RFC3339 range = %s, want T1090", got)
+	}
+}
+
+func TestCreatedFilter_InvalidDateIsError(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-created-before", "31.05.2017")
+	if !strings.Contains(stderr, "invalid -created-before") {
+		t.Errorf("expected error for malformed date; stderr:\n%s", stderr)
+	}
+}
@@ -0,0 +1,52 @@
+// Тесты настроек CSV: разделитель (-csv-delimiter) и вывод без заголовка (-no-header).
+package tests
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestCSVDelimiter_Semicolon(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-csv", "-csv-delimiter", ";")
+	r := csv.NewReader(strings.NewReader(stdout))
+	r.Comma = ';'
+	records, err := r.ReadAll()
+	if err != nil {
+		t.Fatalf("parse semicolon CSV: %v; stdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	if got := records[0][0]; got != "Mitigation ID" {
+		t.Errorf("first header cell = %q, want Mitigation ID", got)
+	}
+	// Запятая в названии больше не требует кавычек, но значение должно сохраниться целиком
+	found := false
+	for _, rec := range records[1:] {
+		if rec[2] == "T1090" {
+			found = rec[3] == `Proxy, "Relay" | Hop`
+		}
+	}
+	if !found {
+		t.Errorf("T1090 row missing or name mangled; stdout:\n%s", stdout)
+	}
+}
+
+func TestCSVDelimiter_NoHeader(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-csv", "-no-header")
+	if strings.Contains(stdout, "Mitigation ID") {
+		t.Errorf("header row present with -no-header; stdout:\n%s", stdout)
+	}
+	if !strings.HasPrefix(stdout, "M1037,Filter Network Traffic,T1071,") {
+		t.Errorf("expected first data row at the top; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+}
+
+func TestCSVDelimiter_InvalidRejected(t *testing.T) {
+	bin := getBinary(t)
+	for _, d := range []string{"", ";;", `"`, "\n"} {
+		if _, code := runExitCode(t, bin, "-mitigation", "M1037", "-csv", "-csv-delimiter", d); code != 1 {
+			t.Errorf("-csv-delimiter %q: exit code %d, want 1", d, code)
+		}
+	}
+}
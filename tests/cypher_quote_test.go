@@ -0,0 +1,88 @@
+// Тесты экранирования строковых литералов в Cypher (защита от Cypher injection).
+// Бандл из testdata содержит названия с кавычками и обратными слэшами.
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+// extractCypherLiterals возвращает содержимое всех литералов в одинарных кавычках (с учётом
+// экранирования через '\') и признак того, что последний литерал в строке закрыт.
+func extractCypherLiterals(line string) (lits []string, balanced bool) {
+	i := 0
+	for i < len(line) {
+		if line[i] != '\'' {
+			i++
+			continue
+		}
+		i++
+		var b strings.Builder
+		closed := false
+		for i < len(line) {
+			c := line[i]
+			if c == '\\' && i+1 < len(line) {
+				b.WriteByte(line[i+1])
+				i += 2
+				continue
+			}
+			i++
+			if c == '\'' {
+				closed = true
+				break
+			}
+			b.WriteByte(c)
+		}
+		if !closed {
+			return lits, false
+		}
+		lits = append(lits, b.String())
+	}
+	return lits, true
+}
+
+func TestCypher_LiteralsBalancedAndTerminated(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-cypher", "-mitigation", "M1037")
+
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("expected Cypher statements; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	var names []string
+	for _, line := range lines {
+		lits, balanced := extractCypherLiterals(line)
+		if !balanced {
+			t.Errorf("unterminated string literal in statement: %s", line)
+		}
+		if !strings.HasSuffix(line, ";") {
+			t.Errorf("statement must end with ';': %s", line)
+		}
+		names = append(names, lits...)
+	}
+	// Названия с кавычками и слэшем должны пережить экранирование без искажений
+	for _, want := range []string{`Proxy, "Relay" | Hop`, `Technique "Without" External ID's \ Path`} {
+		found := false
+		for _, n := range names {
+			if n == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			t.Errorf("literal %q not found after unescaping; output:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestCypher_UsesMergeForIdempotency(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-cypher", "-mitigation", "M1037")
+
+	if strings.Contains(stdout, "CREATE") {
+		t.Errorf("Cypher import must use MERGE, not CREATE; output:\n%s", stdout)
+	}
+	if n := strings.Count(stdout, "MERGE (t:Technique {id: 'T1090'})"); n != 1 {
+		t.Errorf("technique node T1090 must be merged exactly once, got %d; output:\n%s", n, stdout)
+	}
+}
@@ -0,0 +1,54 @@
+// Дедупликация техник по STIX ID: техника без ссылки mitre-attack получает ID из хвоста STIX ID
+// и не должна сливаться с другой техникой, у которой такой же внешний ID.
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const dedupBundleJSON = `{"type": "bundle", "id": "bundle--dedup", "spec_version": "2.0", "objects": [
+  {"type": "course-of-action", "id": "course-of-action--m9001", "name": "Crafted Mitigation",
+   "external_references": [{"source_name": "mitre-attack", "external_id": "M9001"}]},
+  {"type": "attack-pattern", "id": "attack-pattern--T9001", "name": "No ATT&CK Reference",
+   "external_references": [{"source_name": "capec", "external_id": "CAPEC-1"}]},
+  {"type": "attack-pattern", "id": "attack-pattern--real", "name": "Real T9001",
+   "external_references": [{"source_name": "mitre-attack", "external_id": "T9001"}]},
+  {"type": "relationship", "id": "relationship--a", "relationship_type": "mitigates",
+   "source_ref": "course-of-action--m9001", "target_ref": "attack-pattern--T9001"},
+  {"type": "relationship", "id": "relationship--b", "relationship_type": "mitigates",
+   "source_ref": "course-of-action--m9001", "target_ref": "attack-pattern--real"},
+  {"type": "relationship", "id": "relationship--c", "relationship_type": "mitigates",
+   "source_ref": "course-of-action--m9001", "target_ref": "attack-pattern--T9001"}
+]}`
+
+func TestDedup_ByStixIDNotExternalID(t *testing.T) {
+	bin := getBinary(t)
+	path := filepath.Join(t.TempDir(), "dedup.json")
+	if err := os.WriteFile(path, []byte(dedupBundleJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := runMitremit(t, bin, nil, "-bundle-file", path, "-mitigation", "M9001", "-json")
+	var got []struct {
+		ExternalID string `json:"external_id"`
+		Name       string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	// Обе техники с ID T9001 (одна — по хвосту STIX ID) остаются, повтор связи — нет
+	if len(got) != 2 {
+		t.Fatalf("got %d techniques, want 2: %+v", len(got), got)
+	}
+	names := map[string]bool{got[0].Name: true, got[1].Name: true}
+	if !names["No ATT&CK Reference"] || !names["Real T9001"] {
+		t.Errorf("expected both distinct STIX objects; got %+v", got)
+	}
+	for _, tech := range got {
+		if tech.ExternalID != "T9001" {
+			t.Errorf("external_id = %q, want T9001 for %q", tech.ExternalID, tech.Name)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+// Тесты режима аудита -deprecated-only на локальном бандле из testdata.
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeprecatedOnly_ShowsOnlyRevokedTechniques(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-deprecated-only", "-json")
+	// Из техник M1037 отозвана только T1043
+	if got := strings.Join(jsonTechniqueIDs(t, stdout, stderr), ","); got != "T1043" {
+		t.Errorf("-deprecated-only techniques = %s, want T1043", got)
+	}
+}
+
+func TestDeprecatedOnly_NothingStale(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-deprecated-only", "-json")
+	if ids := jsonTechniqueIDs(t, stdout, stderr); len(ids) != 0 {
+		t.Errorf("M1040 has no revoked/deprecated techniques, got %v", ids)
+	}
+}
+
+func TestDeprecatedOnly_DefaultIncludesCurrentTechniques(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-json")
+	if got := strings.Join(jsonTechniqueIDs(t, stdout, stderr), ","); got != "T1090,T1565" {
+		t.Errorf("techniques without -deprecated-only = %s, want T1090,T1565", got)
+	}
+}
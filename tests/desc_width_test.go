@@ -0,0 +1,54 @@
+// Тесты обрезки описаний техник (-desc-width).
+package tests
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDescWidth_TruncatesCSVByRunes(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t),
+		"-mitigation", "M1037", "-show-description", "-csv", "-desc-width", "20")
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil || len(records) < 2 {
+		t.Fatalf("expected CSV (err %v); stdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	col := len(records[0]) - 1
+	if records[0][col] != "Description" {
+		t.Fatalf("last CSV column = %q, want Description", records[0][col])
+	}
+	truncated := 0
+	for _, rec := range records[1:] {
+		desc := rec[col]
+		if n := utf8.RuneCountInString(desc); n > 20 {
+			t.Errorf("description has %d runes, want <= 20: %q", n, desc)
+		}
+		if strings.HasSuffix(desc, "…") {
+			truncated++
+		}
+	}
+	if truncated == 0 {
+		t.Errorf("expected at least one truncated description with an ellipsis:\n%s", stdout)
+	}
+}
+
+func TestDescWidth_JSONKeepsFullDescription(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t),
+		"-mitigation", "M1037", "-show-description", "-json", "-desc-width", "20")
+	var techs []struct {
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil {
+		t.Fatalf("expected JSON array (err %v):\n%s", err, stdout)
+	}
+	for _, tech := range techs {
+		if strings.HasSuffix(tech.Description, "…") {
+			t.Errorf("JSON description must not be truncated: %q", tech.Description)
+		}
+	}
+}
@@ -0,0 +1,33 @@
+// Тесты поиска источников данных для техники (-detections).
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDetections_ListsDataComponentWithSource(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-detections", "T1071", "-json")
+	var dets []struct {
+		DataComponent string `json:"data_component"`
+		DataSource    string `json:"data_source"`
+		DataSourceID  string `json:"data_source_id"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &dets); err != nil {
+		t.Fatalf("expected JSON array (err %v); stdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	if len(dets) != 1 || dets[0].DataComponent != "Network Traffic Content" ||
+		dets[0].DataSource != "Network Traffic" || dets[0].DataSourceID != "DS0029" {
+		t.Errorf("unexpected detections for T1071: %+v", dets)
+	}
+}
+
+func TestDetections_UnknownTechniqueIsError(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-detections", "T9999")
+	if !strings.Contains(stderr, "technique T9999 not found") {
+		t.Errorf("expected not-found error; stderr:\n%s", stderr)
+	}
+}
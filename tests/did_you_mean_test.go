@@ -29,8 +29,9 @@ func TestDidYouMean_SuggestsSingleCloseName(t *testing.T) {
 	if !strings.Contains(out, "Did you mean:") {
 		t.Errorf("stderr should contain 'Did you mean:' suggestion; got:\n%s", out)
 	}
-	if !strings.Contains(out, "Filter Network Traffic") {
-		t.Errorf("stderr should suggest correct name 'Filter Network Traffic'; got:\n%s", out)
+	// Ближайшее имя идёт первым в списке подсказок
+	if !strings.Contains(out, `Did you mean: "Filter Network Traffic"`) {
+		t.Errorf("stderr should suggest correct name 'Filter Network Traffic' first; got:\n%s", out)
 	}
 }
 
@@ -48,7 +49,7 @@ func TestDidYouMean_NoSuggestionWhenExactMatch(t *testing.T) {
 
 func TestDidYouMean_NoSuggestionWhenNoCloseMatch(t *testing.T) {
 	bin := getBinary(t)
-	// Совсем другое имя — подсказки быть не должно (или только если случайно один вариант в пределах 2)
+	// Совсем другое имя — подсказки быть не должно (или только если случайно есть варианты в пределах порога)
 	cmd := exec.Command(bin, "-mitigation-name", "XyZzY NoSuch Mitigation 123")
 	cmd.Dir = repoRoot(t)
 	var stderr strings.Builder
@@ -58,6 +59,56 @@ func TestDidYouMean_NoSuggestionWhenNoCloseMatch(t *testing.T) {
 	if !strings.Contains(out, "not found (check spelling)") {
 		t.Errorf("stderr should contain 'not found'; got:\n%s", out)
 	}
-	// Не требуем отсутствия "Did you mean" — при большом наборе митигаций теоретически может быть один в радиусе 2
+	// Не требуем отсутствия "Did you mean" — при большом наборе митигаций теоретически могут быть варианты в пределах порога
 	// Проверяем лишь, что при явной опечатке подсказка есть (первый тест), а при точном совпадении — нет ошибки (второй)
 }
+
+func TestDidYouMean_SuggestsCloseMitigationID(t *testing.T) {
+	bin := getBinary(t)
+	// Опечатка в последней цифре: M1038 вместо M1037 (в тестовом бандле ближайший ID один)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1038")
+	if !strings.Contains(stderr, "mitigation M1038 not found") {
+		t.Errorf("stderr should report unknown ID; got:\n%s", stderr)
+	}
+	if !strings.Contains(stderr, "Did you mean: M1037?") {
+		t.Errorf("stderr should suggest M1037; got:\n%s", stderr)
+	}
+}
+
+func TestDidYouMean_ThresholdScalesWithNameLength(t *testing.T) {
+	bin := getBinary(t)
+	// Две опечатки в длинном имени: порог по длине (4) допускает подсказку, -suggest-distance 1 — нет
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation-name", "Filter Netwrk Trafic")
+	if !strings.Contains(stderr, `Did you mean: "Filter Network Traffic"?`) {
+		t.Errorf("expected a suggestion for a long name with two typos; stderr:\n%s", stderr)
+	}
+	_, stderr = runMitremit(t, bin, fixtureCacheDir(t), "-mitigation-name", "Filter Netwrk Trafic", "-suggest-distance", "1")
+	if strings.Contains(stderr, "Did you mean") {
+		t.Errorf("-suggest-distance 1 must suppress the suggestion; stderr:\n%s", stderr)
+	}
+}
+
+func TestDidYouMean_DebugShowsDistances(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation-name", "Filter Netwrk Trafic", "-debug")
+	for _, want := range []string{`suggestion threshold for "Filter Netwrk Trafic": 4`, "distance 2: Filter Network Traffic"} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("-debug output should contain %q; stderr:\n%s", want, stderr)
+		}
+	}
+}
+
+func TestDidYouMean_RankedSuggestions(t *testing.T) {
+	bin := getBinary(t)
+	// Большой порог: в подсказку попадают все три митигации тестового бандла, ближайшая первой
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation-name", "Control", "-suggest-distance", "40")
+	want := `Did you mean: "Legacy Control", "Filter Network Traffic", or "Behavior Prevention on Endpoint"?`
+	if !strings.Contains(stderr, want) {
+		t.Errorf("expected ranked suggestions %s; stderr:\n%s", want, stderr)
+	}
+	_, stderr = runMitremit(t, bin, fixtureCacheDir(t), "-mitigation-name", "Control", "-suggest-distance", "26")
+	want = `Did you mean: "Legacy Control" or "Filter Network Traffic"?`
+	if !strings.Contains(stderr, want) {
+		t.Errorf("expected two suggestions %s; stderr:\n%s", want, stderr)
+	}
+}
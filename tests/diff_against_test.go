@@ -0,0 +1,100 @@
+// Тесты сравнения с прошлым выводом -json (-diff-against).
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writePrevious сохраняет текущий -json вывод для M1037 без T1071 и с лишней техникой T9999.
+func writePrevious(t *testing.T, bin string) string {
+	t.Helper()
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-json")
+	var prev []map[string]any
+	if err := json.Unmarshal([]byte(stdout), &prev); err != nil {
+		t.Fatalf("bad JSON: %v\nstderr:\n%s", err, stderr)
+	}
+	var kept []map[string]any
+	for _, p := range prev {
+		if p["external_id"] != "T1071" {
+			kept = append(kept, p)
+		}
+	}
+	kept = append(kept, map[string]any{"external_id": "T9999", "name": "Removed Technique"})
+	data, _ := json.Marshal(kept)
+	path := filepath.Join(t.TempDir(), "prev.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("write previous output: %v", err)
+	}
+	return path
+}
+
+func TestDiffAgainst_PlusMinusLines(t *testing.T) {
+	bin := getBinary(t)
+	prev := writePrevious(t, bin)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-diff-against", prev)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 change lines, got %d:\n%s\nstderr:\n%s", len(lines), stdout, stderr)
+	}
+	if !strings.HasPrefix(lines[0], "+ M1037") || !strings.Contains(lines[0], "T1071 ") {
+		t.Errorf("expected added T1071, got %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "- M1037") || !strings.Contains(lines[1], "T9999") {
+		t.Errorf("expected removed T9999, got %q", lines[1])
+	}
+}
+
+func TestDiffAgainst_JSON(t *testing.T) {
+	bin := getBinary(t)
+	prev := writePrevious(t, bin)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-diff-against", prev, "-json")
+	var d struct {
+		Added []struct {
+			ExternalID string `json:"external_id"`
+		} `json:"added"`
+		Removed []struct {
+			ExternalID string `json:"external_id"`
+		} `json:"removed"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &d); err != nil {
+		t.Fatalf("bad JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	if len(d.Added) != 1 || d.Added[0].ExternalID != "T1071" {
+		t.Errorf("added = %+v, want [T1071]", d.Added)
+	}
+	if len(d.Removed) != 1 || d.Removed[0].ExternalID != "T9999" {
+		t.Errorf("removed = %+v, want [T9999]", d.Removed)
+	}
+}
+
+func TestDiffAgainst_NoChangesIsEmpty(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-json")
+	path := filepath.Join(t.TempDir(), "same.json")
+	if err := os.WriteFile(path, []byte(stdout), 0o644); err != nil {
+		t.Fatalf("write previous output: %v", err)
+	}
+	out, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-diff-against", path)
+	if strings.TrimSpace(out) != "" {
+		t.Errorf("expected no changes, got:\n%s\nstderr:\n%s", out, stderr)
+	}
+}
+
+func TestDiffAgainst_AcceptsTreeAndGroupedOutput(t *testing.T) {
+	bin := getBinary(t)
+	for _, args := range [][]string{{"-tree"}, {"-group-output-by", "tactic"}} {
+		stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), append([]string{"-mitigation", "M1037", "-json"}, args...)...)
+		path := filepath.Join(t.TempDir(), "prev.json")
+		if err := os.WriteFile(path, []byte(stdout), 0o644); err != nil {
+			t.Fatalf("write previous output: %v", err)
+		}
+		out, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-diff-against", path)
+		if strings.TrimSpace(out) != "" || strings.Contains(stderr, "ERROR") {
+			t.Errorf("%v: expected no changes, got:\n%s\nstderr:\n%s", args, out, stderr)
+		}
+	}
+}
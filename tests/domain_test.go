@@ -0,0 +1,31 @@
+// Тесты выбора домена ATT&CK (-domain): у каждого домена свой файл бандла в кэше.
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDomain_MobileUsesOwnCacheFile(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	dir := env[envMITRECacheDir]
+	// Бандл лежит только под именем enterprise; переименовываем его в mobile-attack.json
+	if err := os.Rename(filepath.Join(dir, cacheFilename), filepath.Join(dir, "mobile-attack.json")); err != nil {
+		t.Fatalf("rename fixture: %v", err)
+	}
+	stdout, stderr := runMitremit(t, bin, env, "-domain", "mobile", "-debug", "-mitigation", "M1037")
+	if !strings.Contains(stderr, "mobile-attack.json") || !strings.Contains(stderr, "cached bundle found") {
+		t.Errorf("expected -domain mobile to read mobile-attack.json from cache; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+}
+
+func TestDomain_InvalidIsError(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-domain", "pre", "-mitigation", "M1037")
+	if !strings.Contains(stderr, "invalid -domain") {
+		t.Errorf("expected error about invalid domain; stderr:\n%s", stderr)
+	}
+}
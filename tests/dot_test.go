@@ -0,0 +1,51 @@
+// Тесты вывода Graphviz DOT (-dot) на локальном бандле из testdata.
+package tests
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestDOT_DigraphWithNodesAndEdges(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-dot")
+	if !strings.HasPrefix(stdout, "digraph mitigations {\n") || !strings.HasSuffix(stdout, "}\n") {
+		t.Fatalf("output is not a digraph; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	for _, want := range []string{
+		`"M1040" [shape=box, label="M1040\nBehavior Prevention on Endpoint"];`,
+		// Кавычки в названии экранируются внутри метки
+		`"T1090" [shape=ellipse, label="T1090\nProxy, \"Relay\" | Hop"];`,
+		`"M1040" -> "T1090" [label="mitigates"];`,
+		`"M1040" -> "T1565" [label="mitigates"];`,
+	} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("DOT output lacks %s; stdout:\n%s", want, stdout)
+		}
+	}
+}
+
+func TestDOT_SharedTechniqueIsOneNodeInBatch(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040,M1037", "-dot")
+	if n := len(regexp.MustCompile(`(?m)^  "T1090" \[`).FindAllString(stdout, -1)); n != 1 {
+		t.Errorf("T1090 node declared %d times, want 1; stdout:\n%s", n, stdout)
+	}
+	for _, edge := range []string{`"M1040" -> "T1090"`, `"M1037" -> "T1090"`} {
+		if !strings.Contains(stdout, edge) {
+			t.Errorf("missing edge %s; stdout:\n%s", edge, stdout)
+		}
+	}
+}
+
+func TestDOT_IdentifiersAreSanitized(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-dot")
+	// Каждый идентификатор узла — в кавычках и только из безопасных символов
+	for _, m := range regexp.MustCompile(`(?m)^  ("[^"]*")`).FindAllStringSubmatch(stdout, -1) {
+		if !regexp.MustCompile(`^"[\p{L}\p{N}._-]+"$`).MatchString(m[1]) {
+			t.Errorf("unsafe DOT identifier %s", m[1])
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// Тесты -dry-run: решения по кэшу без загрузки и без вывода техник.
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDryRun_FreshCacheNoDownload(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	_, code := runExitCode(t, bin, "-dry-run", "-mitigation", "M1037")
+	if code != 0 {
+		t.Errorf("exit code = %d, want 0", code)
+	}
+	stdout, stderr := runMitremit(t, bin, env, "-dry-run", "-mitigation", "M1037")
+	for _, want := range []string{"cache dir:", env[envMITRECacheDir], "cache valid:  yes", "download:     no (cache is fresh)"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected %q; stdout:\n%s\nstderr:\n%s", want, stdout, stderr)
+		}
+	}
+	if strings.Contains(stdout, "T1071") {
+		t.Errorf("-dry-run must not print techniques:\n%s", stdout)
+	}
+}
+
+func TestDryRun_ExpiredCacheWouldDownload(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	oldTime := time.Now().Add(-(cacheTTL + time.Hour))
+	if err := os.Chtimes(filepath.Join(env[envMITRECacheDir], cacheFilename), oldTime, oldTime); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	stdout, stderr := runMitremit(t, bin, env, "-dry-run", "-debug")
+	if !strings.Contains(stdout, "cache valid:  no") || !strings.Contains(stdout, "download:     yes (cache expired)") {
+		t.Errorf("expected an expired cache and a pending download; stdout:\n%s", stdout)
+	}
+	if strings.Contains(stderr, "downloading") {
+		t.Errorf("-dry-run must not download; stderr:\n%s", stderr)
+	}
+}
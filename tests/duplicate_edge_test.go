@@ -0,0 +1,49 @@
+// Повторяющиеся связи mitigates (одна и та же пара митигация → техника дважды) не дают
+// повторов техники ни в одном формате.
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const duplicateEdgeBundleJSON = `{"type": "bundle", "id": "bundle--dup-edge", "spec_version": "2.0", "objects": [
+  {"type": "course-of-action", "id": "course-of-action--m9002", "name": "Twice Linked",
+   "external_references": [{"source_name": "mitre-attack", "external_id": "M9002"}]},
+  {"type": "attack-pattern", "id": "attack-pattern--t9002", "name": "Linked Technique",
+   "external_references": [{"source_name": "mitre-attack", "external_id": "T9002"}]},
+  {"type": "attack-pattern", "id": "attack-pattern--t9003", "name": "Other Technique",
+   "external_references": [{"source_name": "mitre-attack", "external_id": "T9003"}]},
+  {"type": "relationship", "id": "relationship--first", "relationship_type": "mitigates",
+   "source_ref": "course-of-action--m9002", "target_ref": "attack-pattern--t9002"},
+  {"type": "relationship", "id": "relationship--other", "relationship_type": "mitigates",
+   "source_ref": "course-of-action--m9002", "target_ref": "attack-pattern--t9003"},
+  {"type": "relationship", "id": "relationship--second", "relationship_type": "mitigates",
+   "source_ref": "course-of-action--m9002", "target_ref": "attack-pattern--t9002"}
+]}`
+
+func TestDuplicateEdge_TechniqueListedOnce(t *testing.T) {
+	bin := getBinary(t)
+	path := filepath.Join(t.TempDir(), "dup-edge.json")
+	if err := os.WriteFile(path, []byte(duplicateEdgeBundleJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		format, needle string
+	}{
+		{"-csv", "M9002,Twice Linked,T9002,"},
+		{"-dot", `"M9002" -> "T9002"`},
+		{"-cypher", "(t:Technique {id: 'T9002'}) MERGE (m)-[:MITIGATES]->(t)"},
+	} {
+		stdout, stderr := runMitremit(t, bin, nil, "-bundle-file", path, "-mitigation", "M9002", tc.format)
+		if n := strings.Count(stdout, tc.needle); n != 1 {
+			t.Errorf("%s: %q occurs %d times, want 1; stdout:\n%s\nstderr:\n%s", tc.format, tc.needle, n, stdout, stderr)
+		}
+	}
+	stdout, _ := runMitremit(t, bin, nil, "-bundle-file", path, "-mitigation", "M9002", "-count")
+	if strings.TrimSpace(stdout) != "2" {
+		t.Errorf("-count = %q, want 2", strings.TrimSpace(stdout))
+	}
+}
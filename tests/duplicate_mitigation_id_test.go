@@ -0,0 +1,61 @@
+// Тесты выбора митигации, когда один внешний ID носят несколько STIX-объектов.
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"mitremit/attack"
+)
+
+// duplicateIDBundle — бандл с тремя объектами M9999: устаревший (самый новый), действующий
+// старый и действующий более новый. Технику смягчает только последний.
+const duplicateIDBundle = `{"type": "bundle", "spec_version": "2.1", "objects": [
+  {"type": "course-of-action", "id": "course-of-action--m9999-a", "name": "Duplicate Deprecated", "modified": "2024-06-01T00:00:00.000Z", "x_mitre_deprecated": true, "external_references": [{"source_name": "mitre-attack", "external_id": "M9999"}]},
+  {"type": "course-of-action", "id": "course-of-action--m9999-b", "name": "Duplicate Old", "modified": "2022-01-01T00:00:00.000Z", "external_references": [{"source_name": "mitre-attack", "external_id": "M9999"}]},
+  {"type": "course-of-action", "id": "course-of-action--m9999-c", "name": "Duplicate Current", "modified": "2023-05-01T00:00:00.000Z", "external_references": [{"source_name": "mitre-attack", "external_id": "M9999"}]},
+  {"type": "attack-pattern", "id": "attack-pattern--t1071", "name": "Application Layer Protocol", "external_references": [{"source_name": "mitre-attack", "external_id": "T1071"}]},
+  {"type": "relationship", "id": "relationship--c", "relationship_type": "mitigates", "source_ref": "course-of-action--m9999-c", "target_ref": "attack-pattern--t1071"}
+]}`
+
+func TestDuplicateMitigationID_CandidatesOrdered(t *testing.T) {
+	want := []string{"course-of-action--m9999-c", "course-of-action--m9999-b", "course-of-action--m9999-a"}
+	// Порядок обхода map случаен — повторяем разбор, чтобы поймать недетерминированность
+	for range 20 {
+		idx, err := attack.ParseBundle([]byte(duplicateIDBundle))
+		if err != nil {
+			t.Fatalf("ParseBundle: %v", err)
+		}
+		if got := idx.MitigationCandidates("m9999"); !slices.Equal(got, want) {
+			t.Fatalf("MitigationCandidates = %v, want %v", got, want)
+		}
+		if got := idx.MitigationByExtID("M9999"); got != want[0] {
+			t.Fatalf("MitigationByExtID = %q, want %q", got, want[0])
+		}
+	}
+}
+
+func TestDuplicateMitigationID_CLIPicksCurrentAndLogs(t *testing.T) {
+	bin := getBinary(t)
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	if err := os.WriteFile(path, []byte(duplicateIDBundle), 0o644); err != nil {
+		t.Fatalf("write bundle: %v", err)
+	}
+	stdout, stderr := runMitremit(t, bin, nil, "-bundle-file", path, "-mitigation", "M9999", "-json", "-debug")
+	var techs []struct {
+		ExternalID string `json:"external_id"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil {
+		t.Fatalf("expected JSON array (err %v); stdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	if len(techs) != 1 || techs[0].ExternalID != "T1071" {
+		t.Errorf("expected T1071 from the current M9999 object, got %+v", techs)
+	}
+	if !strings.Contains(stderr, "3 objects share mitigation ID M9999; using course-of-action--m9999-c") {
+		t.Errorf("expected -debug note about duplicate candidates; stderr:\n%s", stderr)
+	}
+}
@@ -0,0 +1,19 @@
+// Тесты встроенного бандла (-embedded) в обычной сборке без тега embedbundle.
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEmbedded_WithoutTagIsClearError(t *testing.T) {
+	bin := getBinary(t)
+	stdout, code := runExitCode(t, bin, "-embedded", "-mitigation", "M1037")
+	if code != 1 || stdout != "" {
+		t.Errorf("expected exit 1 and no output; code=%d stdout:\n%s", code, stdout)
+	}
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-embedded", "-mitigation", "M1037")
+	if !strings.Contains(stderr, "-tags embedbundle") {
+		t.Errorf("expected a hint to build with -tags embedbundle; stderr:\n%s", stderr)
+	}
+}
@@ -0,0 +1,70 @@
+// Тесты значений флагов из переменных окружения MITREMIT_*.
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEnvOverride_Format(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	env["MITREMIT_FORMAT"] = "json"
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1037")
+	var techs []struct {
+		ExternalID string `json:"external_id"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil {
+		t.Fatalf("MITREMIT_FORMAT=json should select JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	if len(techs) == 0 {
+		t.Errorf("expected techniques for M1037; stderr:\n%s", stderr)
+	}
+}
+
+func TestEnvOverride_FlagTakesPrecedence(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	env["MITREMIT_FORMAT"] = "json"
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1037", "-csv")
+	if !strings.HasPrefix(stdout, "Mitigation ID,") {
+		t.Fatalf("-csv on the command line should win over MITREMIT_FORMAT; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+}
+
+func TestEnvOverride_InvalidValueIsError(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	env["MITREMIT_FORMAT"] = "yaml"
+	_, stderr := runMitremit(t, bin, env, "-mitigation", "M1037")
+	if !strings.Contains(stderr, "MITREMIT_FORMAT") {
+		t.Errorf("expected an error naming MITREMIT_FORMAT; stderr:\n%s", stderr)
+	}
+}
+
+func TestEnvOverride_DisallowedFlagIsError(t *testing.T) {
+	bin := getBinary(t)
+	for _, name := range []string{"MITREMIT_EXEC", "MITREMIT_OUTPUT", "MITREMIT_BUNDLE_FILE", "MITREMIT_BUNDLE_URL",
+		"MITREMIT_PROXY", "MITREMIT_CA_FILE", "MITREMIT_TACTIC"} {
+		env := fixtureCacheDir(t)
+		env[name] = "x"
+		stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1037")
+		if stdout != "" || !strings.Contains(stderr, name+" cannot set") {
+			t.Errorf("%s must be rejected; stdout:\n%s\nstderr:\n%s", name, stdout, stderr)
+		}
+	}
+}
+
+func TestEnvOverride_RelativeCacheDirIgnored(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	env["MITREMIT_CACHE_DIR"] = "../elsewhere"
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1037", "-count")
+	if !strings.Contains(stderr, "MITREMIT_CACHE_DIR must be absolute path") {
+		t.Errorf("expected a warning about the relative MITREMIT_CACHE_DIR; stderr:\n%s", stderr)
+	}
+	if strings.TrimSpace(stdout) == "" {
+		t.Errorf("the fixture cache should still be used; stderr:\n%s", stderr)
+	}
+}
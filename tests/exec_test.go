@@ -0,0 +1,32 @@
+// Тесты пост-обработки -exec на локальном бандле из testdata.
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExec_PlaceholdersPassedAsSingleArguments(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t),
+		"-mitigation", "M1040", "-exec", `printf %s|%s|%s|%s|%s\n {id} {name} {tactics} {platforms} {mitigation}`)
+	// Имя с пробелами, кавычками и "|" должно прийти команде одним аргументом, без shell
+	want := `T1090|Proxy, "Relay" | Hop|command-and-control,defense-evasion|Linux,macOS,Windows,Network|M1040` + "\n" +
+		`T1565|Data Manipulation|impact|Windows|M1040` + "\n"
+	if stdout != want {
+		t.Errorf("-exec output:\n%s\nwant:\n%s\nstderr:\n%s", stdout, want, stderr)
+	}
+	if strings.Contains(stdout, "TECHNIQUE ID") {
+		t.Errorf("-exec must replace the table output; stdout:\n%s", stdout)
+	}
+}
+
+func TestExec_FailuresReportedAndProcessingContinues(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-exec", "false {id}")
+	for _, id := range []string{"T1090", "T1565"} {
+		if !strings.Contains(stderr, "exec "+id+":") {
+			t.Errorf("expected failure report for %s; stderr:\n%s", id, stderr)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Тесты кода выхода -fail-empty (0 — есть результаты, 2 — пусто).
+package tests
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// runExitCode запускает утилиту на тестовом бандле и возвращает stdout и код выхода.
+func runExitCode(t *testing.T, bin string, args ...string) (string, int) {
+	t.Helper()
+	return runExitCodeEnv(t, bin, fixtureCacheDir(t), args...)
+}
+
+// runExitCodeEnv — как runExitCode, но с заданными переменными окружения.
+func runExitCodeEnv(t *testing.T, bin string, env map[string]string, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(bin, args...)
+	cmd.Dir = repoRoot(t)
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	var stdout strings.Builder
+	cmd.Stdout = &stdout
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.String(), exitErr.ExitCode()
+	}
+	if err != nil {
+		t.Fatalf("run %s: %v", bin, err)
+	}
+	return stdout.String(), 0
+}
+
+func TestFailEmpty_ExitCodeTwoWhenFilteredOut(t *testing.T) {
+	bin := getBinary(t)
+	stdout, code := runExitCode(t, bin, "-fail-empty", "-json", "-mitigation", "M1037", "-tactic", "no-such-tactic")
+	if code != 2 {
+		t.Errorf("exit code = %d, want 2 for empty result", code)
+	}
+	if !strings.Contains(stdout, "null") && !strings.Contains(stdout, "[]") {
+		t.Errorf("requested JSON output should still be printed; got:\n%s", stdout)
+	}
+}
+
+func TestFailEmpty_ExitCodeZeroWithResults(t *testing.T) {
+	bin := getBinary(t)
+	_, code := runExitCode(t, bin, "-fail-empty", "-mitigation", "M1037")
+	if code != 0 {
+		t.Errorf("exit code = %d, want 0 when techniques are found", code)
+	}
+}
@@ -0,0 +1,141 @@
+// Подменный upstream для тестов загрузки: утилита ходит на настоящий URL бандла
+// (raw.githubusercontent.com), но через HTTPS-прокси из окружения, который туннелирует
+// CONNECT в локальный TLS-сервер с самоподписанным сертификатом.
+package tests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// upstreamHost — хост, с которого утилита скачивает бандлы.
+const upstreamHost = "raw.githubusercontent.com"
+
+// chanListener — net.Listener, соединения в который передаёт прокси после CONNECT.
+type chanListener struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+}
+
+func newChanListener() *chanListener {
+	return &chanListener{conns: make(chan net.Conn), done: make(chan struct{})}
+}
+
+func (l *chanListener) Accept() (net.Conn, error) {
+	select {
+	case c := <-l.conns:
+		return c, nil
+	case <-l.done:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *chanListener) Close() error {
+	l.once.Do(func() { close(l.done) })
+	return nil
+}
+
+func (l *chanListener) Addr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)}
+}
+
+// fakeUpstream запускает подменный upstream с обработчиком h и возвращает окружение для
+// runMitremit: HTTPS_PROXY указывает на локальный прокси, SSL_CERT_FILE — на сертификат,
+// которому доверяет дочерний процесс.
+func fakeUpstream(t *testing.T, h http.Handler) map[string]string {
+	t.Helper()
+	certFile, tlsCert := selfSignedCert(t, upstreamHost)
+
+	ln := newChanListener()
+	srv := &http.Server{Handler: h}
+	go func() { _ = srv.Serve(tls.NewListener(ln, &tls.Config{Certificates: []tls.Certificate{tlsCert}})) }()
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "only CONNECT is supported", http.StatusMethodNotAllowed)
+			return
+		}
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		if _, err := conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+			conn.Close()
+			return
+		}
+		select {
+		case ln.conns <- conn:
+		case <-ln.done:
+			conn.Close()
+		}
+	}))
+	t.Cleanup(func() {
+		proxy.Close()
+		_ = ln.Close()
+		_ = srv.Close()
+	})
+
+	return map[string]string{
+		"HTTPS_PROXY":   proxy.URL,
+		"https_proxy":   proxy.URL,
+		"NO_PROXY":      "",
+		"no_proxy":      "",
+		"SSL_CERT_FILE": certFile,
+	}
+}
+
+// selfSignedCert выпускает самоподписанный сертификат для host, записывает его в PEM-файл
+// во временной директории и возвращает путь к файлу и пару для TLS-сервера.
+func selfSignedCert(t *testing.T, host string) (string, tls.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: host},
+		DNSNames:              []string{host},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshal key: %v", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	path := filepath.Join(t.TempDir(), "upstream.pem")
+	if err := os.WriteFile(path, certPEM, 0o600); err != nil {
+		t.Fatalf("write certificate: %v", err)
+	}
+	pair, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		t.Fatalf("load key pair: %v", err)
+	}
+	return path, pair
+}
@@ -0,0 +1,47 @@
+// Общий помощник для тестов, работающих без сети на локальном STIX-бандле из testdata.
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fixtureCacheDir копирует testdata/enterprise-attack.json во временную директорию кэша
+// и возвращает окружение для runMitremit. Свежий mtime копии гарантирует, что утилита
+// возьмёт бандл из кэша и не пойдёт в сеть.
+func fixtureCacheDir(t *testing.T) map[string]string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "enterprise-attack.json"), fixtureBundle(t), 0o644); err != nil {
+		t.Fatalf("write fixture bundle: %v", err)
+	}
+	return map[string]string{envMITRECacheDir: dir}
+}
+
+// fixtureBundle возвращает содержимое testdata/enterprise-attack.json.
+func fixtureBundle(t *testing.T) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(repoRoot(t), "tests", "testdata", "enterprise-attack.json"))
+	if err != nil {
+		t.Fatalf("read fixture bundle: %v", err)
+	}
+	return data
+}
+
+// jsonTechniqueIDs разбирает вывод -json (массив техник) и возвращает их внешние ID по порядку.
+func jsonTechniqueIDs(t *testing.T, stdout, stderr string) []string {
+	t.Helper()
+	var techniques []struct {
+		ExternalID string `json:"external_id"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &techniques); err != nil {
+		t.Fatalf("output is not a JSON array of techniques: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	ids := make([]string, 0, len(techniques))
+	for _, tech := range techniques {
+		ids = append(ids, tech.ExternalID)
+	}
+	return ids
+}
@@ -0,0 +1,56 @@
+// Тесты колонки Platforms в CSV и флага -flatten-platforms на локальном бандле из testdata.
+package tests
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+// csvColumn возвращает значения колонки name во всех строках данных CSV.
+func csvColumn(t *testing.T, stdout, name string) []string {
+	t.Helper()
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil || len(records) == 0 {
+		t.Fatalf("output is not valid CSV (%v):\n%s", err, stdout)
+	}
+	col := -1
+	for i, h := range records[0] {
+		if h == name {
+			col = i
+		}
+	}
+	if col < 0 {
+		t.Fatalf("CSV header %v has no %q column", records[0], name)
+	}
+	var values []string
+	for _, r := range records[1:] {
+		values = append(values, r[col])
+	}
+	return values
+}
+
+func TestFlattenPlatforms_DefaultJoinsPlatforms(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-csv")
+	got := csvColumn(t, stdout, "Platforms")
+	want := []string{"Linux; macOS; Windows; Network", "Windows"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("Platforms column = %q, want %q", got, want)
+	}
+}
+
+func TestFlattenPlatforms_OneRowPerPlatform(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-csv", "-flatten-platforms")
+	ids := csvColumn(t, stdout, "Technique ID")
+	platforms := csvColumn(t, stdout, "Platforms")
+	var rows []string
+	for i := range ids {
+		rows = append(rows, ids[i]+"/"+platforms[i])
+	}
+	want := "T1090/Linux,T1090/macOS,T1090/Windows,T1090/Network,T1565/Windows"
+	if got := strings.Join(rows, ","); got != want {
+		t.Errorf("flattened rows = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,97 @@
+// Тесты группировки вывода (-group-output-by) на локальном бандле из testdata.
+package tests
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGroupOutput_JSONByTactic(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-group-output-by", "tactic", "-json")
+
+	var groups []struct {
+		Group      string `json:"group"`
+		Techniques []struct {
+			ExternalID string `json:"external_id"`
+		} `json:"techniques"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &groups); err != nil {
+		t.Fatalf("output is not grouped JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	// T1090 относится к двум тактикам и должна попасть в обе группы
+	want := map[string][]string{
+		"command-and-control": {"T1090"},
+		"defense-evasion":     {"T1090"},
+		"impact":              {"T1565"},
+	}
+	wantOrder := []string{"command-and-control", "defense-evasion", "impact"}
+	if len(groups) != len(wantOrder) {
+		t.Fatalf("got %d groups, want %d: %+v", len(groups), len(wantOrder), groups)
+	}
+	for i, g := range groups {
+		if g.Group != wantOrder[i] {
+			t.Errorf("group %d = %q, want %q (groups must be sorted)", i, g.Group, wantOrder[i])
+		}
+		var ids []string
+		for _, tech := range g.Techniques {
+			ids = append(ids, tech.ExternalID)
+		}
+		if strings.Join(ids, ",") != strings.Join(want[g.Group], ",") {
+			t.Errorf("group %q techniques = %v, want %v", g.Group, ids, want[g.Group])
+		}
+	}
+}
+
+func TestGroupOutput_CSVByPlatformHasGroupColumn(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-group-output-by", "platform", "-csv")
+
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	if len(records) == 0 || records[0][0] != "Group" {
+		t.Fatalf("expected first CSV column to be Group; got:\n%s", stdout)
+	}
+	var rows []string
+	for _, r := range records[1:] {
+		rows = append(rows, r[0]+"/"+r[3])
+	}
+	want := "Linux/T1090,Network/T1090,Windows/T1090,Windows/T1565,macOS/T1090"
+	if got := strings.Join(rows, ","); got != want {
+		t.Errorf("group/technique rows = %s, want %s", got, want)
+	}
+}
+
+func TestGroupOutput_TableHasSectionPerGroup(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-group-output-by", "tactic")
+
+	prev := -1
+	for _, tactic := range []string{"command-and-control", "defense-evasion", "impact"} {
+		loc := regexp.MustCompile(`(?m)^TACTIC\s+` + tactic + `$`).FindStringIndex(stdout)
+		if loc == nil {
+			t.Fatalf("missing section for %s; stdout:\n%s\nstderr:\n%s", tactic, stdout, stderr)
+		}
+		if loc[0] < prev {
+			t.Errorf("section %s is out of order; stdout:\n%s", tactic, stdout)
+		}
+		prev = loc[0]
+	}
+}
+
+func TestGroupOutput_InvalidDimensionIsError(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	_, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-group-output-by", "color")
+	if !strings.Contains(stderr, `invalid -group-output-by "color"`) {
+		t.Errorf("expected error for unknown grouping dimension; stderr:\n%s", stderr)
+	}
+}
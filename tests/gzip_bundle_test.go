@@ -0,0 +1,80 @@
+// Тесты загрузки сжатого бандла (.json.gz, Content-Encoding: gzip).
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGzipBundle_DecompressedBeforeCaching(t *testing.T) {
+	bin := getBinary(t)
+	data, err := os.ReadFile(filepath.Join(repoRoot(t), "tests", "testdata", cacheFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	compressed := gzipBytes(t, data)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/encoded") {
+			// Сжатие на уровне HTTP — net/http распаковывает такой ответ сам
+			w.Header().Set("Content-Encoding", "gzip")
+		} else {
+			w.Header().Set("Content-Type", "application/gzip")
+		}
+		_, _ = w.Write(compressed)
+	}))
+	defer srv.Close()
+
+	for _, path := range []string{"/enterprise-attack.json.gz", "/encoded"} {
+		env := map[string]string{envMITRECacheDir: t.TempDir()}
+		stdout, stderr := runMitremit(t, bin, env, "-bundle-url", srv.URL+path,
+			"-mitigation", "M1037", "-count", "-retries", "0")
+		if strings.TrimSpace(stdout) != "5" {
+			t.Errorf("%s: expected 5 techniques from the compressed bundle; stdout:\n%s\nstderr:\n%s", path, stdout, stderr)
+		}
+	}
+}
+
+func TestGzipBundle_SizeLimitAppliesToDecompressedData(t *testing.T) {
+	bin := getBinary(t)
+	// ~200 КБ на проводе, больше 200 МБ после распаковки: начало бандла и пробелы, так что
+	// потоковый разбор доходит до предела размера, а не падает на первом байте
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte(`{"type": "bundle", "objects": [`))
+	chunk := bytes.Repeat([]byte(" "), 1<<20)
+	for range 201 {
+		_, _ = zw.Write(chunk)
+	}
+	_ = zw.Close()
+	bomb := buf.Bytes()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(bomb)
+	}))
+	defer srv.Close()
+
+	env := map[string]string{envMITRECacheDir: t.TempDir()}
+	_, stderr := runMitremit(t, bin, env, "-bundle-url", srv.URL+"/enterprise-attack.json.gz",
+		"-mitigation", "M1037", "-retries", "0")
+	if !strings.Contains(stderr, "bundle too large") {
+		t.Errorf("expected size limit on the decompressed bundle; stderr:\n%s", stderr)
+	}
+}
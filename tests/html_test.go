@@ -0,0 +1,29 @@
+// Тесты HTML-отчёта (-html).
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTML_LinksAndEscaping(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-html")
+	if !strings.HasPrefix(stdout, "<!DOCTYPE html>") || !strings.Contains(stdout, "</html>") {
+		t.Fatalf("expected a complete HTML page; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	// ID техники — ссылка на сайт ATT&CK из external_references
+	if !strings.Contains(stdout, `<a href="https://attack.mitre.org/techniques/T1071">T1071</a>`) {
+		t.Errorf("technique link missing:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, `<a href="https://attack.mitre.org/mitigations/M1037">M1037</a>`) {
+		t.Errorf("mitigation link missing:\n%s", stdout)
+	}
+	// Кавычки и апострофы из бандла экранированы
+	if strings.Contains(stdout, `"Relay"`) || !strings.Contains(stdout, "Proxy, &#34;Relay&#34; | Hop") {
+		t.Errorf("technique name not HTML-escaped:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "External ID&#39;s") {
+		t.Errorf("apostrophe not HTML-escaped:\n%s", stdout)
+	}
+}
@@ -0,0 +1,72 @@
+// Тесты проверки формата внешних ID (attack.ValidMitigationID, attack.ValidTechniqueID).
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"mitremit/attack"
+)
+
+func TestValidMitigationID(t *testing.T) {
+	cases := []struct {
+		in   string
+		want bool
+	}{
+		{"M1037", true},
+		{"m1037", true},
+		{"1037", true}, // голый номер дополняется префиксом
+		{"M0930", true},
+		{"M103", false},
+		{"M10370", false},
+		{"T1037", false},
+		{"M1037 ", false},
+		{"Filter Network Traffic", false},
+		{"", false},
+	}
+	for _, c := range cases {
+		if got := attack.ValidMitigationID(c.in); got != c.want {
+			t.Errorf("ValidMitigationID(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestValidTechniqueID(t *testing.T) {
+	cases := []struct {
+		in   string
+		want bool
+	}{
+		{"T1059", true},
+		{"T1059.001", true},
+		{"t1059.001", true},
+		{"T1059.01", false},
+		{"T1059.0011", false},
+		{"T105", false},
+		{"1059", false},
+		{"M1037", false},
+		{"", false},
+	}
+	for _, c := range cases {
+		if got := attack.ValidTechniqueID(c.in); got != c.want {
+			t.Errorf("ValidTechniqueID(%q) = %v, want %v", c.in, got, c.want)
+		}
+	}
+}
+
+func TestIDValidation_RejectedBeforeBundleLoad(t *testing.T) {
+	bin := getBinary(t)
+	// Пустая директория кэша и недоступный адрес: ошибка формата должна появиться без загрузки
+	env := map[string]string{envMITRECacheDir: t.TempDir()}
+	for _, args := range [][]string{
+		{"-mitigation", "M1037,M10"},
+		{"-compare", "M1037,X1"},
+		{"-technique", "T59"},
+		{"-detections", "T1071.1"},
+	} {
+		args = append(args, "-bundle-url", "http://127.0.0.1:1/enterprise-attack.json", "-retries", "0")
+		_, stderr := runMitremit(t, bin, env, args...)
+		if !strings.Contains(stderr, "invalid") || strings.Contains(stderr, "error fetching") {
+			t.Errorf("%v: expected an early invalid ID error; stderr:\n%s", args[:2], stderr)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Тесты снимка разобранного индекса (-index-cache) на локальном бандле из testdata.
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const indexSnapshotHit = ">>> index snapshot hit"
+
+func TestIndexCache_SnapshotReusedWhileBundleMatches(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	snapshot := filepath.Join(env[envMITRECacheDir], "enterprise-attack.index.gob")
+
+	want, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-json")
+	if want == "" {
+		t.Fatalf("no output without -index-cache; stderr:\n%s", stderr)
+	}
+	cold, _ := runMitremit(t, bin, env, "-mitigation", "M1040", "-json", "-index-cache")
+	if _, err := os.Stat(snapshot); err != nil {
+		t.Fatalf("index snapshot not written: %v", err)
+	}
+	warm, _ := runMitremit(t, bin, env, "-mitigation", "M1040", "-json", "-index-cache")
+	if cold != want || warm != want {
+		t.Errorf("-index-cache changed the output;\nwant:\n%s\ncold:\n%s\nwarm:\n%s", want, cold, warm)
+	}
+
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-index-cache", "-debug")
+	if !strings.Contains(stdout+stderr, indexSnapshotHit) {
+		t.Errorf("warm run must use the snapshot; output:\n%s%s", stdout, stderr)
+	}
+}
+
+func TestIndexCache_ChangedBundleInvalidatesSnapshot(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	runMitremit(t, bin, env, "-mitigation", "M1040", "-index-cache")
+
+	// Другой бандл (другой SHA-256): M1037 больше ничего не смягчает. Кладём его несжатым
+	// вместо gzip-кэша — утилита перенесёт его в кэш при следующем запуске
+	bundle := filepath.Join(env[envMITRECacheDir], "enterprise-attack.json")
+	if err := os.Remove(bundle + ".gz"); err != nil {
+		t.Fatal(err)
+	}
+	data := strings.ReplaceAll(string(fixtureBundle(t)), `"source_ref": "course-of-action--m1037"`, `"source_ref": "course-of-action--gone"`)
+	if err := os.WriteFile(bundle, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	_ = os.Chtimes(bundle, now, now)
+
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1037", "-index-cache", "-debug")
+	if strings.Contains(stdout+stderr, indexSnapshotHit) {
+		t.Errorf("snapshot of another bundle must not be used; output:\n%s%s", stdout, stderr)
+	}
+	stdout, _ = runMitremit(t, bin, env, "-mitigation", "M1037", "-count", "-index-cache")
+	if strings.TrimSpace(stdout) != "0" {
+		t.Errorf("expected the new bundle to be parsed (0 techniques), got %q", stdout)
+	}
+}
+
+func TestIndexCache_CorruptSnapshotFallsBackToParse(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	snapshot := filepath.Join(env[envMITRECacheDir], "enterprise-attack.index.gob")
+	if err := os.WriteFile(snapshot, []byte("not a gob stream"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-count", "-index-cache")
+	if strings.TrimSpace(stdout) != "2" {
+		t.Errorf("corrupt snapshot must be ignored; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+}
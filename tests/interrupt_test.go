@@ -0,0 +1,109 @@
+// Тесты прерывания загрузки по SIGINT (код 130, без временных файлов в кэше).
+package tests
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestInterrupt_AbortsDownloadWithCode130(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGINT delivery via os.Process.Signal is Unix-only")
+	}
+	bin := getBinary(t)
+	started := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Отдаём начало бандла и «зависаем»: загрузка не закончится сама
+		w.Header().Set("Content-Length", "30000000")
+		_, _ = w.Write([]byte(`{"type": "bundle", "objects": [`))
+		w.(http.Flusher).Flush()
+		started <- struct{}{}
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	cmd := exec.Command(bin, "-bundle-url", srv.URL+"/enterprise-attack.json", "-mitigation", "M1037",
+		"-retries", "0", "-timeout", "1m")
+	cmd.Dir = repoRoot(t)
+	cmd.Env = append(os.Environ(), envMITRECacheDir+"="+dir)
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-started:
+	case <-time.After(10 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatal("download did not start")
+	}
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 130 {
+			t.Errorf("expected exit code 130 after SIGINT, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatal("process did not exit promptly after SIGINT")
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmp) != 0 {
+		t.Errorf("temporary cache files left behind: %v", tmp)
+	}
+}
+
+func TestInterrupt_DuringRetryBackoff(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SIGINT delivery via os.Process.Signal is Unix-only")
+	}
+	bin := getBinary(t)
+	requests := make(chan struct{}, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- struct{}{}
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	cmd := exec.Command(bin, "-bundle-url", srv.URL+"/enterprise-attack.json", "-mitigation", "M1037",
+		"-retries", "3", "-retry-delay", "1m")
+	cmd.Dir = repoRoot(t)
+	cmd.Env = append(os.Environ(), envMITRECacheDir+"="+t.TempDir())
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-requests:
+	case <-time.After(10 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatal("download did not start")
+	}
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 130 {
+			t.Errorf("expected exit code 130 after SIGINT, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		_ = cmd.Process.Kill()
+		t.Fatal("SIGINT during the retry delay did not stop the process")
+	}
+	if n := len(requests); n != 0 {
+		t.Errorf("%d retries were sent after SIGINT", n)
+	}
+}
@@ -0,0 +1,54 @@
+// Тесты графового JSON (-json-graph).
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type jsonGraphOut struct {
+	Mitigation struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"mitigation"`
+	Techniques []struct {
+		ExternalID string `json:"external_id"`
+	} `json:"techniques"`
+	Edges []struct {
+		From string `json:"from"`
+		To   string `json:"to"`
+	} `json:"edges"`
+}
+
+func TestJSONGraph_EdgePerTechnique(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-json-graph")
+	var g jsonGraphOut
+	if err := json.Unmarshal([]byte(stdout), &g); err != nil {
+		t.Fatalf("output is not a graph object: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	if g.Mitigation.ID != "M1037" || g.Mitigation.Name == "" {
+		t.Errorf("unexpected mitigation node: %+v", g.Mitigation)
+	}
+	if len(g.Techniques) == 0 || len(g.Edges) != len(g.Techniques) {
+		t.Fatalf("expected one edge per technique, got %d techniques and %d edges",
+			len(g.Techniques), len(g.Edges))
+	}
+	for i, e := range g.Edges {
+		if e.From != "M1037" || e.To != g.Techniques[i].ExternalID {
+			t.Errorf("edge %d = %+v, want M1037 -> %s", i, e, g.Techniques[i].ExternalID)
+		}
+	}
+}
+
+func TestJSONGraph_ArrayForSeveralMitigations(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037,M1040", "-json-graph")
+	var gs []jsonGraphOut
+	if err := json.Unmarshal([]byte(stdout), &gs); err != nil {
+		t.Fatalf("expected array of graph objects: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	if len(gs) != 2 || gs[0].Mitigation.ID != "M1037" || gs[1].Mitigation.ID != "M1040" {
+		t.Errorf("unexpected graphs: %+v", gs)
+	}
+}
@@ -0,0 +1,69 @@
+// Тесты JSON Schema вывода (-json-schema).
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type schemaDoc struct {
+	Type  string `json:"type"`
+	Items struct {
+		Type       string `json:"type"`
+		Properties map[string]struct {
+			Type string `json:"type"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	} `json:"items"`
+}
+
+func TestJSONSchema_DescribesJSONOutput(t *testing.T) {
+	bin := getBinary(t)
+	out, stderr := runMitremit(t, bin, nil, "-json-schema")
+	var schema schemaDoc
+	if err := json.Unmarshal([]byte(out), &schema); err != nil {
+		t.Fatalf("schema is not JSON: %v\nstdout:\n%s\nstderr:\n%s", err, out, stderr)
+	}
+	if schema.Type != "array" || schema.Items.Type != "object" {
+		t.Fatalf("expected array of objects, got %s of %s", schema.Type, schema.Items.Type)
+	}
+	for _, field := range []string{"external_id", "name", "tactics", "platforms"} {
+		if _, ok := schema.Items.Properties[field]; !ok {
+			t.Errorf("schema lacks property %q", field)
+		}
+	}
+
+	// Реальный вывод -json со всеми необязательными полями должен соответствовать схеме
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-json",
+		"-show-description", "-show-url")
+	var items []map[string]any
+	if err := json.Unmarshal([]byte(stdout), &items); err != nil || len(items) == 0 {
+		t.Fatalf("bad -json output: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	for _, item := range items {
+		for _, req := range schema.Items.Required {
+			if _, ok := item[req]; !ok {
+				t.Errorf("item %v lacks required field %q", item["external_id"], req)
+			}
+		}
+		for key, val := range item {
+			prop, ok := schema.Items.Properties[key]
+			if !ok {
+				t.Errorf("field %q is not described by the schema", key)
+				continue
+			}
+			var got string
+			switch val.(type) {
+			case string:
+				got = "string"
+			case []any:
+				got = "array"
+			default:
+				got = "other"
+			}
+			if got != prop.Type {
+				t.Errorf("field %q: value is %s, schema says %s", key, got, prop.Type)
+			}
+		}
+	}
+}
@@ -0,0 +1,35 @@
+// Тесты вывода JSON Lines (-jsonl).
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestJSONL_OneSelfDescribingObjectPerLine(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-jsonl", "-mitigation", "M1037,M1040")
+	lines := strings.Split(strings.TrimRight(stdout, "\n"), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("expected several JSON lines; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	seen := make(map[string]bool)
+	for _, line := range lines {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("line is not a standalone JSON object (%v): %q", err, line)
+		}
+		mit, _ := rec["mitigation"].(string)
+		if mit == "" {
+			t.Errorf("record without mitigation ID: %q", line)
+		}
+		if _, ok := rec["external_id"]; !ok {
+			t.Errorf("record without external_id (field names must match -json): %q", line)
+		}
+		seen[mit] = true
+	}
+	if !seen["M1037"] || !seen["M1040"] {
+		t.Errorf("expected records for both M1037 and M1040; got %v", seen)
+	}
+}
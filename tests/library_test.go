@@ -0,0 +1,141 @@
+// Тесты библиотечного API пакета attack (без запуска бинарника).
+package tests
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"mitremit/attack"
+)
+
+func TestLibrary_TechniquesForMitigation(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", cacheFilename))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	idx, err := attack.ParseBundle(raw)
+	if err != nil {
+		t.Fatalf("ParseBundle: %v", err)
+	}
+	techs, ok := idx.TechniquesForMitigation("M1037")
+	if !ok {
+		t.Fatal("M1037 not found in fixture bundle")
+	}
+	var ids []string
+	for _, tech := range techs {
+		ids = append(ids, tech.ID)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i-1] > ids[i] {
+			t.Errorf("techniques not sorted by ID: %v", ids)
+		}
+	}
+	if len(ids) == 0 {
+		t.Errorf("expected techniques for M1037, got none")
+	}
+	if _, ok := idx.TechniquesForMitigation("M9999"); ok {
+		t.Errorf("expected ok=false for unknown mitigation")
+	}
+}
+
+func TestLibrary_TechniquesForMitigation_ReplacesRevoked(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", cacheFilename))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	idx, err := attack.ParseBundle(raw)
+	if err != nil {
+		t.Fatalf("ParseBundle: %v", err)
+	}
+	// M1099 указывает на отозванную T1043 (revoked-by → T1071): как и в CLI, в результате преемник
+	techs, ok := idx.TechniquesForMitigation("M1099")
+	if !ok {
+		t.Fatal("M1099 not found in fixture bundle")
+	}
+	var got []string
+	for _, tech := range techs {
+		got = append(got, tech.ID+"<"+tech.Replaces)
+	}
+	want := []string{"T1071<T1043", "T1099<"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("techniques = %v, want %v", got, want)
+	}
+}
+
+func TestLibrary_ParseStreamMatchesParseBundle(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", cacheFilename))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	want, err := attack.ParseBundle(raw)
+	if err != nil {
+		t.Fatalf("ParseBundle: %v", err)
+	}
+	got, err := attack.ParseStream(strings.NewReader(string(raw)), 4)
+	if err != nil {
+		t.Fatalf("ParseStream: %v", err)
+	}
+	if len(got.Rels) != len(want.Rels) || len(got.Techniques) != len(want.Techniques) ||
+		len(got.Mitigations) != len(want.Mitigations) || got.SpecVersion != want.SpecVersion {
+		t.Errorf("ParseStream index differs from ParseBundle")
+	}
+	for i := range want.Rels {
+		if got.Rels[i].ID != want.Rels[i].ID {
+			t.Fatalf("relationship order differs at %d: %s vs %s", i, got.Rels[i].ID, want.Rels[i].ID)
+		}
+	}
+	if _, err := attack.ParseBundle([]byte(`{"type":"report","objects":[]}`)); err == nil {
+		t.Errorf("ParseBundle must reject a non-bundle")
+	}
+}
+
+func TestLibrary_UsersOf(t *testing.T) {
+	idx := attack.NewIndex()
+	for i, src := range []string{"intrusion-set--b", "intrusion-set--a", "intrusion-set--b"} {
+		idx.Rels = append(idx.Rels, attack.Relationship{ID: fmt.Sprint("relationship--", i),
+			RelationshipType: "uses", SourceRef: src, TargetRef: "attack-pattern--t"})
+	}
+	idx.Rels = append(idx.Rels, attack.Relationship{RelationshipType: "mitigates",
+		SourceRef: "course-of-action--m", TargetRef: "attack-pattern--t"})
+	got := strings.Join(idx.UsersOf("attack-pattern--t"), ",")
+	if got != "intrusion-set--b,intrusion-set--a" {
+		t.Errorf("UsersOf = %s, want users without repeats in relationship order", got)
+	}
+	if users := idx.UsersOf("attack-pattern--none"); len(users) != 0 {
+		t.Errorf("UsersOf for an unused technique = %v", users)
+	}
+}
+
+func TestLibrary_MitigatedTechniques_TiebreakBySTIXID(t *testing.T) {
+	idx := attack.NewIndex()
+	ref := []attack.ExternalReference{{SourceName: "mitre-attack", ExternalID: "T1000"}}
+	for _, id := range []string{"attack-pattern--b", "attack-pattern--a"} {
+		idx.Techniques[id] = attack.AttackPattern{ID: id, ExternalRefs: ref}
+		idx.Rels = append(idx.Rels, attack.Relationship{RelationshipType: "mitigates",
+			SourceRef: "course-of-action--m", TargetRef: id})
+	}
+	mts := idx.MitigatedTechniques("course-of-action--m", true, nil)
+	if len(mts) != 2 || mts[0].Technique.ID != "attack-pattern--a" || mts[1].Technique.ID != "attack-pattern--b" {
+		t.Errorf("equal external IDs must be ordered by STIX ID: %+v", mts)
+	}
+}
+
+func TestLibrary_TacticsFromKillChain_SortedAndDeduplicated(t *testing.T) {
+	phases := []attack.KillChainPhase{
+		{KillChainName: "mitre-attack", PhaseName: "defense-evasion"},
+		{KillChainName: "mitre-attack", PhaseName: "command-and-control"},
+		{KillChainName: "lockheed", PhaseName: "delivery"},
+		{KillChainName: "mitre-attack", PhaseName: "defense-evasion"},
+	}
+	want := []string{"command-and-control", "defense-evasion"}
+	if got := attack.TacticsFromKillChain(phases); !slices.Equal(got, want) {
+		t.Errorf("TacticsFromKillChain = %v, want %v", got, want)
+	}
+	if got := attack.NewTechnique(attack.AttackPattern{KillChainPhases: phases}).Tactics; !slices.Equal(got, want) {
+		t.Errorf("NewTechnique tactics = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,30 @@
+// Тесты списка митигаций (-list-mitigations).
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestListMitigations_JSONSortedByID(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-list-mitigations", "-json")
+	var mits []struct {
+		ExternalID string `json:"external_id"`
+		Name       string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &mits); err != nil {
+		t.Fatalf("expected JSON array (err %v); stdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	if len(mits) < 2 {
+		t.Fatalf("expected every mitigation of the fixture bundle; got %d", len(mits))
+	}
+	for i := 1; i < len(mits); i++ {
+		if mits[i-1].ExternalID >= mits[i].ExternalID {
+			t.Errorf("mitigations not sorted by ID: %s before %s", mits[i-1].ExternalID, mits[i].ExternalID)
+		}
+	}
+	if mits[0].ExternalID != "M1037" || mits[0].Name != "Filter Network Traffic" {
+		t.Errorf("first mitigation = %+v, want M1037 Filter Network Traffic", mits[0])
+	}
+}
@@ -0,0 +1,21 @@
+// Тесты списка платформ (-list-platforms).
+package tests
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestListPlatforms_UniqueSorted(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-list-platforms", "-json")
+	var got []string
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("expected JSON array (err %v); stdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	want := []string{"Containers", "Linux", "macOS", "Network", "Windows"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("platforms = %v, want %v", got, want)
+	}
+}
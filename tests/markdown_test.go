@@ -0,0 +1,30 @@
+// Тесты Markdown-таблицы (-markdown).
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdown_HeadingTableAndPipeEscaping(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-markdown")
+	if !strings.HasPrefix(stdout, "## M1037 — Filter Network Traffic\n") {
+		t.Fatalf("expected mitigation heading first; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if !strings.Contains(stdout, "| --- | --- | --- |") {
+		t.Errorf("header separator row missing:\n%s", stdout)
+	}
+	// Название "Proxy, \"Relay\" | Hop" содержит "|": он должен быть экранирован
+	if !strings.Contains(stdout, `| T1090 | Proxy, "Relay" \| Hop |`) {
+		t.Errorf("pipe in technique name not escaped:\n%s", stdout)
+	}
+	for _, line := range strings.Split(strings.TrimSpace(stdout), "\n") {
+		if strings.HasPrefix(line, "|") {
+			cells := strings.Count(strings.ReplaceAll(line, `\|`, ""), "|") - 1
+			if cells != 3 {
+				t.Errorf("row has %d cells, want 3: %q", cells, line)
+			}
+		}
+	}
+}
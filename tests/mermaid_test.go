@@ -0,0 +1,31 @@
+// Тесты вывода Mermaid (-mermaid).
+package tests
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestMermaid_SanitizedIDsAndEscapedLabels(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-mermaid")
+	if !strings.HasPrefix(stdout, "graph LR\n") {
+		t.Fatalf("expected graph LR header; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if !strings.Contains(stdout, "M1037 -->|mitigates| T1071_001\n") {
+		t.Errorf("edge with sanitized sub-technique ID missing:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, `T1090("T1090<br/>Proxy, #quot;Relay#quot; | Hop")`) {
+		t.Errorf("quotes in label not escaped:\n%s", stdout)
+	}
+	// ID узлов — только буквы, цифры и '_'
+	reNode := regexp.MustCompile(`^\s+(\S+?)[\[(]"`)
+	for _, line := range strings.Split(stdout, "\n") {
+		if m := reNode.FindStringSubmatch(line); m != nil {
+			if !regexp.MustCompile(`^[A-Za-z0-9_]+$`).MatchString(m[1]) {
+				t.Errorf("node ID %q is not alphanumeric", m[1])
+			}
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Тесты замеров фаз (-metrics).
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMetrics_PhasesOnStderrOnly(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-count", "-metrics")
+	if strings.TrimSpace(stdout) != "5" {
+		t.Errorf("-metrics must not change stdout; got:\n%s", stdout)
+	}
+	for _, phase := range []string{"metrics:", "cache read", "parse", "extraction", "output", "total"} {
+		if !strings.Contains(stderr, phase) {
+			t.Errorf("stderr lacks %q:\n%s", phase, stderr)
+		}
+	}
+	if strings.Contains(stderr, "download") {
+		t.Errorf("fresh cache must not report a download phase:\n%s", stderr)
+	}
+}
+
+func TestMetrics_OffByDefault(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-count")
+	if strings.Contains(stderr, "metrics:") {
+		t.Errorf("metrics printed without -metrics:\n%s", stderr)
+	}
+}
@@ -0,0 +1,62 @@
+// Тесты форм записи ID митигации: M1037, m1037 и голый номер 1037.
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"mitremit/attack"
+)
+
+func TestMitigationIDForms_Normalize(t *testing.T) {
+	cases := []struct{ in, want string }{
+		{"1037", "M1037"},
+		{"0930", "M0930"},
+		{"M1037", "M1037"},
+		{"m1037", "m1037"},
+		{"T1059", "T1059"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		if got := attack.NormalizeMitigationID(c.in); got != c.want {
+			t.Errorf("NormalizeMitigationID(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestMitigationIDForms_LibraryResolvesAllForms(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", cacheFilename))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	idx, err := attack.ParseBundle(raw)
+	if err != nil {
+		t.Fatalf("ParseBundle: %v", err)
+	}
+	want := idx.MitigationByExtID("M1037")
+	if want == "" {
+		t.Fatal("M1037 not found in fixture bundle")
+	}
+	for _, in := range []string{"1037", "m1037", "M1037"} {
+		if got := idx.MitigationByExtID(in); got != want {
+			t.Errorf("MitigationByExtID(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMitigationIDForms_CLIOutputIdentical(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	ref, stderr := runMitremit(t, bin, env, "-mitigation", "M1037", "-csv")
+	if !strings.Contains(ref, "M1037,Filter Network Traffic") {
+		t.Fatalf("unexpected output for M1037; stdout:\n%s\nstderr:\n%s", ref, stderr)
+	}
+	for _, in := range []string{"1037", "m1037"} {
+		got, stderr := runMitremit(t, bin, env, "-mitigation", in, "-csv")
+		if got != ref {
+			t.Errorf("-mitigation %s output differs from M1037:\n%s\nstderr:\n%s", in, got, stderr)
+		}
+	}
+}
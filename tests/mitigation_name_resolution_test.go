@@ -0,0 +1,65 @@
+// Тесты детерминированного поиска митигации по названию (-mitigation-name).
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// sharedNameBundle — "Shared Name" носят две разные митигации (M9001, M9002), "Versioned" —
+// два объекта одной митигации M9003, из которых действующий — более новый.
+const sharedNameBundle = `{"type": "bundle", "spec_version": "2.1", "objects": [
+  {"type": "course-of-action", "id": "course-of-action--m9001", "name": "Shared Name", "external_references": [{"source_name": "mitre-attack", "external_id": "M9001"}]},
+  {"type": "course-of-action", "id": "course-of-action--m9002", "name": "shared name", "external_references": [{"source_name": "mitre-attack", "external_id": "M9002"}]},
+  {"type": "course-of-action", "id": "course-of-action--m9003-old", "name": "Versioned", "modified": "2022-01-01T00:00:00.000Z", "external_references": [{"source_name": "mitre-attack", "external_id": "M9003"}]},
+  {"type": "course-of-action", "id": "course-of-action--m9003-new", "name": "Versioned", "modified": "2024-01-01T00:00:00.000Z", "external_references": [{"source_name": "mitre-attack", "external_id": "M9003"}]},
+  {"type": "attack-pattern", "id": "attack-pattern--t1071", "name": "Application Layer Protocol", "external_references": [{"source_name": "mitre-attack", "external_id": "T1071"}]},
+  {"type": "attack-pattern", "id": "attack-pattern--t1565", "name": "Data Manipulation", "external_references": [{"source_name": "mitre-attack", "external_id": "T1565"}]},
+  {"type": "relationship", "id": "relationship--old", "relationship_type": "mitigates", "source_ref": "course-of-action--m9003-old", "target_ref": "attack-pattern--t1071"},
+  {"type": "relationship", "id": "relationship--new", "relationship_type": "mitigates", "source_ref": "course-of-action--m9003-new", "target_ref": "attack-pattern--t1565"}
+]}`
+
+func writeSharedNameBundle(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	if err := os.WriteFile(path, []byte(sharedNameBundle), 0o644); err != nil {
+		t.Fatalf("write bundle: %v", err)
+	}
+	return path
+}
+
+func TestMitigationName_AmbiguousNameIsError(t *testing.T) {
+	bin := getBinary(t)
+	path := writeSharedNameBundle(t)
+	_, stderr := runMitremit(t, bin, nil, "-bundle-file", path, "-mitigation-name", "SHARED NAME")
+	if !strings.Contains(stderr, `mitigation name "SHARED NAME" is ambiguous (2 mitigations)`) {
+		t.Fatalf("expected ambiguity error; stderr:\n%s", stderr)
+	}
+	if i, j := strings.Index(stderr, "M9001"), strings.Index(stderr, "M9002"); i < 0 || j < i {
+		t.Errorf("expected both candidates listed by ID; stderr:\n%s", stderr)
+	}
+	if _, code := runExitCodeEnv(t, bin, nil, "-bundle-file", path, "-mitigation-name", "shared name"); code != 1 {
+		t.Errorf("exit code %d, want 1", code)
+	}
+}
+
+func TestMitigationName_VersionedObjectsStable(t *testing.T) {
+	bin := getBinary(t)
+	path := writeSharedNameBundle(t)
+	// Порядок обхода map случаен — несколько запусков должны дать одну и ту же митигацию
+	for range 5 {
+		stdout, stderr := runMitremit(t, bin, nil, "-bundle-file", path, "-mitigation-name", "versioned", "-json")
+		var techs []struct {
+			ExternalID string `json:"external_id"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &techs); err != nil {
+			t.Fatalf("expected JSON array (err %v); stdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+		}
+		if len(techs) != 1 || techs[0].ExternalID != "T1565" {
+			t.Fatalf("expected T1565 from the newer M9003 object, got %+v", techs)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+// Тесты выбора митигаций по регулярному выражению (-mitigation-regex).
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestMitigationRegex_AggregatesMatches(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation-regex", "(?i)^(filter|behavior)", "-json")
+	var techs []struct {
+		ExternalID string `json:"external_id"`
+		Mitigation string `json:"mitigation"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil {
+		t.Fatalf("bad JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	perMit := map[string]int{}
+	for _, tech := range techs {
+		perMit[tech.Mitigation]++
+	}
+	if perMit["M1037"] != 5 || perMit["M1040"] != 2 || len(perMit) != 2 {
+		t.Errorf("techniques per mitigation = %v, want M1037:5 M1040:2", perMit)
+	}
+	if strings.Contains(stderr, "matches all") {
+		t.Errorf("unexpected match-all warning; stderr:\n%s", stderr)
+	}
+}
+
+func TestMitigationRegex_MatchAllWarns(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation-regex", ".*", "-count")
+	if !strings.Contains(stderr, "matches all 3 mitigations") {
+		t.Errorf("expected a warning when the pattern matches everything; stderr:\n%s", stderr)
+	}
+	_, stderr = runMitremit(t, bin, fixtureCacheDir(t), "-mitigation-regex", ".*", "-count", "-quiet")
+	if strings.Contains(stderr, "matches all") {
+		t.Errorf("-quiet should suppress the warning; stderr:\n%s", stderr)
+	}
+}
+
+func TestMitigationRegex_InvalidPatternAndNoMatch(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation-regex", "(unclosed")
+	if !strings.Contains(stderr, "invalid -mitigation-regex") {
+		t.Errorf("expected a compile error; stderr:\n%s", stderr)
+	}
+	_, code := runExitCode(t, bin, "-mitigation-regex", "^Nothing Like This$")
+	if code != 1 {
+		t.Errorf("exit code = %d, want 1 when nothing matches", code)
+	}
+}
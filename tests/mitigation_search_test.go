@@ -0,0 +1,28 @@
+// Тесты поиска митигации по подстроке названия (-mitigation-search).
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMitigationSearch_SingleMatchRunsQuery(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation-search", "network TRAFFIC")
+	if !strings.Contains(stdout, "(M1037)") || !strings.Contains(stdout, "T1071") {
+		t.Errorf("expected M1037 table for unique substring match; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+}
+
+func TestMitigationSearch_SeveralMatchesListedOnStderr(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation-search", "e")
+	if stdout != "" {
+		t.Errorf("ambiguous search must not print results; stdout:\n%s", stdout)
+	}
+	for _, id := range []string{"M1037", "M1040"} {
+		if !strings.Contains(stderr, id) {
+			t.Errorf("candidate %s missing from stderr:\n%s", id, stderr)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+// Тесты фильтра по метке modified (-modified-since).
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestModifiedSince_KeepsOnlyRecentlyModified(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1037", "-modified-since", "2024-01-01", "-csv")
+	if !strings.Contains(stdout, ",T1071,") {
+		t.Errorf("T1071 (modified 2024-02-01) missing; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	for _, old := range []string{"T1071.001", "T1090", "T1565"} {
+		if strings.Contains(stdout, ","+old+",") {
+			t.Errorf("%s was modified before 2024-01-01 but is listed:\n%s", old, stdout)
+		}
+	}
+	// Граница включительна
+	stdout, _ = runMitremit(t, bin, env, "-mitigation", "M1037", "-modified-since", "2024-02-01T00:00:00Z", "-csv")
+	if !strings.Contains(stdout, ",T1071,") {
+		t.Errorf("technique modified exactly at the bound must be kept:\n%s", stdout)
+	}
+}
+
+func TestModifiedSince_InvalidDateIsError(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-modified-since", "last week")
+	if !strings.Contains(stderr, "invalid -modified-since") {
+		t.Errorf("expected error about the date; stderr:\n%s", stderr)
+	}
+}
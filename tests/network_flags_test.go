@@ -0,0 +1,73 @@
+// Тесты сетевых флагов (-timeout, -proxy): проверка значений и загрузка через прокси.
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNetworkFlags_NonPositiveTimeoutIsError(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-timeout", "0s", "-mitigation", "M1037")
+	if !strings.Contains(stderr, "-timeout must be positive") {
+		t.Errorf("expected error about non-positive timeout; stderr:\n%s", stderr)
+	}
+}
+
+func TestNetworkFlags_MalformedProxyIsError(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-proxy", "proxy.corp:3128", "-mitigation", "M1037")
+	if !strings.Contains(stderr, "invalid -proxy") {
+		t.Errorf("expected error about malformed proxy URL; stderr:\n%s", stderr)
+	}
+}
+
+// countingUpstream — поддельный upstream, который отдаёт тестовый бандл и считает запросы.
+func countingUpstream(t *testing.T) (map[string]string, *atomic.Int32) {
+	t.Helper()
+	data := fixtureBundle(t)
+	var hits atomic.Int32
+	env := fakeUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		_, _ = w.Write(data)
+	}))
+	env[envMITRECacheDir] = t.TempDir()
+	return env, &hits
+}
+
+func TestNetworkFlags_EnvProxyUsed(t *testing.T) {
+	bin := getBinary(t)
+	env, hits := countingUpstream(t)
+	stdout, stderr := runMitremit(t, bin, env, "-retries", "0", "-mitigation", "M1040", "-count")
+	if strings.TrimSpace(stdout) != "2" || hits.Load() != 1 {
+		t.Errorf("expected the download to go through HTTPS_PROXY; requests=%d stdout:\n%s\nstderr:\n%s",
+			hits.Load(), stdout, stderr)
+	}
+}
+
+func TestNetworkFlags_ProxyFlagOverridesEnv(t *testing.T) {
+	bin := getBinary(t)
+	env, hits := countingUpstream(t)
+	cliProxy := env["HTTPS_PROXY"]
+
+	// Прокси из окружения отклоняет все запросы и считает их
+	var envHits atomic.Int32
+	envProxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		envHits.Add(1)
+		http.Error(w, "env proxy must not be used", http.StatusBadGateway)
+	}))
+	t.Cleanup(envProxy.Close)
+	env["HTTPS_PROXY"], env["https_proxy"] = envProxy.URL, envProxy.URL
+
+	stdout, stderr := runMitremit(t, bin, env, "-proxy", cliProxy, "-retries", "0", "-mitigation", "M1040", "-count")
+	if strings.TrimSpace(stdout) != "2" || hits.Load() != 1 {
+		t.Errorf("expected the download to go through -proxy; requests=%d stdout:\n%s\nstderr:\n%s",
+			hits.Load(), stdout, stderr)
+	}
+	if envHits.Load() != 0 {
+		t.Errorf("-proxy must override HTTPS_PROXY, but the env proxy got %d requests", envHits.Load())
+	}
+}
@@ -0,0 +1,28 @@
+// Тесты общего графа nGQL для нескольких митигаций.
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNGQL_BatchDeduplicatesTechniqueVertices(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037,M1040", "-ngql")
+	// T1090 и T1565 смягчают обе митигации: одна вершина, но ребро от каждой митигации
+	for _, id := range []string{"T1090", "T1565"} {
+		vertex := "INSERT VERTEX technique(id, name, tactics) VALUES `" + id + "`:"
+		if n := strings.Count(stdout, vertex); n != 1 {
+			t.Errorf("technique %s inserted %d times, want 1; stdout:\n%s\nstderr:\n%s", id, n, stdout, stderr)
+		}
+		for _, mit := range []string{"M1037", "M1040"} {
+			edge := "INSERT EDGE mitigates() VALUES `" + mit + "` -> `" + id + "`;"
+			if !strings.Contains(stdout, edge) {
+				t.Errorf("missing edge %s", edge)
+			}
+		}
+	}
+	if n := strings.Count(stdout, "INSERT VERTEX mitigation"); n != 2 {
+		t.Errorf("expected 2 mitigation vertices, got %d", n)
+	}
+}
@@ -0,0 +1,98 @@
+// Тесты отчёта -output-dir и manifest.json на локальном бандле из testdata.
+package tests
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type reportManifestJSON struct {
+	ToolVersion  string            `json:"tool_version"`
+	GeneratedAt  string            `json:"generated_at"`
+	Query        map[string]string `json:"query"`
+	BundleSHA256 string            `json:"bundle_sha256"`
+	Files        []struct {
+		Name   string `json:"name"`
+		Format string `json:"format"`
+		Size   int    `json:"size"`
+		SHA256 string `json:"sha256"`
+	} `json:"files"`
+}
+
+func sha256HexOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestOutputDir_WritesReportsAndManifest(t *testing.T) {
+	bin := getBinary(t)
+	dir := filepath.Join(t.TempDir(), "report")
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-output-dir", dir)
+
+	data, err := os.ReadFile(filepath.Join(dir, "manifest.json"))
+	if err != nil {
+		t.Fatalf("manifest.json not written: %v\nstderr:\n%s", err, stderr)
+	}
+	var m reportManifestJSON
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatalf("manifest.json is not valid JSON: %v\n%s", err, data)
+	}
+	if m.ToolVersion == "" || m.GeneratedAt == "" {
+		t.Errorf("manifest lacks tool_version/generated_at: %s", data)
+	}
+	if m.Query["mitigation"] != "M1040" {
+		t.Errorf("manifest query = %v, want mitigation=M1040", m.Query)
+	}
+	fixture, err := os.ReadFile(filepath.Join(repoRoot(t), "tests", "testdata", "enterprise-attack.json"))
+	if err != nil {
+		t.Fatalf("read fixture bundle: %v", err)
+	}
+	if m.BundleSHA256 != sha256HexOf(fixture) {
+		t.Errorf("bundle_sha256 = %s, want SHA-256 of the bundle %s", m.BundleSHA256, sha256HexOf(fixture))
+	}
+
+	// Каждый файл из манифеста существует, а размер и хэш совпадают с содержимым
+	formats := make(map[string]bool)
+	for _, f := range m.Files {
+		formats[f.Format] = true
+		content, err := os.ReadFile(filepath.Join(dir, f.Name))
+		if err != nil {
+			t.Errorf("manifest lists %s, but it cannot be read: %v", f.Name, err)
+			continue
+		}
+		if f.Size != len(content) || f.SHA256 != sha256HexOf(content) {
+			t.Errorf("%s: manifest size/sha256 = %d/%s, actual %d/%s", f.Name, f.Size, f.SHA256, len(content), sha256HexOf(content))
+		}
+	}
+	for _, want := range []string{"table", "json", "csv", "ngql"} {
+		if !formats[want] {
+			t.Errorf("manifest has no %s report; files: %+v", want, m.Files)
+		}
+	}
+
+	report, err := os.ReadFile(filepath.Join(dir, "report.json"))
+	if err != nil {
+		t.Fatalf("read report.json: %v", err)
+	}
+	if got := strings.Join(jsonTechniqueIDs(t, string(report), stderr), ","); got != "T1090,T1565" {
+		t.Errorf("report.json techniques = %s, want T1090,T1565", got)
+	}
+}
+
+func TestOutputDir_UnwritableDirectoryIsError(t *testing.T) {
+	bin := getBinary(t)
+	// Путь внутри обычного файла создать нельзя
+	file := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(file, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-output-dir", filepath.Join(file, "report"))
+	if !strings.Contains(stderr, "error writing report") {
+		t.Errorf("expected report write error; stderr:\n%s", stderr)
+	}
+}
@@ -0,0 +1,39 @@
+// Тесты записи результата в файл (-output).
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestOutput_WritesJSONFileWithPrivateMode(t *testing.T) {
+	bin := getBinary(t)
+	path := filepath.Join(t.TempDir(), "result.json")
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-json", "-mitigation", "M1037", "-output", path)
+	if stdout != "" {
+		t.Errorf("expected empty stdout with -output; got:\n%s", stdout)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("output file not written: %v; stderr:\n%s", err, stderr)
+	}
+	var techs []map[string]any
+	if err := json.Unmarshal(data, &techs); err != nil || len(techs) == 0 {
+		t.Errorf("expected non-empty JSON array in output file (err %v):\n%s", err, data)
+	}
+	if runtime.GOOS != "windows" {
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := info.Mode().Perm(); perm != 0o600 {
+			t.Errorf("output file mode = %o, want 600", perm)
+		}
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("temporary file %s.tmp left behind", path)
+	}
+}
@@ -0,0 +1,105 @@
+// Параллельный разбор объектов бандла (attack.BuildIndexParallel): совпадение с
+// последовательным разбором и бенчмарк на бандле размера реального enterprise-attack.
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"mitremit/attack"
+)
+
+// syntheticBundle строит бандл с числом объектов порядка enterprise-attack.json: техники
+// с описаниями, митигации, группы, ПО и ~20 тыс. связей.
+func syntheticBundle(tb testing.TB) attack.Bundle {
+	tb.Helper()
+	const (
+		techniques    = 800
+		mitigations   = 270
+		groups        = 160
+		software      = 700
+		relationships = 20000
+	)
+	desc := strings.Repeat("Adversaries may abuse this behavior to achieve their goals. ", 20)
+	var objs []any
+	for i := range techniques {
+		objs = append(objs, map[string]any{
+			"type": "attack-pattern", "id": fmt.Sprintf("attack-pattern--%d", i),
+			"name": fmt.Sprintf("Technique %d", i), "description": desc,
+			"x_mitre_platforms":   []string{"Windows", "Linux", "macOS"},
+			"kill_chain_phases":   []map[string]string{{"kill_chain_name": "mitre-attack", "phase_name": "execution"}},
+			"external_references": []map[string]string{{"source_name": "mitre-attack", "external_id": fmt.Sprintf("T%04d", i)}},
+		})
+	}
+	for i := range mitigations {
+		objs = append(objs, map[string]any{
+			"type": "course-of-action", "id": fmt.Sprintf("course-of-action--%d", i),
+			"name": fmt.Sprintf("Mitigation %d", i), "description": desc,
+			"external_references": []map[string]string{{"source_name": "mitre-attack", "external_id": fmt.Sprintf("M%04d", i)}},
+		})
+	}
+	for i := range groups {
+		objs = append(objs, map[string]any{
+			"type": "intrusion-set", "id": fmt.Sprintf("intrusion-set--%d", i), "name": fmt.Sprintf("Group %d", i),
+			"external_references": []map[string]string{{"source_name": "mitre-attack", "external_id": fmt.Sprintf("G%04d", i)}},
+		})
+	}
+	for i := range software {
+		objs = append(objs, map[string]any{
+			"type": "malware", "id": fmt.Sprintf("malware--%d", i), "name": fmt.Sprintf("Software %d", i),
+			"external_references": []map[string]string{{"source_name": "mitre-attack", "external_id": fmt.Sprintf("S%04d", i)}},
+		})
+	}
+	for i := range relationships {
+		objs = append(objs, map[string]any{
+			"type": "relationship", "id": fmt.Sprintf("relationship--%d", i), "relationship_type": "mitigates",
+			"source_ref": fmt.Sprintf("course-of-action--%d", i%mitigations),
+			"target_ref": fmt.Sprintf("attack-pattern--%d", i%techniques),
+		})
+	}
+	bundle := attack.Bundle{Type: "bundle", SpecVersion: "2.0"}
+	for _, o := range objs {
+		raw, err := json.Marshal(o)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		bundle.Objects = append(bundle.Objects, raw)
+	}
+	return bundle
+}
+
+func TestBuildIndexParallel_MatchesSequential(t *testing.T) {
+	bundle := syntheticBundle(t)
+	// Повтор STIX ID в разных частях: побеждать должен последний объект, как при последовательном разборе
+	bundle.Objects = append(bundle.Objects,
+		json.RawMessage(`{"type":"attack-pattern","id":"attack-pattern--0","name":"Technique 0 (updated)"}`))
+	seq := attack.BuildIndexParallel(bundle, 1)
+	for _, workers := range []int{2, 3, 8} {
+		par := attack.BuildIndexParallel(bundle, workers)
+		if !reflect.DeepEqual(seq, par) {
+			t.Errorf("workers=%d: parallel index differs from sequential", workers)
+		}
+	}
+	if got := seq.Techniques["attack-pattern--0"].Name; got != "Technique 0 (updated)" {
+		t.Errorf("duplicate STIX ID: got %q, want the last object in the bundle", got)
+	}
+}
+
+func BenchmarkBuildIndex(b *testing.B) {
+	bundle := syntheticBundle(b)
+	counts := []int{1}
+	if n := runtime.NumCPU(); n > 1 {
+		counts = append(counts, n)
+	}
+	for _, workers := range counts {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for b.Loop() {
+				attack.BuildIndexParallel(bundle, workers)
+			}
+		})
+	}
+}
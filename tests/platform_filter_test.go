@@ -0,0 +1,35 @@
+// Тесты фильтра -platform на локальном бандле из testdata.
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPlatformFilter_AnyOfListedPlatforms(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	for _, tc := range []struct {
+		platforms string
+		want      string
+	}{
+		{"network", "T1090"},        // без учёта регистра
+		{"Windows", "T1090,T1565"},  // у обеих техник
+		{"macOS, Network", "T1090"}, // хотя бы одна из платформ; пробелы в списке допустимы
+		{"Android", ""},
+	} {
+		stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-platform", tc.platforms, "-json")
+		if got := strings.Join(jsonTechniqueIDs(t, stdout, stderr), ","); got != tc.want {
+			t.Errorf("-platform %q: techniques = %q, want %q", tc.platforms, got, tc.want)
+		}
+	}
+}
+
+func TestPlatformFilter_CombinesWithTactic(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t),
+		"-mitigation", "M1037", "-platform", "Windows", "-tactic", "impact", "-json")
+	if got := strings.Join(jsonTechniqueIDs(t, stdout, stderr), ","); got != "T1565" {
+		t.Errorf("Windows impact techniques of M1037 = %s, want T1565", got)
+	}
+}
@@ -0,0 +1,33 @@
+// Тесты индикатора загрузки: вне терминала (пайп, CI) он не выводится.
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProgress_HiddenWhenStderrIsNotTerminal(t *testing.T) {
+	bin := getBinary(t)
+	data, err := os.ReadFile(filepath.Join(repoRoot(t), "tests", "testdata", cacheFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	defer srv.Close()
+
+	env := map[string]string{envMITRECacheDir: t.TempDir()}
+	stdout, stderr := runMitremit(t, bin, env, "-bundle-url", srv.URL+"/enterprise-attack.json",
+		"-mitigation", "M1037", "-count", "-retries", "0")
+	if strings.TrimSpace(stdout) != "5" {
+		t.Fatalf("download failed; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if strings.Contains(stderr, "downloading bundle:") || strings.Contains(stderr, "\r") {
+		t.Errorf("progress must not be written to a non-terminal stderr:\n%q", stderr)
+	}
+}
@@ -0,0 +1,40 @@
+// Тесты тихого режима (-quiet): в stdout только данные.
+package tests
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestQuiet_DebugGoesToStderrAndStdoutIsPureCSV(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-debug", "-quiet", "-csv", "-mitigation", "M1037")
+	if strings.Contains(stdout, ">>>") {
+		t.Errorf("diagnostics leaked to stdout with -quiet:\n%s", stdout)
+	}
+	if !strings.Contains(stderr, ">>> fetchBundle()") {
+		t.Errorf("expected debug lines on stderr with -quiet; stderr:\n%s", stderr)
+	}
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil || len(records) < 2 {
+		t.Errorf("stdout is not clean CSV (err %v):\n%s", err, stdout)
+	}
+}
+
+func TestQuiet_SuppressesWarnings(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	// M1099 в тестовом бандле помечена устаревшей — без -quiet об этом предупреждают
+	_, stderr := runMitremit(t, bin, env, "-mitigation", "M1099", "-count")
+	if !strings.Contains(stderr, "WARNING:") {
+		t.Fatalf("expected a deprecation warning without -quiet; stderr:\n%s", stderr)
+	}
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1099", "-count", "-quiet")
+	if stderr != "" {
+		t.Errorf("-quiet must suppress warnings; stderr:\n%s", stderr)
+	}
+	if strings.TrimSpace(stdout) != "1" {
+		t.Errorf("unexpected output with -quiet: %q", stdout)
+	}
+}
@@ -0,0 +1,42 @@
+// Тесты описания связей mitigates (-show-relationship-desc).
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRelationshipDesc_JSONField(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-json", "-show-relationship-desc")
+	var items []struct {
+		ExternalID       string `json:"external_id"`
+		MitigationDetail string `json:"mitigation_detail"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &items); err != nil {
+		t.Fatalf("bad JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	for _, it := range items {
+		want := ""
+		if it.ExternalID == "T1071" {
+			want = "Filter traffic that uses unusual protocols."
+		}
+		if it.MitigationDetail != want {
+			t.Errorf("%s mitigation_detail = %q, want %q", it.ExternalID, it.MitigationDetail, want)
+		}
+	}
+}
+
+func TestRelationshipDesc_TableAndDefault(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	stdout, _ := runMitremit(t, bin, env, "-mitigation", "M1037", "-show-relationship-desc")
+	if !strings.Contains(stdout, "how: Filter traffic that uses unusual protocols.") {
+		t.Errorf("table lacks the relationship description:\n%s", stdout)
+	}
+	stdout, _ = runMitremit(t, bin, env, "-mitigation", "M1037", "-json")
+	if strings.Contains(stdout, "mitigation_detail") {
+		t.Errorf("mitigation_detail present without -show-relationship-desc:\n%s", stdout)
+	}
+}
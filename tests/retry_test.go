@@ -0,0 +1,66 @@
+// Тесты повторов загрузки (-retries, -retry-delay): 5xx повторяются, 4xx — нет.
+package tests
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// flakyUpstream отдаёт statuses по одному на запрос, затем — тестовый бандл; возвращает
+// окружение для запуска утилиты и счётчик запросов.
+func flakyUpstream(t *testing.T, statuses ...int) (map[string]string, *atomic.Int32) {
+	t.Helper()
+	data := fixtureBundle(t)
+	var hits atomic.Int32
+	env := fakeUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(hits.Add(1))
+		if n <= len(statuses) {
+			w.WriteHeader(statuses[n-1])
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	env[envMITRECacheDir] = t.TempDir()
+	return env, &hits
+}
+
+func TestRetries_ServerErrorsRetriedUntilSuccess(t *testing.T) {
+	bin := getBinary(t)
+	env, hits := flakyUpstream(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	stdout, stderr := runMitremit(t, bin, env, "-retries", "2", "-retry-delay", "1ms",
+		"-mitigation", "M1040", "-count")
+	if strings.TrimSpace(stdout) != "2" {
+		t.Errorf("expected success after two 503 responses; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if hits.Load() != 3 {
+		t.Errorf("expected 3 requests (503, 503, 200), got %d", hits.Load())
+	}
+}
+
+func TestRetries_GiveUpAfterLimit(t *testing.T) {
+	bin := getBinary(t)
+	env, hits := flakyUpstream(t, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	stdout, stderr := runMitremit(t, bin, env, "-retries", "1", "-retry-delay", "1ms",
+		"-mitigation", "M1040", "-count")
+	if stdout != "" || !strings.Contains(stderr, "HTTP 503") {
+		t.Errorf("-retries 1 must fail with the last 503; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if hits.Load() != 2 {
+		t.Errorf("-retries 1 must make exactly 2 requests, got %d", hits.Load())
+	}
+}
+
+func TestRetries_ClientErrorNotRetried(t *testing.T) {
+	bin := getBinary(t)
+	env, hits := flakyUpstream(t, http.StatusNotFound, http.StatusNotFound, http.StatusNotFound)
+	stdout, stderr := runMitremit(t, bin, env, "-retries", "3", "-retry-delay", "1ms",
+		"-mitigation", "M1040", "-count")
+	if stdout != "" || !strings.Contains(stderr, "HTTP 404") {
+		t.Errorf("expected failure on 404; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("404 must not be retried: expected exactly 1 request, got %d", hits.Load())
+	}
+}
@@ -0,0 +1,51 @@
+// Тесты замены отозванных техник преемниками по связи revoked-by.
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestRevoked_TechniqueReplacedBySuccessor(t *testing.T) {
+	bin := getBinary(t)
+	// В тестовом бандле M1099 смягчает отозванную T1043, которую заменила T1071
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-json", "-mitigation", "M1099")
+	var techs []struct {
+		ExternalID string `json:"external_id"`
+		Replaces   string `json:"replaces"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil {
+		t.Fatalf("invalid JSON: %v; stdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	if len(techs) != 1 || techs[0].ExternalID != "T1071" || techs[0].Replaces != "T1043" {
+		t.Errorf("expected only T1071 replacing revoked T1043; got %+v", techs)
+	}
+}
+
+func TestRevoked_DirectMappingWinsOverReplacement(t *testing.T) {
+	bin := getBinary(t)
+	// M1037 смягчает и T1071 напрямую, и отозванную T1043 → T1071: строка одна, без пометки замены
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-json", "-mitigation", "M1037")
+	var techs []struct {
+		ExternalID string `json:"external_id"`
+		Replaces   string `json:"replaces"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil {
+		t.Fatalf("invalid JSON: %v; stdout:\n%s", err, stdout)
+	}
+	n := 0
+	for _, tech := range techs {
+		if tech.ExternalID == "T1043" {
+			t.Errorf("revoked T1043 must not be reported")
+		}
+		if tech.ExternalID == "T1071" {
+			n++
+			if tech.Replaces != "" {
+				t.Errorf("direct mapping of T1071 must not be annotated as a replacement")
+			}
+		}
+	}
+	if n != 1 {
+		t.Errorf("expected T1071 exactly once, got %d", n)
+	}
+}
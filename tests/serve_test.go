@@ -0,0 +1,146 @@
+// Тесты HTTP-сервера (-serve): коды ответа GET /mitigation/{id} и расписание фонового обновления.
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startServer запускает mitremit -serve на свободном порту и ждёт готовности; возвращает
+// базовый URL и функцию остановки, которая отдаёт вывод сервера (stdout и stderr).
+func startServer(t *testing.T, bin string, env map[string]string, args ...string) (baseURL string, stop func() string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	cmd := exec.Command(bin, append([]string{"-serve", addr}, args...)...)
+	cmd.Dir = repoRoot(t)
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	var outBuf bytes.Buffer
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &outBuf
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	stopped := false
+	stop = func() string {
+		if !stopped {
+			stopped = true
+			_ = cmd.Process.Kill()
+			_ = cmd.Wait()
+		}
+		return outBuf.String()
+	}
+	t.Cleanup(func() { stop() })
+
+	baseURL = "http://" + addr
+	deadline := time.Now().Add(15 * time.Second)
+	for {
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			conn.Close()
+			return baseURL, stop
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("server did not start on %s; output:\n%s", addr, stop())
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func getStatus(t *testing.T, url string) (int, string) {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestServe_StatusCodes(t *testing.T) {
+	bin := getBinary(t)
+	fixture := filepath.Join(repoRoot(t), "tests", "testdata", cacheFilename)
+	base, stop := startServer(t, bin, nil, "-bundle-file", fixture, "-debug")
+
+	code, body := getStatus(t, base+"/mitigation/M1037")
+	if code != http.StatusOK {
+		t.Fatalf("M1037: status %d, body:\n%s", code, body)
+	}
+	var techniques []struct {
+		ExternalID string `json:"external_id"`
+	}
+	if err := json.Unmarshal([]byte(body), &techniques); err != nil || len(techniques) != 5 {
+		t.Errorf("M1037: want 5 techniques in JSON (err %v), body:\n%s", err, body)
+	}
+
+	// Голый номер дополняется префиксом M, как в -mitigation
+	if code, body := getStatus(t, base+"/mitigation/1037"); code != http.StatusOK {
+		t.Errorf("bare number: status %d, want 200; body:\n%s", code, body)
+	}
+	if code, body := getStatus(t, base+"/mitigation/bogus"); code != http.StatusBadRequest {
+		t.Errorf("malformed ID: status %d, want 400; body:\n%s", code, body)
+	}
+	if code, body := getStatus(t, base+"/mitigation/M9999"); code != http.StatusNotFound {
+		t.Errorf("unknown ID: status %d, want 404; body:\n%s", code, body)
+	}
+
+	// Локальный файл не меняется — фоновое обновление не планируется
+	if output := stop(); !strings.Contains(output, "background refresh disabled") || strings.Contains(output, "next bundle refresh") {
+		t.Errorf("refresh must be disabled for -bundle-file; output:\n%s", output)
+	}
+}
+
+func TestServe_RefreshScheduledFromCacheAge(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	// Кэшу 23 часа: до устаревания (cacheTTL = 24h) остаётся около часа, а не полные сутки
+	bundle := filepath.Join(env[envMITRECacheDir], cacheFilename)
+	old := time.Now().Add(-23 * time.Hour)
+	if err := os.Chtimes(bundle, old, old); err != nil {
+		t.Fatal(err)
+	}
+	base, stop := startServer(t, bin, env, "-debug")
+	if code, body := getStatus(t, base+"/mitigation/M1037"); code != http.StatusOK {
+		t.Fatalf("M1037: status %d, body:\n%s", code, body)
+	}
+	output := stop()
+	if !strings.Contains(output, "next bundle refresh in 59m") && !strings.Contains(output, "next bundle refresh in 1h0m0s") {
+		t.Errorf("refresh must be scheduled from the cache mtime (about 1h); output:\n%s", output)
+	}
+}
+
+func TestServe_StrictIDApplies(t *testing.T) {
+	bin := getBinary(t)
+	fixture := filepath.Join(repoRoot(t), "tests", "testdata", cacheFilename)
+
+	base, _ := startServer(t, bin, nil, "-bundle-file", fixture)
+	if code, body := getStatus(t, base+"/mitigation/m1037"); code != http.StatusOK {
+		t.Errorf("lower-case ID without -strict-id: status %d, want 200; body:\n%s", code, body)
+	}
+
+	base, _ = startServer(t, bin, nil, "-bundle-file", fixture, "-strict-id")
+	if code, body := getStatus(t, base+"/mitigation/m1037"); code != http.StatusNotFound {
+		t.Errorf("lower-case ID with -strict-id: status %d, want 404; body:\n%s", code, body)
+	}
+	if code, body := getStatus(t, base+"/mitigation/M1037"); code != http.StatusOK {
+		t.Errorf("exact ID with -strict-id: status %d, want 200; body:\n%s", code, body)
+	}
+}
@@ -0,0 +1,43 @@
+// Тесты связанных кампаний: campaign, использовавшие технику (-show-campaigns).
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestShowCampaigns_CampaignListed(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-show-campaigns", "-json")
+	var techs []struct {
+		ExternalID string   `json:"external_id"`
+		Campaigns  []string `json:"campaigns"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil {
+		t.Fatalf("expected JSON array (err %v); stdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	got := make(map[string][]string)
+	for _, tech := range techs {
+		got[tech.ExternalID] = tech.Campaigns
+	}
+	// Кампанию использует только T1071; группы и ПО в campaigns не попадают
+	if ca := got["T1071"]; len(ca) != 1 || ca[0] != "Operation Fixture (C0001)" {
+		t.Errorf("T1071 campaigns = %v, want [Operation Fixture (C0001)]", ca)
+	}
+	if ca := got["T1090"]; len(ca) != 0 {
+		t.Errorf("T1090 campaigns = %v, want none", ca)
+	}
+}
+
+func TestShowCampaigns_CSVColumn(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-show-campaigns", "-csv")
+	lines := strings.Split(stdout, "\n")
+	if !strings.HasSuffix(lines[0], ",Campaigns") {
+		t.Fatalf("expected Campaigns column in CSV header, got %q; stderr:\n%s", lines[0], stderr)
+	}
+	if !strings.Contains(stdout, "T1071,Application Layer Protocol,command-and-control,Linux; macOS; Windows,Operation Fixture (C0001)") {
+		t.Errorf("expected campaign in T1071 row; stdout:\n%s", stdout)
+	}
+}
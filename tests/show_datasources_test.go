@@ -0,0 +1,40 @@
+// Тесты вывода источников данных техник (-show-datasources).
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestShowDataSources_JSONField(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-json", "-show-datasources")
+	var items []struct {
+		ExternalID  string   `json:"external_id"`
+		DataSources []string `json:"data_sources"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &items); err != nil {
+		t.Fatalf("bad JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	found := false
+	for _, it := range items {
+		if it.ExternalID == "T1071" {
+			found = true
+			if len(it.DataSources) != 1 || it.DataSources[0] != "Network Traffic: Network Traffic Content" {
+				t.Errorf("T1071 data_sources = %v", it.DataSources)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("T1071 missing from output:\n%s", stdout)
+	}
+}
+
+func TestShowDataSources_OffByDefault(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-json")
+	if strings.Contains(stdout, "data_sources") {
+		t.Errorf("data_sources present without -show-datasources:\n%s", stdout)
+	}
+}
@@ -0,0 +1,75 @@
+// Тесты -show-description на локальном бандле из testdata.
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// descriptionStart — начало описания T1090 в фикстуре после очистки markdown-разметки.
+const descriptionStart = "Adversaries may use a connection proxy to direct network"
+
+func TestShowDescription_JSONKeepsOriginalText(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-show-description", "-json")
+	var techs []struct {
+		ExternalID  string `json:"external_id"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil {
+		t.Fatalf("invalid JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	if len(techs) == 0 || techs[0].ExternalID != "T1090" {
+		t.Fatalf("unexpected techniques: %+v", techs)
+	}
+	if !strings.HasPrefix(techs[0].Description, "Adversaries may use a **connection proxy**") {
+		t.Errorf("JSON description must be the original markdown, got %q", techs[0].Description)
+	}
+
+	stdout, _ = runMitremit(t, bin, env, "-mitigation", "M1040", "-json")
+	if strings.Contains(stdout, `"description"`) {
+		t.Errorf("description must not be emitted without -show-description:\n%s", stdout)
+	}
+}
+
+func TestShowDescription_CSVColumnIsPlainText(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-show-description", "-csv")
+	descs := csvColumn(t, stdout, "Description")
+	if len(descs) != 2 {
+		t.Fatalf("got %d rows, want 2:\n%s", len(descs), stdout)
+	}
+	if !strings.HasPrefix(descs[0], descriptionStart) {
+		t.Errorf("T1090 description = %q, want plain text starting with %q", descs[0], descriptionStart)
+	}
+	for _, markup := range []string{"**", "(Citation", "<code>", "](", "\n"} {
+		if strings.Contains(descs[0], markup) {
+			t.Errorf("CSV description still contains %q: %q", markup, descs[0])
+		}
+	}
+	if descs[1] != "" {
+		t.Errorf("T1565 has no description, got %q", descs[1])
+	}
+}
+
+func TestShowDescription_TableWrapsUnderName(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-show-description")
+	lines := strings.Split(stdout, "\n")
+	for i, line := range lines {
+		if !strings.Contains(line, "connection proxy") {
+			continue
+		}
+		// Описание — на отдельной строке под строкой T1090, с отступом до колонки названия
+		if i == 0 || !strings.HasPrefix(lines[i-1], "T1090") {
+			t.Errorf("description must follow the T1090 row; stdout:\n%s", stdout)
+		}
+		if col := strings.Index(lines[i-1], "Proxy"); strings.Index(line, "Adversaries") != col {
+			t.Errorf("description is not aligned with the name column; stdout:\n%s", stdout)
+		}
+		return
+	}
+	t.Errorf("table lacks the description; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+}
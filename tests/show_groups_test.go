@@ -0,0 +1,38 @@
+// Тесты контекста угроз: группы, использующие технику (-show-groups).
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestShowGroups_JSONField(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-show-groups", "-json")
+	var techs []struct {
+		ExternalID string   `json:"external_id"`
+		Groups     []string `json:"groups"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil {
+		t.Fatalf("expected JSON array (err %v); stdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	got := make(map[string][]string)
+	for _, tech := range techs {
+		got[tech.ExternalID] = tech.Groups
+	}
+	if g := got["T1090"]; len(g) != 1 || g[0] != "APT29 (G0016)" {
+		t.Errorf("T1090 groups = %v, want [APT29 (G0016)]", g)
+	}
+	if g := got["T1565"]; len(g) != 0 {
+		t.Errorf("T1565 is not used by any group; got %v", g)
+	}
+}
+
+func TestShowGroups_OffByDefault(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-json")
+	if strings.Contains(stdout, `"groups"`) {
+		t.Errorf("groups must not be emitted without -show-groups:\n%s", stdout)
+	}
+}
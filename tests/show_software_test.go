@@ -0,0 +1,30 @@
+// Тесты связанного ПО: malware и tool, использующие технику (-show-software).
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestShowSoftware_MalwareAndToolListed(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-show-software", "-json")
+	var techs []struct {
+		ExternalID string   `json:"external_id"`
+		Software   []string `json:"software"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil {
+		t.Fatalf("expected JSON array (err %v); stdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	got := make(map[string][]string)
+	for _, tech := range techs {
+		got[tech.ExternalID] = tech.Software
+	}
+	// T1071 использует malware, T1090 — tool; группы и кампании в software не попадают
+	if sw := got["T1071"]; len(sw) != 1 || sw[0] != "Trojan.Fixture (S0001)" {
+		t.Errorf("T1071 software = %v, want [Trojan.Fixture (S0001)]", sw)
+	}
+	if sw := got["T1090"]; len(sw) != 1 || sw[0] != "FixtureTool (S0002)" {
+		t.Errorf("T1090 software = %v, want [FixtureTool (S0002)]", sw)
+	}
+}
@@ -0,0 +1,62 @@
+// Тесты -show-url на локальном бандле из testdata.
+package tests
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestShowURL_JSONAndCSV(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-show-url", "-json")
+	var techs []struct {
+		ExternalID string `json:"external_id"`
+		URL        string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil {
+		t.Fatalf("invalid JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	for _, tech := range techs {
+		if want := "https://attack.mitre.org/techniques/" + tech.ExternalID; tech.URL != want {
+			t.Errorf("%s url = %q, want %q", tech.ExternalID, tech.URL, want)
+		}
+	}
+
+	stdout, _ = runMitremit(t, bin, env, "-mitigation", "M1040", "-show-url", "-csv")
+	want := "https://attack.mitre.org/techniques/T1090|https://attack.mitre.org/techniques/T1565"
+	if got := strings.Join(csvColumn(t, stdout, "URL"), "|"); got != want {
+		t.Errorf("CSV URL column = %s, want %s", got, want)
+	}
+}
+
+func TestShowURL_SubtechniqueURL(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-show-url", "-csv")
+	ids := csvColumn(t, stdout, "Technique ID")
+	urls := csvColumn(t, stdout, "URL")
+	for i, id := range ids {
+		if id == "T1071.001" && urls[i] != "https://attack.mitre.org/techniques/T1071/001" {
+			t.Errorf("T1071.001 URL = %q", urls[i])
+		}
+	}
+}
+
+func TestShowURL_TableColumn(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-show-url")
+	if !regexp.MustCompile(`(?m)^TECHNIQUE ID\s+TECHNIQUE NAME\s+TACTICS\s+URL$`).MatchString(stdout) {
+		t.Errorf("table header lacks URL column; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if !regexp.MustCompile(`(?m)^T1565\s+.*https://attack\.mitre\.org/techniques/T1565$`).MatchString(stdout) {
+		t.Errorf("T1565 row lacks its URL; stdout:\n%s", stdout)
+	}
+
+	stdout, _ = runMitremit(t, bin, env, "-mitigation", "M1040")
+	if strings.Contains(stdout, "attack.mitre.org") {
+		t.Errorf("URLs must not be shown without -show-url; stdout:\n%s", stdout)
+	}
+}
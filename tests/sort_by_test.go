@@ -0,0 +1,64 @@
+// Тесты порядка техник (-sort-by).
+package tests
+
+import (
+	"encoding/json"
+	"slices"
+	"sort"
+	"testing"
+)
+
+// sortedTechnique — поля JSON-вывода, влияющие на порядок.
+type sortedTechnique struct {
+	ExternalID string   `json:"external_id"`
+	Name       string   `json:"name"`
+	Tactics    []string `json:"tactics"`
+}
+
+// sortedTechniques запускает -json с заданным -sort-by и возвращает техники в порядке вывода.
+func sortedTechniques(t *testing.T, by string) []sortedTechnique {
+	t.Helper()
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-json", "-sort-by", by)
+	var techs []sortedTechnique
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil || len(techs) < 2 {
+		t.Fatalf("expected JSON array (err %v); stdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	return techs
+}
+
+func TestSortBy_Name(t *testing.T) {
+	techs := sortedTechniques(t, "name")
+	if !sort.SliceIsSorted(techs, func(i, j int) bool { return techs[i].Name < techs[j].Name }) {
+		t.Errorf("techniques not sorted by name: %+v", techs)
+	}
+}
+
+func TestSortBy_TacticThenID(t *testing.T) {
+	techs := sortedTechniques(t, "tactic")
+	key := func(i int) string {
+		if len(techs[i].Tactics) == 0 {
+			return "￿"
+		}
+		return techs[i].Tactics[0]
+	}
+	for i := 1; i < len(techs); i++ {
+		if key(i-1) > key(i) || (key(i-1) == key(i) && techs[i-1].ExternalID > techs[i].ExternalID) {
+			t.Errorf("techniques not sorted by first tactic then ID at %d: %+v", i, techs)
+		}
+	}
+}
+
+func TestSortBy_TacticsWithinTechniqueSorted(t *testing.T) {
+	// Порядок тактик не зависит от -sort-by: проверяем на выводе по умолчанию
+	techs := sortedTechniques(t, "id")
+	for _, tech := range techs {
+		if !sort.StringsAreSorted(tech.Tactics) {
+			t.Errorf("%s tactics not sorted: %v", tech.ExternalID, tech.Tactics)
+		}
+		// В фикстуре у T1090 фазы kill chain идут в обратном порядке
+		if tech.ExternalID == "T1090" && !slices.Equal(tech.Tactics, []string{"command-and-control", "defense-evasion"}) {
+			t.Errorf("T1090 tactics = %v, want [command-and-control defense-evasion]", tech.Tactics)
+		}
+	}
+}
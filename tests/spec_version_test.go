@@ -0,0 +1,56 @@
+// Тесты проверки spec_version бандла.
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// bundleWithSpecVersion записывает копию фикстуры с заменённым spec_version и возвращает путь.
+func bundleWithSpecVersion(t *testing.T, spec string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(repoRoot(t), "tests", "testdata", "enterprise-attack.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	patched := strings.Replace(string(data), `"spec_version": "2.0"`, `"spec_version": "`+spec+`"`, 1)
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	if err := os.WriteFile(path, []byte(patched), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSpecVersion_UnknownWarnsButRuns(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, nil, "-bundle-file", bundleWithSpecVersion(t, "3.0"), "-mitigation", "M1037", "-count")
+	if !strings.Contains(stderr, `WARNING: unrecognized bundle spec_version "3.0"`) {
+		t.Errorf("expected spec_version warning; stderr:\n%s", stderr)
+	}
+	if strings.TrimSpace(stdout) != "5" {
+		t.Errorf("warning must not be fatal; stdout:\n%s", stdout)
+	}
+}
+
+func TestSpecVersion_KnownIsSilentUnlessDebug(t *testing.T) {
+	bin := getBinary(t)
+	path := bundleWithSpecVersion(t, "2.1")
+	_, stderr := runMitremit(t, bin, nil, "-bundle-file", path, "-mitigation", "M1037", "-count")
+	if strings.Contains(stderr, "spec_version") {
+		t.Errorf("known spec_version must not be reported without -debug; stderr:\n%s", stderr)
+	}
+	_, stderr = runMitremit(t, bin, nil, "-bundle-file", path, "-mitigation", "M1037", "-count", "-debug")
+	if !strings.Contains(stderr, ">>> bundle spec_version: 2.1") {
+		t.Errorf("-debug must print the detected spec_version; stderr:\n%s", stderr)
+	}
+}
+
+func TestSpecVersion_QuietSuppressesWarning(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, nil, "-bundle-file", bundleWithSpecVersion(t, "3.0"), "-mitigation", "M1037", "-count", "-quiet")
+	if strings.Contains(stderr, "spec_version") || strings.TrimSpace(stdout) != "5" {
+		t.Errorf("-quiet must drop the warning and keep the result; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+}
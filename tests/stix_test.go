@@ -0,0 +1,60 @@
+// Тесты подмножества STIX-бандла (-stix).
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSTIX_SubsetRoundTrips(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-stix")
+	var bundle struct {
+		Type    string `json:"type"`
+		ID      string `json:"id"`
+		Objects []struct {
+			Type string `json:"type"`
+			ID   string `json:"id"`
+		} `json:"objects"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &bundle); err != nil {
+		t.Fatalf("invalid JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	if bundle.Type != "bundle" || !strings.HasPrefix(bundle.ID, "bundle--") {
+		t.Errorf("bad bundle envelope: type=%q id=%q", bundle.Type, bundle.ID)
+	}
+	got := make(map[string]string)
+	for _, o := range bundle.Objects {
+		got[o.ID] = o.Type
+	}
+	want := map[string]string{
+		"course-of-action--m1040": "course-of-action",
+		"attack-pattern--t1090":   "attack-pattern",
+		"attack-pattern--t1565":   "attack-pattern",
+	}
+	for id, typ := range want {
+		if got[id] != typ {
+			t.Errorf("object %s (%s) missing from subset; got %v", id, typ, got)
+		}
+	}
+	for id, typ := range got {
+		if typ != "relationship" && want[id] == "" {
+			t.Errorf("unexpected object %s (%s) in subset", id, typ)
+		}
+	}
+
+	// Подмножество — валидный вход для самой утилиты с тем же результатом
+	path := filepath.Join(t.TempDir(), "subset.json")
+	if err := os.WriteFile(path, []byte(stdout), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	direct, _ := runMitremit(t, bin, env, "-mitigation", "M1040", "-csv")
+	again, stderr := runMitremit(t, bin, nil, "-bundle-file", path, "-mitigation", "M1040", "-csv")
+	if again != direct {
+		t.Errorf("round trip differs:\n%s\nwant:\n%s\nstderr:\n%s", again, direct, stderr)
+	}
+}
@@ -0,0 +1,80 @@
+// Тесты конвейерного разбора бандла (-stream-buffer): результат должен совпадать с разбором в памяти.
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Техника attack-pattern--dup встречается в бандле дважды: как и при разборе в памяти,
+// побеждает последний объект.
+const streamDupBundleJSON = `{"type": "bundle", "id": "bundle--stream", "spec_version": "2.0", "objects": [
+  {"type": "course-of-action", "id": "course-of-action--m9001", "name": "Crafted Mitigation",
+   "external_references": [{"source_name": "mitre-attack", "external_id": "M9001"}]},
+  {"type": "attack-pattern", "id": "attack-pattern--dup", "name": "First Version",
+   "external_references": [{"source_name": "mitre-attack", "external_id": "T9001"}]},
+  {"type": "relationship", "id": "relationship--a", "relationship_type": "mitigates",
+   "source_ref": "course-of-action--m9001", "target_ref": "attack-pattern--dup"},
+  {"type": "attack-pattern", "id": "attack-pattern--dup", "name": "Second Version",
+   "external_references": [{"source_name": "mitre-attack", "external_id": "T9001"}]}
+]}`
+
+func TestStreamBuffer_MatchesInMemoryParse(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	want, stderr := runMitremit(t, bin, env, "-mitigation", "M1037", "-json")
+	if len(jsonTechniqueIDs(t, want, stderr)) == 0 {
+		t.Fatalf("in-memory parse found no techniques; stderr:\n%s", stderr)
+	}
+	for _, buffer := range []string{"1", "3", "1024"} {
+		got, stderr := runMitremit(t, bin, env, "-mitigation", "M1037", "-json", "-stream-buffer", buffer)
+		if got != want {
+			t.Errorf("-stream-buffer %s output differs from in-memory parse:\n%s\nwant:\n%s\nstderr:\n%s", buffer, got, want, stderr)
+		}
+	}
+}
+
+func TestStreamBuffer_LastDuplicateObjectWins(t *testing.T) {
+	bin := getBinary(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "enterprise-attack.json"), []byte(streamDupBundleJSON), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{envMITRECacheDir: dir}
+	for _, buffer := range []string{"0", "1", "64"} {
+		stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M9001", "-json", "-stream-buffer", buffer)
+		var got []struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+			t.Fatalf("-stream-buffer %s: invalid JSON: %v\nstdout:\n%s\nstderr:\n%s", buffer, err, stdout, stderr)
+		}
+		if len(got) != 1 || got[0].Name != "Second Version" {
+			t.Errorf("-stream-buffer %s: techniques = %+v, want the later duplicate \"Second Version\"", buffer, got)
+		}
+	}
+}
+
+func TestStreamBuffer_MalformedBundleIsError(t *testing.T) {
+	bin := getBinary(t)
+	dir := t.TempDir()
+	truncated := streamDupBundleJSON[:len(streamDupBundleJSON)/2]
+	if err := os.WriteFile(filepath.Join(dir, "enterprise-attack.json"), []byte(truncated), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr := runMitremit(t, bin, map[string]string{envMITRECacheDir: dir}, "-mitigation", "M9001", "-stream-buffer", "4")
+	if !strings.Contains(stderr, "error parsing bundle JSON") {
+		t.Errorf("expected parse error for truncated bundle; stderr:\n%s", stderr)
+	}
+}
+
+func TestStreamBuffer_NegativeIsError(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-stream-buffer", "-1")
+	if !strings.Contains(stderr, "-stream-buffer must not be negative") {
+		t.Errorf("expected error for negative buffer; stderr:\n%s", stderr)
+	}
+}
@@ -0,0 +1,86 @@
+// Тесты потокового разбора по умолчанию: бандл читается прямо из файла кэша или тела ответа.
+package tests
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestStreamParse_DefaultMatchesInMemoryFallback(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	for _, args := range [][]string{
+		{"-mitigation", "M1040", "-json"},
+		{"-mitigation", "M1037,M1099", "-csv"},
+		{"-technique", "T1090", "-json"},
+	} {
+		streamed, stderr := runMitremit(t, bin, env, args...)
+		inMemory, _ := runMitremit(t, bin, env, append(args, "-stream-buffer", "0")...)
+		if streamed == "" || streamed != inMemory {
+			t.Errorf("%v: streamed output differs from -stream-buffer 0;\nstreamed:\n%s\nin-memory:\n%s\nstderr:\n%s",
+				args, streamed, inMemory, stderr)
+		}
+	}
+}
+
+func TestStreamParse_DownloadIsCachedWhileParsing(t *testing.T) {
+	bin := getBinary(t)
+	data := fixtureBundle(t)
+	env := fakeUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	dir := t.TempDir()
+	env[envMITRECacheDir] = dir
+
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-count")
+	if strings.TrimSpace(stdout) != "2" {
+		t.Fatalf("unexpected output; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	f, err := os.Open(filepath.Join(dir, cacheFilename+".gz"))
+	if err != nil {
+		t.Fatalf("bundle not cached: %v", err)
+	}
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatalf("cache is not gzip: %v", err)
+	}
+	cached, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("decompress cache: %v", err)
+	}
+	if !bytes.Equal(cached, data) {
+		t.Errorf("cached bundle differs from the downloaded one (%d vs %d bytes)", len(cached), len(data))
+	}
+	if tmp, _ := filepath.Glob(filepath.Join(dir, "*.tmp")); len(tmp) != 0 {
+		t.Errorf("temporary cache files left behind: %v", tmp)
+	}
+}
+
+func TestStreamParse_TruncatedDownloadIsNotCached(t *testing.T) {
+	bin := getBinary(t)
+	data := fixtureBundle(t)
+	env := fakeUpstream(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Обрыв соединения посреди тела: объявлен полный размер, отправлена половина
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		_, _ = w.Write(data[:len(data)/2])
+	}))
+	dir := t.TempDir()
+	env[envMITRECacheDir] = dir
+
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-count")
+	if stdout != "" || !strings.Contains(stderr, "error") {
+		t.Errorf("truncated download must fail; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		t.Errorf("nothing must be cached after a truncated download, found %s", e.Name())
+	}
+}
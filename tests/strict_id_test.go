@@ -0,0 +1,27 @@
+// Тесты регистрозависимого сравнения ID митигаций (-strict-id).
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStrictID_DefaultIsCaseInsensitive(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "m1037", "-csv")
+	if !strings.Contains(stdout, "M1037,Filter Network Traffic") {
+		t.Errorf("lower-case ID should match without -strict-id; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+}
+
+func TestStrictID_RejectsCaseMismatch(t *testing.T) {
+	bin := getBinary(t)
+	stdout, code := runExitCode(t, bin, "-mitigation", "m1037", "-strict-id", "-csv")
+	if code != 1 || stdout != "" {
+		t.Errorf("expected exit 1 and no output for m1037 with -strict-id; code=%d stdout:\n%s", code, stdout)
+	}
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "m1037", "-strict-id")
+	if !strings.Contains(stderr, "Did you mean: M1037?") {
+		t.Errorf("expected a hint with the correctly cased ID; stderr:\n%s", stderr)
+	}
+}
@@ -0,0 +1,32 @@
+// Тесты итоговой строки таблицы.
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTableFooter_CountsTechniquesAndTactics(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037")
+	lines := strings.Split(strings.TrimRight(stdout, "\n"), "\n")
+	// T1071, T1071.001, T1090, T1565, noext; тактики command-and-control, defense-evasion, impact
+	if got, want := lines[len(lines)-1], "Total: 5 techniques across 3 tactics"; got != want {
+		t.Errorf("footer = %q, want %q; stdout:\n%s\nstderr:\n%s", got, want, stdout, stderr)
+	}
+
+	stdout, _ = runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-tactic", "impact")
+	if !strings.Contains(stdout, "Total: 1 technique across 1 tactic\n") {
+		t.Errorf("expected singular footer; stdout:\n%s", stdout)
+	}
+}
+
+func TestTableFooter_NotInMachineFormats(t *testing.T) {
+	bin := getBinary(t)
+	for _, format := range []string{"-csv", "-json", "-jsonl", "-tsv"} {
+		stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", format)
+		if strings.Contains(stdout, "Total:") {
+			t.Errorf("%s output contains table footer:\n%s", format, stdout)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+// Тесты отображаемых имён тактик (-tactic-display-names).
+package tests
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"mitremit/attack"
+)
+
+func TestTacticDisplayNames_Library(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", cacheFilename))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	idx, err := attack.ParseBundle(raw)
+	if err != nil {
+		t.Fatalf("ParseBundle: %v", err)
+	}
+	names := idx.TacticDisplayNames()
+	if got := names["defense-evasion"]; got != "Defense Evasion" {
+		t.Errorf("defense-evasion -> %q, want Defense Evasion", got)
+	}
+	if got := names["command-and-control"]; got != "Command and Control" {
+		t.Errorf("command-and-control -> %q, want Command and Control", got)
+	}
+}
+
+func TestTacticDisplayNames_JSON(t *testing.T) {
+	bin := getBinary(t)
+	// -tactic по-прежнему принимает shortname
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t),
+		"-mitigation", "M1037", "-tactic", "defense-evasion", "-tactic-display-names", "-json")
+	var techs []struct {
+		ExternalID string   `json:"external_id"`
+		Tactics    []string `json:"tactics"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil {
+		t.Fatalf("bad JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	if len(techs) != 1 || techs[0].ExternalID != "T1090" {
+		t.Fatalf("expected only T1090, got %+v", techs)
+	}
+	// Отображаемые имена отсортированы заново, уже по алфавиту имён
+	want := []string{"Command and Control", "Defense Evasion"}
+	if !slices.Equal(techs[0].Tactics, want) {
+		t.Errorf("tactics = %v, want %v", techs[0].Tactics, want)
+	}
+}
@@ -0,0 +1,32 @@
+// Тесты фильтра -tactic на локальном бандле из testdata.
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTacticFilter_KeepsTechniquesOfTactic(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	// Сравнение без учёта регистра; T1090 относится к двум тактикам
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1040", "-tactic", "Defense-Evasion", "-json")
+	if got := strings.Join(jsonTechniqueIDs(t, stdout, stderr), ","); got != "T1090" {
+		t.Errorf("defense-evasion techniques of M1040 = %s, want T1090", got)
+	}
+	stdout, stderr = runMitremit(t, bin, env, "-mitigation", "M1040", "-tactic", "impact", "-json")
+	if got := strings.Join(jsonTechniqueIDs(t, stdout, stderr), ","); got != "T1565" {
+		t.Errorf("impact techniques of M1040 = %s, want T1565", got)
+	}
+}
+
+func TestTacticFilter_NoMatchMessageInTable(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-tactic", "exfiltration")
+	if !strings.Contains(stdout, "no techniques for mitigation M1040 under tactic exfiltration") {
+		t.Errorf("expected no-match message; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if strings.Contains(stdout, "TECHNIQUE ID") {
+		t.Errorf("empty table must be replaced by the message; stdout:\n%s", stdout)
+	}
+}
@@ -0,0 +1,32 @@
+// Тесты гистограммы техник по тактикам (-tactic-histogram).
+package tests
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestTacticHistogram_JSONSortedDescending(t *testing.T) {
+	bin := getBinary(t)
+	// T1090 есть у обеих митигаций — учитывается один раз
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037,M1040", "-tactic-histogram", "-json")
+	var got []struct {
+		Tactic string `json:"tactic"`
+		Count  int    `json:"count"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	want := []struct {
+		Tactic string `json:"tactic"`
+		Count  int    `json:"count"`
+	}{
+		{"command-and-control", 3},
+		{"defense-evasion", 1},
+		{"impact", 1},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("histogram = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,69 @@
+// Тесты обратного поиска -technique на локальном бандле из testdata.
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+type techniqueMitigationJSON struct {
+	ExternalID string `json:"external_id"`
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+}
+
+func TestTechniqueLookup_JSONMarksObsoleteMitigations(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-technique", "t1043", "-json")
+	var mits []techniqueMitigationJSON
+	if err := json.Unmarshal([]byte(stdout), &mits); err != nil {
+		t.Fatalf("invalid JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	// M1099 устарела (x_mitre_deprecated) — она остаётся в списке, но помечена
+	var got []string
+	for _, m := range mits {
+		got = append(got, m.ExternalID+":"+m.Status)
+	}
+	if strings.Join(got, ",") != "M1037:,M1099:deprecated" {
+		t.Errorf("mitigations of T1043 = %v, want [M1037: M1099:deprecated]", got)
+	}
+}
+
+func TestTechniqueLookup_TableAndCSVShowStatus(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	stdout, stderr := runMitremit(t, bin, env, "-technique", "T1043")
+	if !strings.Contains(stdout, "Legacy Control [deprecated]") {
+		t.Errorf("table must mark the deprecated mitigation; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	stdout, _ = runMitremit(t, bin, env, "-technique", "T1043", "-csv")
+	if got := strings.Join(csvColumn(t, stdout, "Status"), ","); got != ",deprecated" {
+		t.Errorf("CSV Status column = %q, want \",deprecated\"", got)
+	}
+}
+
+func TestTechniqueLookup_PrefersCurrentObjectOverRevokedCopy(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	// У T1565 есть отозванная копия с более поздним modified и без связей: выбор не должен
+	// зависеть от порядка обхода map, поэтому запускаем несколько раз
+	for range 3 {
+		stdout, stderr := runMitremit(t, bin, env, "-technique", "T1565", "-json")
+		var mits []techniqueMitigationJSON
+		if err := json.Unmarshal([]byte(stdout), &mits); err != nil {
+			t.Fatalf("invalid JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+		}
+		if len(mits) != 2 || mits[0].ExternalID != "M1037" || mits[1].ExternalID != "M1040" {
+			t.Fatalf("mitigations of T1565 = %+v, want M1037 and M1040 of the current technique", mits)
+		}
+	}
+}
+
+func TestTechniqueLookup_UnknownTechniqueIsError(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-technique", "T9999")
+	if !strings.Contains(stderr, "technique T9999 not found") {
+		t.Errorf("expected not-found error; stderr:\n%s", stderr)
+	}
+}
@@ -0,0 +1,59 @@
+// Тесты пользовательского формата вывода (-template, -template-file).
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTemplate_RendersEachTechnique(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037",
+		"-template", `{{.Mitigation}}|{{.MitigationName}}|{{.ExternalID}}|{{join .Tactics ","}}`)
+	lines := strings.Split(strings.TrimRight(stdout, "\n"), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("expected one line per technique (5), got %d; stdout:\n%s\nstderr:\n%s", len(lines), stdout, stderr)
+	}
+	want := "M1037|Filter Network Traffic|T1090|command-and-control,defense-evasion"
+	if !strings.Contains(stdout, want+"\n") {
+		t.Errorf("expected line %q; stdout:\n%s", want, stdout)
+	}
+}
+
+func TestTemplate_FromFile(t *testing.T) {
+	bin := getBinary(t)
+	path := filepath.Join(t.TempDir(), "row.tmpl")
+	// Шаблон сам заканчивает строку — второй перевод строки не добавляется
+	if err := os.WriteFile(path, []byte("- {{.ExternalID}} {{.Name}}\n"), 0o644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040", "-template-file", path)
+	want := "- T1090 Proxy, \"Relay\" | Hop\n- T1565 Data Manipulation\n"
+	if stdout != want {
+		t.Errorf("stdout = %q, want %q; stderr:\n%s", stdout, want, stderr)
+	}
+}
+
+func TestTemplate_ErrorsReported(t *testing.T) {
+	bin := getBinary(t)
+	cases := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-template", "{{.Name"}, "parse template"},
+		{[]string{"-template", "{{.NoSuchField}}"}, "execute template for T1071"},
+		{[]string{"-template", "{{.Name}}", "-template-file", "row.tmpl"}, "mutually exclusive"},
+	}
+	for _, c := range cases {
+		args := append([]string{"-mitigation", "M1037"}, c.args...)
+		stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), args...)
+		if !strings.Contains(stderr, c.want) {
+			t.Errorf("%v: expected %q in stderr; stderr:\n%s", c.args, c.want, stderr)
+		}
+		if _, code := runExitCode(t, bin, args...); code != 1 {
+			t.Errorf("%v: exit code %d, want 1; stdout:\n%s", c.args, code, stdout)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+//go:build linux
+
+// Тесты адаптации таблицы к ширине терминала: stdout утилиты подключается к псевдотерминалу
+// заданной ширины.
+package tests
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"syscall"
+	"testing"
+
+	"golang.org/x/sys/unix"
+)
+
+// ansiEscape — управляющие последовательности, которые утилита может добавить при выводе в терминал.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// runInTerminal запускает бинарник со stdout, подключённым к псевдотерминалу шириной cols,
+// и возвращает напечатанное в него (без \r и управляющих последовательностей) и stderr.
+func runInTerminal(t *testing.T, binary string, env map[string]string, cols uint16, args ...string) (stdout, stderr string) {
+	t.Helper()
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("pseudo-terminals are not available: %v", err)
+	}
+	defer master.Close()
+	if err := unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, 0); err != nil {
+		t.Fatalf("unlock pty: %v", err)
+	}
+	n, err := unix.IoctlGetInt(int(master.Fd()), unix.TIOCGPTN)
+	if err != nil {
+		t.Fatalf("get pty number: %v", err)
+	}
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatalf("open pty slave: %v", err)
+	}
+	if err := unix.IoctlSetWinsize(int(slave.Fd()), unix.TIOCSWINSZ, &unix.Winsize{Row: 50, Col: cols}); err != nil {
+		t.Fatalf("set terminal size: %v", err)
+	}
+
+	cmd := exec.Command(binary, args...)
+	cmd.Dir = repoRoot(t)
+	cmd.Env = os.Environ()
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+	var errBuf bytes.Buffer
+	cmd.Stdout = slave
+	cmd.Stderr = &errBuf
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("start mitremit: %v", err)
+	}
+	slave.Close()
+	// После выхода процесса чтение из master завершается ошибкой EIO — это конец вывода
+	out, _ := io.ReadAll(master)
+	_ = cmd.Wait()
+	clean := strings.ReplaceAll(string(out), "\r", "")
+	return ansiEscape.ReplaceAllString(clean, ""), errBuf.String()
+}
+
+func TestTermWidth_WideTerminalKeepsAllColumns(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runInTerminal(t, bin, fixtureCacheDir(t), 200, "-mitigation", "M1040")
+	if !regexp.MustCompile(`(?m)^TECHNIQUE ID\s+TECHNIQUE NAME\s+TACTICS$`).MatchString(stdout) {
+		t.Errorf("expected full table header; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if !strings.Contains(stdout, "command-and-control, defense-evasion") {
+		t.Errorf("expected tactics column; stdout:\n%s", stdout)
+	}
+}
+
+func TestTermWidth_NarrowTerminalDropsTactics(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runInTerminal(t, bin, fixtureCacheDir(t), 60, "-mitigation", "M1040")
+	if !regexp.MustCompile(`(?m)^TECHNIQUE ID\s+TECHNIQUE NAME$`).MatchString(stdout) {
+		t.Errorf("expected header without TACTICS; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if strings.Contains(stdout, "defense-evasion") {
+		t.Errorf("tactics column must be dropped in a 60-column terminal; stdout:\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "T1565") {
+		t.Errorf("techniques must still be listed; stdout:\n%s", stdout)
+	}
+}
+
+func TestTermWidth_VeryNarrowTerminalStacksRows(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runInTerminal(t, bin, fixtureCacheDir(t), 40, "-mitigation", "M1040")
+	for _, want := range []string{"TECHNIQUE ID:", "TECHNIQUE NAME:", "TACTICS:"} {
+		if !strings.Contains(stdout, want) {
+			t.Errorf("expected stacked layout with %q; stdout:\n%s\nstderr:\n%s", want, stdout, stderr)
+		}
+	}
+}
+
+func TestTermWidth_PipeIsNotResized(t *testing.T) {
+	bin := getBinary(t)
+	// Вне терминала ширина неизвестна — таблица печатается целиком
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1040")
+	if !strings.Contains(stdout, "command-and-control, defense-evasion") {
+		t.Errorf("expected full table when stdout is a pipe; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+}
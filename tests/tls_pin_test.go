@@ -0,0 +1,82 @@
+// Тесты доверенных CA (-ca-file) и пиннинга ключа сервера (-pin-sha256).
+package tests
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tlsMirror поднимает HTTPS-сервер с фикстурой и возвращает его, путь к PEM с его сертификатом
+// и пин его открытого ключа.
+func tlsMirror(t *testing.T) (srv *httptest.Server, caFile, pin string) {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(repoRoot(t), "tests", "testdata", cacheFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	cert := srv.Certificate()
+	caFile = filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return srv, caFile, base64.StdEncoding.EncodeToString(sum[:])
+}
+
+func runMirror(t *testing.T, url string, extra ...string) (stdout, stderr string) {
+	t.Helper()
+	args := append([]string{"-bundle-url", url + "/enterprise-attack.json", "-mitigation", "M1037",
+		"-count", "-retries", "0"}, extra...)
+	return runMitremit(t, getBinary(t), map[string]string{envMITRECacheDir: t.TempDir()}, args...)
+}
+
+func TestTLS_CAFileTrustsPrivateMirror(t *testing.T) {
+	srv, caFile, _ := tlsMirror(t)
+	if stdout, stderr := runMirror(t, srv.URL); strings.TrimSpace(stdout) == "5" {
+		t.Fatalf("self-signed mirror must not be trusted without -ca-file; stderr:\n%s", stderr)
+	}
+	stdout, stderr := runMirror(t, srv.URL, "-ca-file", caFile)
+	if strings.TrimSpace(stdout) != "5" {
+		t.Errorf("expected download via -ca-file; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+}
+
+func TestTLS_PinMatchAndMismatch(t *testing.T) {
+	srv, caFile, pin := tlsMirror(t)
+	stdout, stderr := runMirror(t, srv.URL, "-ca-file", caFile, "-pin-sha256", pin)
+	if strings.TrimSpace(stdout) != "5" {
+		t.Errorf("expected download with a matching pin; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	wrong := base64.StdEncoding.EncodeToString(make([]byte, sha256.Size))
+	stdout, stderr = runMirror(t, srv.URL, "-ca-file", caFile, "-pin-sha256", wrong)
+	if stdout != "" || !strings.Contains(stderr, "does not match -pin-sha256") {
+		t.Errorf("expected pin mismatch failure; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+}
+
+func TestTLS_InvalidFlagsRejectedEarly(t *testing.T) {
+	bin := getBinary(t)
+	_, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-pin-sha256", "not-base64!", "-mitigation", "M1037")
+	if !strings.Contains(stderr, "invalid -pin-sha256") {
+		t.Errorf("expected error about malformed pin; stderr:\n%s", stderr)
+	}
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, []byte("no certs here\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, stderr = runMitremit(t, bin, fixtureCacheDir(t), "-ca-file", empty, "-mitigation", "M1037")
+	if !strings.Contains(stderr, "contains no PEM certificates") {
+		t.Errorf("expected error about CA file without certificates; stderr:\n%s", stderr)
+	}
+}
@@ -0,0 +1,60 @@
+// Тесты под-техник: вложение под родителя (-tree) и отбрасывание (-no-subtechniques).
+package tests
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTree_JSONNestsSubtechniques(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-tree", "-json")
+	var nodes []struct {
+		ExternalID    string `json:"external_id"`
+		Subtechniques []struct {
+			ExternalID string `json:"external_id"`
+		} `json:"subtechniques"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &nodes); err != nil {
+		t.Fatalf("expected JSON array (err %v); stdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	for _, n := range nodes {
+		if n.ExternalID == "T1071.001" {
+			t.Errorf("sub-technique T1071.001 must not be at the top level")
+		}
+		if n.ExternalID == "T1071" {
+			if len(n.Subtechniques) != 1 || n.Subtechniques[0].ExternalID != "T1071.001" {
+				t.Errorf("T1071 subtechniques = %+v, want [T1071.001]", n.Subtechniques)
+			}
+			return
+		}
+	}
+	t.Errorf("parent T1071 not found in tree:\n%s", stdout)
+}
+
+func TestTree_TableIndentsSubtechniques(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-tree")
+	parent := strings.Index(stdout, "\nT1071 ")
+	child := strings.Index(stdout, "  └ T1071.001")
+	if parent < 0 || child < 0 || child < parent {
+		t.Errorf("expected indented T1071.001 right under T1071:\n%s", stdout)
+	}
+}
+
+func TestNoSubtechniques_DropsDottedIDs(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-no-subtechniques", "-json")
+	var techs []struct {
+		ExternalID string `json:"external_id"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &techs); err != nil || len(techs) == 0 {
+		t.Fatalf("expected non-empty JSON array (err %v); stdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	for _, tech := range techs {
+		if strings.Contains(tech.ExternalID, ".") {
+			t.Errorf("sub-technique %s must be filtered out", tech.ExternalID)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+// Тесты TSV-вывода (-tsv).
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTSV_SameColumnsAsCSV(t *testing.T) {
+	bin := getBinary(t)
+	env := fixtureCacheDir(t)
+	stdout, stderr := runMitremit(t, bin, env, "-mitigation", "M1037", "-tsv", "-show-description", "-desc-width", "0")
+	lines := strings.Split(strings.TrimSuffix(stdout, "\n"), "\n")
+	if len(lines) < 2 {
+		t.Fatalf("expected header and rows; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	wantHeader := "Mitigation ID\tMitigation Name\tTechnique ID\tTechnique Name\tTactics\tPlatforms\tDescription"
+	if lines[0] != wantHeader {
+		t.Errorf("header = %q, want %q", lines[0], wantHeader)
+	}
+	// Запятые и кавычки в названии не экранируются, каждая строка — ровно 7 колонок
+	if !strings.Contains(stdout, "\tT1090\tProxy, \"Relay\" | Hop\t") {
+		t.Errorf("technique name with comma should be emitted verbatim:\n%s", stdout)
+	}
+	for _, line := range lines {
+		if n := strings.Count(line, "\t"); n != 6 {
+			t.Errorf("line has %d tabs, want 6: %q", n, line)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+// Тесты -version: версия сборки и spec_version бандла из кэша.
+package tests
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVersion_ReportsToolAndCachedSpecVersion(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-version")
+	if !strings.HasPrefix(stdout, "mitremit ") {
+		t.Errorf("expected tool version line first; stdout:\n%s\nstderr:\n%s", stdout, stderr)
+	}
+	if !strings.Contains(stdout, "spec_version 2.0") {
+		t.Errorf("expected spec_version of the cached bundle; stdout:\n%s", stdout)
+	}
+}
+
+func TestVersion_NoCacheDoesNotDownload(t *testing.T) {
+	bin := getBinary(t)
+	stdout, stderr := runMitremit(t, bin, map[string]string{envMITRECacheDir: t.TempDir()}, "-version", "-debug")
+	if !strings.Contains(stdout, "bundle: not cached") {
+		t.Errorf("expected 'not cached' without a cached bundle; stdout:\n%s", stdout)
+	}
+	if strings.Contains(stderr, "downloading") {
+		t.Errorf("-version must not download the bundle; stderr:\n%s", stderr)
+	}
+}
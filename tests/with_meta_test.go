@@ -0,0 +1,103 @@
+// Тесты обёртки вывода метаданными (-with-meta).
+package tests
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// bundleWithCollection пишет копию тестового бандла с объектом x-mitre-collection версии version.
+func bundleWithCollection(t *testing.T, version string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(repoRoot(t), "tests", "testdata", cacheFilename))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bundle map[string]any
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		t.Fatal(err)
+	}
+	bundle["objects"] = append(bundle["objects"].([]any), map[string]any{
+		"type": "x-mitre-collection", "id": "x-mitre-collection--fixture", "name": "Enterprise ATT&CK",
+		"x_mitre_version": version,
+	})
+	out, _ := json.Marshal(bundle)
+	path := filepath.Join(t.TempDir(), "bundle.json")
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestWithMeta_WrapsJSON(t *testing.T) {
+	bin := getBinary(t)
+	bundle := bundleWithCollection(t, "15.1")
+	stdout, stderr := runMitremit(t, bin, nil, "-bundle-file", bundle, "-mitigation", "M1040", "-json", "-with-meta")
+	var rep struct {
+		Mitigation struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"mitigation"`
+		Techniques []struct {
+			ExternalID string `json:"external_id"`
+		} `json:"techniques"`
+		GeneratedAt   string `json:"generated_at"`
+		AttackVersion string `json:"attack_version"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &rep); err != nil {
+		t.Fatalf("bad JSON: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	if rep.Mitigation.ID != "M1040" || rep.Mitigation.Name != "Behavior Prevention on Endpoint" {
+		t.Errorf("mitigation = %+v", rep.Mitigation)
+	}
+	if len(rep.Techniques) != 2 {
+		t.Errorf("expected 2 techniques, got %+v", rep.Techniques)
+	}
+	if rep.GeneratedAt == "" || rep.AttackVersion != "15.1" {
+		t.Errorf("generated_at = %q, attack_version = %q (want 15.1)", rep.GeneratedAt, rep.AttackVersion)
+	}
+}
+
+func TestWithMeta_CSVColumns(t *testing.T) {
+	bin := getBinary(t)
+	bundle := bundleWithCollection(t, "15.1")
+	stdout, stderr := runMitremit(t, bin, nil, "-bundle-file", bundle, "-mitigation", "M1040", "-csv", "-with-meta")
+	records, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil {
+		t.Fatalf("-with-meta must keep CSV parseable: %v\nstdout:\n%s\nstderr:\n%s", err, stdout, stderr)
+	}
+	header := records[0]
+	if header[0] != "Mitigation ID" || header[len(header)-2] != "Generated At" || header[len(header)-1] != "ATT&CK Version" {
+		t.Fatalf("unexpected header %q", header)
+	}
+	for _, rec := range records[1:] {
+		if rec[len(rec)-2] == "" || rec[len(rec)-1] != "15.1" {
+			t.Errorf("row %q lacks metadata columns", rec)
+		}
+	}
+}
+
+func TestWithMeta_DiffAgainstAcceptsWrappedOutput(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-json", "-with-meta")
+	path := filepath.Join(t.TempDir(), "prev.json")
+	if err := os.WriteFile(path, []byte(stdout), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	out, stderr := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-diff-against", path)
+	if strings.TrimSpace(out) != "" || strings.Contains(stderr, "ERROR") {
+		t.Errorf("expected no changes against -with-meta output, got:\n%s\nstderr:\n%s", out, stderr)
+	}
+}
+
+func TestWithMeta_DefaultStaysArray(t *testing.T) {
+	bin := getBinary(t)
+	stdout, _ := runMitremit(t, bin, fixtureCacheDir(t), "-mitigation", "M1037", "-json")
+	if !strings.HasPrefix(strings.TrimSpace(stdout), "[") {
+		t.Errorf("without -with-meta JSON must stay a bare array; stdout:\n%s", stdout)
+	}
+}